@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// parseAgeRecipient decodes an age1... X25519 recipient
+func parseAgeRecipient(recipient string) (*age.X25519Recipient, error) {
+	key, err := age.ParseX25519Recipient(strings.TrimSpace(recipient))
+	if err != nil {
+		return nil, fmt.Errorf("invalid age recipient: %w", err)
+	}
+	return key, nil
+}
+
+// ageEncrypt encrypts plaintext to the X25519 recipients, or to passphrase
+// when there are none, as an age v1 file; passphrases are stretched with
+// scrypt at the work factor of the age CLI
+func ageEncrypt(plaintext []byte, recipients []age.Recipient, passphrase string) ([]byte, error) {
+	if len(recipients) == 0 {
+		recipient, err := age.NewScryptRecipient(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		recipients = []age.Recipient{recipient}
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
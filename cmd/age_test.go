@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"filippo.io/age"
+)
+
+func ageDecrypt(t *testing.T, data []byte, identity age.Identity) []byte {
+	t.Helper()
+	r, err := age.Decrypt(bytes.NewReader(data), identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return plaintext
+}
+
+func TestAgeEncryptToRecipients(t *testing.T) {
+	identities := make([]*age.X25519Identity, 2)
+	var recipients []age.Recipient
+	for i := range identities {
+		identity, err := age.GenerateX25519Identity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipient, err := parseAgeRecipient(" " + identity.Recipient().String() + "\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		identities[i], recipients = identity, append(recipients, recipient)
+	}
+
+	// More than one STREAM chunk of 64 KiB
+	plaintext := bytes.Repeat([]byte("private key\n"), 10000)
+	data, err := ageEncrypt(plaintext, recipients, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, identity := range identities {
+		if got := ageDecrypt(t, data, identity); !bytes.Equal(got, plaintext) {
+			t.Errorf("decrypted %d bytes, want %d", len(got), len(plaintext))
+		}
+	}
+}
+
+func TestAgeEncryptToPassphrase(t *testing.T) {
+	data, err := ageEncrypt([]byte("private key"), nil, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := age.NewScryptIdentity("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ageDecrypt(t, data, identity); string(got) != "private key" {
+		t.Errorf("decrypted %q", got)
+	}
+}
+
+func TestParseAgeRecipientRejectsOtherKeys(t *testing.T) {
+	for _, recipient := range []string{"age1", "AGE-SECRET-KEY-1GFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPYYSJZGFPQ4EGAEX", "npub180cvv07tjdrrgpa0j7j7tmnyl2yr6yr7l8j4s3evf6u64th6gkwsyjh6w6"} {
+		if _, err := parseAgeRecipient(recipient); err == nil {
+			t.Errorf("parseAgeRecipient(%q) succeeded", recipient)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// aleoCLI is the snarkOS binary used to create Aleo accounts; the account
+// derivation hashes with Poseidon over BLS12-377, which has no Go implementation
+const aleoCLI = "snarkos"
+
+// generateAleoKeyPair runs snarkos account new and returns the private key and
+// address, with the view key needed for record scanning as an extra
+func generateAleoKeyPair() (string, string, keyExtras, error) {
+	out, err := exec.Command(aleoCLI, "account", "new").Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", "", nil, fmt.Errorf("%s not found in PATH, install it from https://github.com/ProvableHQ/snarkOS", aleoCLI)
+	}
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error running %s account new: %w", aleoCLI, err)
+	}
+
+	// Each value is printed on its own line after a label such as "View Key"
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		for _, word := range strings.Fields(scanner.Text()) {
+			switch {
+			case strings.HasPrefix(word, "APrivateKey1"):
+				fields["privateKey"] = word
+			case strings.HasPrefix(word, "AViewKey1"):
+				fields["viewKey"] = word
+			case strings.HasPrefix(word, "aleo1"):
+				fields["address"] = word
+			}
+		}
+	}
+	if fields["privateKey"] == "" || fields["viewKey"] == "" || fields["address"] == "" {
+		return "", "", nil, fmt.Errorf("unexpected %s account new output", aleoCLI)
+	}
+
+	extra := keyExtras{
+		"viewKey": secretExtra(fields["viewKey"]),
+	}
+
+	return fields["privateKey"], fields["address"], extra, nil
+}
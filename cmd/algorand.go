@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+const algorandChecksumLength = 4
+
+// algorandAddress encodes a public key as base32(pubkey || checksum) where the
+// checksum is the last four bytes of its SHA-512/256 digest
+func algorandAddress(pubKey ed25519.PublicKey) string {
+	digest := sha512.Sum512_256(pubKey)
+	payload := append(append([]byte{}, pubKey...), digest[len(digest)-algorandChecksumLength:]...)
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(payload)
+}
+
+// toUint11Array packs bytes into 11-bit little-endian groups, the order used
+// by Algorand mnemonics
+func toUint11Array(data []byte) []uint32 {
+	var buffer, bits uint32
+	var output []uint32
+	for _, b := range data {
+		buffer |= uint32(b) << bits
+		bits += 8
+		if bits >= 11 {
+			output = append(output, buffer&0x7ff)
+			buffer >>= 11
+			bits -= 11
+		}
+	}
+	if bits != 0 {
+		output = append(output, buffer&0x7ff)
+	}
+	return output
+}
+
+// algorandMnemonic renders a 32-byte seed as 24 words plus a checksum word
+// taken from the first 11 bits of its SHA-512/256 digest
+func algorandMnemonic(seed []byte) string {
+	words := make([]string, 0, 25)
+	for _, idx := range toUint11Array(seed) {
+		words = append(words, wordlists.English[idx])
+	}
+	digest := sha512.Sum512_256(seed)
+	words = append(words, wordlists.English[toUint11Array(digest[:2])[0]])
+	return strings.Join(words, " ")
+}
+
+// generateAlgorandKeyPair returns the base64 64-byte secret key used by the
+// SDKs and the account address, with the 25-word mnemonic as an extra
+func generateAlgorandKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	pubKey, privKey, err := newEd25519Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	extra := keyExtras{
+		"mnemonic": secretExtra(algorandMnemonic(privKey.Seed())),
+	}
+
+	return base64.StdEncoding.EncodeToString(privKey), algorandAddress(pubKey), extra, nil
+}
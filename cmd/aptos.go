@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	aptosEd25519Scheme    = 0x00
+	aptosPrivateKeyPrefix = "ed25519-priv-"
+)
+
+// generateAptosKeyPair returns an AIP-80 private key string and the account
+// address, which for a fresh account equals its authentication key
+func generateAptosKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return "", "", nil, err
+	}
+	return aptosKeyPair(seed)
+}
+
+// aptosKeyPair returns the AIP-80 private key and account address of an
+// ed25519 seed, with the public key as an extra
+func aptosKeyPair(seed []byte) (string, string, keyExtras, error) {
+	pubKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	// authentication key = sha3-256(pubkey || scheme)
+	hasher := sha3.New256()
+	hasher.Write(pubKey)
+	hasher.Write([]byte{aptosEd25519Scheme})
+	authKey := hasher.Sum(nil)
+
+	extra := keyExtras{
+		"publicKey": publicExtra("0x" + hex.EncodeToString(pubKey)),
+	}
+
+	return aptosPrivateKeyPrefix + "0x" + hex.EncodeToString(seed), "0x" + hex.EncodeToString(authKey), extra, nil
+}
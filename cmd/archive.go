@@ -0,0 +1,240 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// archiveFormats are the extensions -archive accepts
+var archiveFormats = []string{".zip", ".tar.age"}
+
+// writtenFiles are the files this run wrote, in order, which -archive
+// bundles and then removes
+var writtenFiles []string
+
+// WinZip AES (AE-2) encryption of zip entries, which 7-Zip, WinZip, macOS's
+// Archive Utility and libarchive open. AE-2 leaves the CRC out, as it would
+// leak a checksum of the plaintext
+const (
+	zipMethodAES     = 99
+	zipAESExtraID    = 0x9901
+	zipAESVersion    = 2
+	zipAESStrength   = 3 // AES-256
+	zipAESKeySize    = 32
+	zipAESSaltSize   = 16
+	zipAESIterations = 1000
+	zipAESMACSize    = 10
+	// zipAESReaderVersion is the version needed to extract AES entries, 5.1
+	zipAESReaderVersion = 51
+)
+
+// zipAESEncrypt encrypts a compressed entry as salt || password verifier ||
+// ciphertext || authentication code
+func zipAESEncrypt(compressed []byte, password string) ([]byte, error) {
+	salt := make([]byte, zipAESSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	keys := pbkdf2.Key([]byte(password), salt, zipAESIterations, 2*zipAESKeySize+2, sha1.New)
+	block, err := aes.NewCipher(keys[:zipAESKeySize])
+	if err != nil {
+		return nil, err
+	}
+
+	// WinZip's CTR mode counts little-endian from 1, unlike crypto/cipher's
+	ciphertext := make([]byte, len(compressed))
+	var counter, stream [aes.BlockSize]byte
+	for i := 0; i < len(compressed); i += aes.BlockSize {
+		for j := range counter {
+			counter[j]++
+			if counter[j] != 0 {
+				break
+			}
+		}
+		block.Encrypt(stream[:], counter[:])
+		for j := i; j < min(i+aes.BlockSize, len(compressed)); j++ {
+			ciphertext[j] = compressed[j] ^ stream[j-i]
+		}
+	}
+
+	mac := hmac.New(sha1.New, keys[zipAESKeySize:2*zipAESKeySize])
+	mac.Write(ciphertext)
+	out := append(salt, keys[2*zipAESKeySize:]...)
+	out = append(out, ciphertext...)
+	return append(out, mac.Sum(nil)[:zipAESMACSize]...), nil
+}
+
+// zipDOSTime returns t in the MS-DOS date and time fields of zip headers
+func zipDOSTime(t time.Time) (date, clock uint16) {
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
+
+// zipAESArchive bundles files into a zip of deflated, AES-256 encrypted entries
+func zipAESArchive(files []string, password string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var compressed bytes.Buffer
+		fw, err := flate.NewWriter(&compressed, flate.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := fw.Close(); err != nil {
+			return nil, err
+		}
+		encrypted, err := zipAESEncrypt(compressed.Bytes(), password)
+		if err != nil {
+			return nil, err
+		}
+
+		extra := binary.LittleEndian.AppendUint16(nil, zipAESExtraID)
+		extra = binary.LittleEndian.AppendUint16(extra, 7)
+		extra = binary.LittleEndian.AppendUint16(extra, zipAESVersion)
+		extra = append(extra, 'A', 'E', zipAESStrength)
+		extra = binary.LittleEndian.AppendUint16(extra, zip.Deflate)
+		header := &zip.FileHeader{
+			Name:               archiveEntryName(path),
+			CreatorVersion:     3<<8 | zipAESReaderVersion, // Unix
+			ReaderVersion:      zipAESReaderVersion,
+			Flags:              0x1, // encrypted
+			Method:             zipMethodAES,
+			Extra:              extra,
+			CompressedSize64:   uint64(len(encrypted)),
+			UncompressedSize64: uint64(len(data)),
+		}
+		header.ModifiedDate, header.ModifiedTime = zipDOSTime(time.Now())
+		header.SetMode(0o600)
+		entry, err := w.CreateRaw(header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(encrypted); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// tarAgeArchive bundles files into a tarball encrypted with age to password
+func tarAgeArchive(files []string, password string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		header := &tar.Header{
+			Name:    archiveEntryName(path),
+			Mode:    0o600,
+			Size:    int64(len(data)),
+			ModTime: time.Now(),
+			Format:  tar.FormatPAX,
+		}
+		if err := w.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return ageEncrypt(buf.Bytes(), nil, password)
+}
+
+// archiveEntryName returns the name of path inside an archive: relative, and
+// never above the directory it is extracted to
+func archiveEntryName(path string) string {
+	name := filepath.ToSlash(filepath.Clean(path))
+	name = strings.TrimLeft(name, "/")
+	for strings.HasPrefix(name, "../") {
+		name = strings.TrimPrefix(name, "../")
+	}
+	return name
+}
+
+// writeArchive bundles files into an encrypted archive at path, whose
+// extension is one of archiveFormats, then removes those the run created, and
+// the directories they leave empty, so only the sealed archive is left. Files
+// that existed before the run, and devices, are never removed
+func writeArchive(path, password string, files []string) error {
+	var data []byte
+	var err error
+	if strings.HasSuffix(path, ".zip") {
+		data, err = zipAESArchive(files, password)
+	} else {
+		data, err = tarAgeArchive(files, password)
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeOutputFile(path, data); err != nil {
+		return err
+	}
+
+	var dirs []string
+	for _, file := range files {
+		if !createdFiles[file] {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return err
+		}
+		// Relative directories are removed up to the working directory,
+		// absolute ones only where the files were
+		dirs = append(dirs, filepath.Dir(file))
+		if !filepath.IsAbs(file) {
+			for dir := filepath.Dir(filepath.Dir(file)); dir != "." && dir != ".." && !strings.HasSuffix(dir, "/.."); dir = filepath.Dir(dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	// Deepest first; directories still holding other files stay
+	slices.SortFunc(dirs, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	for _, dir := range slices.Compact(dirs) {
+		if dir != "." {
+			os.Remove(dir)
+		}
+	}
+	return nil
+}
+
+// validArchivePath reports whether path has one of archiveFormats
+func validArchivePath(path string) bool {
+	return slices.ContainsFunc(archiveFormats, func(extension string) bool {
+		return strings.HasSuffix(path, extension)
+	})
+}
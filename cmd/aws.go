@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	kmstypes "github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+)
+
+// Secrets Manager, KMS and S3 are called with the AWS SDK, which finds
+// credentials and the region as the AWS CLI does: the environment variables,
+// the shared config and credentials files, SSO, and instance and container
+// roles
+
+// loadAWSConfig loads the default AWS configuration, which must name a region
+func loadAWSConfig(ctx context.Context) (aws.Config, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return aws.Config{}, err
+	}
+	if cfg.Region == "" {
+		return aws.Config{}, errors.New("no AWS region, set AWS_REGION")
+	}
+	return cfg, nil
+}
+
+// newKMSClient returns a KMS client of the default AWS configuration
+func newKMSClient(ctx context.Context) (*kms.Client, error) {
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return kms.NewFromConfig(cfg), nil
+}
+
+// parseAWSTags parses comma-separated key=value tags
+func parseAWSTags(s string) ([]smtypes.Tag, error) {
+	var tags []smtypes.Tag
+	if s == "" {
+		return nil, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("tag %q is not key=value", pair)
+		}
+		tags = append(tags, smtypes.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return tags, nil
+}
+
+// awsSecretNameTemplate names the secrets of -aws-secrets by default
+const awsSecretNameTemplate = "{{.Type}}/{{.Address}}"
+
+// parseAWSSecretName parses a -aws-secret-name template, executed with each
+// keyRecord
+func parseAWSSecretName(name string) (*template.Template, error) {
+	return template.New("aws-secret-name").Funcs(templateFuncs).Option("missingkey=error").Parse(name)
+}
+
+// writeAWSSecrets creates a Secrets Manager secret per keypair of result,
+// numbered from startIndex, named by executing name with its keyRecord. The
+// secret string is the JSON of its address, private key and chain-specific
+// values; it is encrypted with the KMS key kmsKeyID, or the account's
+// aws/secretsmanager key if empty. Existing secrets are never overwritten.
+// The number of secrets created is returned
+func writeAWSSecrets(result KeyGenResult, startIndex int, name *template.Template, tags []smtypes.Tag, kmsKeyID string) (int, error) {
+	ctx := context.Background()
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return 0, err
+	}
+	client := secretsmanager.NewFromConfig(cfg)
+	for i, record := range keyRecords(result, startIndex) {
+		var secretName strings.Builder
+		if err := name.Execute(&secretName, record); err != nil {
+			return i, err
+		}
+		value := map[string]string{"address": record.Address, "privateKey": record.PrivateKey}
+		for k, v := range record.Extra {
+			value[k] = v
+		}
+		secret, err := json.Marshal(value)
+		if err != nil {
+			return i, err
+		}
+
+		input := &secretsmanager.CreateSecretInput{
+			Name:         aws.String(secretName.String()),
+			SecretString: aws.String(string(secret)),
+			Description:  aws.String(fmt.Sprintf("%s key %d generated %s", record.Type, record.Index, result.Timestamp)),
+			Tags:         tags,
+		}
+		if kmsKeyID != "" {
+			input.KmsKeyId = aws.String(kmsKeyID)
+		}
+		if _, err := client.CreateSecret(ctx, input); err != nil {
+			return i, err
+		}
+	}
+	return len(result.PublicKeys), nil
+}
+
+// kmsEnvelope is a file encrypted with -encrypt-kms: AES-256-GCM under a data
+// key, which is stored encrypted with the KMS key
+type kmsEnvelope struct {
+	KMSKeyID         string `json:"kmsKeyId"`
+	EncryptedDataKey []byte `json:"encryptedDataKey"`
+	Nonce            []byte `json:"nonce"`
+	Ciphertext       []byte `json:"ciphertext"`
+}
+
+// kmsDataKey is a data key generated by KMS, in plaintext and encrypted
+type kmsDataKey struct {
+	KeyID                     string
+	Plaintext, CiphertextBlob []byte
+}
+
+// generateKMSDataKey asks KMS for a new AES-256 data key under keyID
+func generateKMSDataKey(keyID string) (kmsDataKey, error) {
+	ctx := context.Background()
+	client, err := newKMSClient(ctx)
+	if err != nil {
+		return kmsDataKey{}, err
+	}
+	out, err := client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{KeyId: aws.String(keyID), KeySpec: kmstypes.DataKeySpecAes256})
+	if err != nil {
+		return kmsDataKey{}, err
+	}
+	return kmsDataKey{KeyID: aws.ToString(out.KeyId), Plaintext: out.Plaintext, CiphertextBlob: out.CiphertextBlob}, nil
+}
+
+// kmsEncrypt seals plaintext in a kmsEnvelope under key
+func kmsEncrypt(plaintext []byte, key kmsDataKey) ([]byte, error) {
+	block, err := aes.NewCipher(key.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(kmsEnvelope{
+		KMSKeyID:         key.KeyID,
+		EncryptedDataKey: key.CiphertextBlob,
+		Nonce:            nonce,
+		Ciphertext:       gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+// kmsDecrypt opens a kmsEnvelope, having KMS decrypt its data key
+func kmsDecrypt(data []byte) ([]byte, error) {
+	var envelope kmsEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	client, err := newKMSClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, err := client.Decrypt(ctx, &kms.DecryptInput{CiphertextBlob: envelope.EncryptedDataKey, KeyId: aws.String(envelope.KMSKeyID)})
+	if err != nil {
+		return nil, err
+	}
+	defer clear(key.Plaintext)
+	block, err := aes.NewCipher(key.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+}
+
+// runKMSDecrypt implements the kms-decrypt subcommand, which opens a results
+// file encrypted with -encrypt-kms
+func runKMSDecrypt(args []string) {
+	fs := flag.NewFlagSet("kms-decrypt", flag.ExitOnError)
+	in := fs.String("in", "", "Results file encrypted with -encrypt-kms")
+	out := fs.String("out", "-", "Write the decrypted results to this file, '-' for stdout")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *in == "" {
+		fmt.Println("Error: Input file is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", *in, err)
+		os.Exit(1)
+	}
+	plaintext, err := kmsDecrypt(data)
+	if err != nil {
+		fmt.Printf("Error decrypting %s: %v\n", *in, err)
+		os.Exit(1)
+	}
+	if *out == "-" {
+		os.Stdout.Write(plaintext)
+		return
+	}
+	if err := writeOutputFile(*out, plaintext); err != nil {
+		fmt.Printf("Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withAWSEndpoint points the AWS SDK at a test server of handler
+func withAWSEndpoint(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	dir := t.TempDir()
+	t.Setenv("AWS_CONFIG_FILE", dir+"/config")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", dir+"/credentials")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_ENDPOINT_URL_S3", "")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+}
+
+func TestKMSEnvelopeRoundTrip(t *testing.T) {
+	dataKey := bytes.Repeat([]byte{7}, 32)
+	blob := []byte("encrypted data key")
+	withAWSEndpoint(t, func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]any
+		json.NewDecoder(r.Body).Decode(&input)
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GenerateDataKey":
+			if input["KeyId"] != "alias/keys" || input["KeySpec"] != "AES_256" {
+				t.Errorf("GenerateDataKey input %v", input)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"KeyId": "arn:aws:kms:us-east-1:111122223333:key/1", "Plaintext": dataKey, "CiphertextBlob": blob})
+		case "TrentService.Decrypt":
+			if input["KeyId"] != "arn:aws:kms:us-east-1:111122223333:key/1" || input["CiphertextBlob"] != "ZW5jcnlwdGVkIGRhdGEga2V5" {
+				t.Errorf("Decrypt input %v", input)
+			}
+			json.NewEncoder(w).Encode(map[string]any{"KeyId": input["KeyId"], "Plaintext": dataKey})
+		default:
+			http.Error(w, "unexpected action", http.StatusBadRequest)
+		}
+	})
+
+	key, err := generateKMSDataKey("alias/keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := kmsEncrypt([]byte("private keys"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := kmsDecrypt(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(opened) != "private keys" {
+		t.Fatalf("kmsDecrypt = %q", opened)
+	}
+}
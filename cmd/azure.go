@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// newAzureSecretsClient returns a Key Vault client of the vault, a name or a
+// URL. It authenticates with the Azure SDK's default credential: the client
+// secret or certificate of the AZURE_* variables, workload identity, the
+// managed identity of the environment, or a login of the Azure CLI or
+// Developer CLI
+func newAzureSecretsClient(vault string) (*azsecrets.Client, error) {
+	if !strings.Contains(vault, "://") {
+		vault = "https://" + vault + ".vault.azure.net"
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	return azsecrets.NewClient(vault, cred, nil)
+}
+
+// azureSecretNameTemplate names the secrets of -azure-vault by default
+const azureSecretNameTemplate = "{{.Type}}-{{.Address}}"
+
+// parseAzureSecretName parses a -azure-secret-name template, executed with
+// each keyRecord
+func parseAzureSecretName(name string) (*template.Template, error) {
+	return template.New("azure-secret-name").Funcs(templateFuncs).Option("missingkey=error").Parse(name)
+}
+
+// validAzureSecretName reports whether name is 1 to 127 letters, digits and
+// dashes, as Key Vault requires
+func validAzureSecretName(name string) bool {
+	if name == "" || len(name) > 127 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseAzureTags parses comma-separated key=value tags
+func parseAzureTags(s string) (map[string]string, error) {
+	tags := make(map[string]string)
+	if s == "" {
+		return tags, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("tag %q is not key=value", pair)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// writeAzureSecrets sets a secret of client per keypair of result, numbered
+// from startIndex, named by executing name with its keyRecord. The value is
+// the JSON of its address, private key and chain-specific values, and the
+// secret is tagged with its key type and index besides tags. A secret that
+// already exists, even deleted but recoverable, is never given a new
+// version. The number of secrets set is returned
+func writeAzureSecrets(client *azsecrets.Client, result KeyGenResult, startIndex int, name *template.Template, tags map[string]string) (int, error) {
+	ctx := context.Background()
+	for i, record := range keyRecords(result, startIndex) {
+		var secretName strings.Builder
+		if err := name.Execute(&secretName, record); err != nil {
+			return i, err
+		}
+		if !validAzureSecretName(secretName.String()) {
+			return i, fmt.Errorf("secret name %q is not 1 to 127 letters, digits and dashes", secretName.String())
+		}
+		value := map[string]string{"address": record.Address, "privateKey": record.PrivateKey}
+		for k, v := range record.Extra {
+			value[k] = v
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return i, err
+		}
+
+		// Setting a secret that exists adds a version, so it is checked first
+		_, err = client.GetSecret(ctx, secretName.String(), "", nil)
+		var respErr *azcore.ResponseError
+		if err == nil {
+			return i, fmt.Errorf("secret %s already exists", secretName.String())
+		} else if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusNotFound {
+			return i, err
+		}
+
+		secretTags := map[string]*string{"keyType": to.Ptr(record.Type), "index": to.Ptr(strconv.Itoa(record.Index))}
+		for k, v := range tags {
+			secretTags[k] = to.Ptr(v)
+		}
+		params := azsecrets.SetSecretParameters{Value: to.Ptr(string(data)), ContentType: to.Ptr("application/json"), Tags: secretTags}
+		if _, err := client.SetSecret(ctx, secretName.String(), params, nil); err != nil {
+			return i, err
+		}
+	}
+	return len(result.PublicKeys), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+// staticCredential is an Azure credential of a fixed token
+type staticCredential string
+
+func (c staticCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: string(c), ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// newTestVault returns a client of a Key Vault test server holding secrets
+func newTestVault(t *testing.T, secrets map[string]map[string]any) *azsecrets.Client {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer vault-token" {
+			w.Header().Set("WWW-Authenticate", `Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// GET /secrets/{name}/{version} and PUT /secrets/{name}
+		name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/secrets/"), "/")
+		switch r.Method {
+		case http.MethodGet:
+			if _, ok := secrets[name]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"error":{"code":"SecretNotFound","message":"A secret with (name/id) `+name+` was not found in this key vault."}}`)
+				return
+			}
+			json.NewEncoder(w).Encode(secrets[name])
+		case http.MethodPut:
+			var body map[string]any
+			json.NewDecoder(r.Body).Decode(&body)
+			secrets[name] = body
+			json.NewEncoder(w).Encode(body)
+		}
+	}))
+	t.Cleanup(server.Close)
+	client, err := azsecrets.NewClient(server.URL, staticCredential("vault-token"), &azsecrets.ClientOptions{
+		ClientOptions:                        azcore.ClientOptions{Transport: server.Client()},
+		DisableChallengeResourceVerification: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestWriteAzureSecrets(t *testing.T) {
+	secrets := map[string]map[string]any{}
+	client := newTestVault(t, secrets)
+	name, err := parseAzureSecretName(azureSecretNameTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := KeyGenResult{KeyType: "evm", PrivateKeys: []string{"01", "02"}, PublicKeys: []string{"0xabc", "0xdef"}}
+	written, err := writeAzureSecrets(client, result, 5, name, map[string]string{"env": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 2 || len(secrets) != 2 {
+		t.Fatalf("%d secrets written, %d stored, want 2", written, len(secrets))
+	}
+	secret := secrets["evm-0xdef"]
+	if secret["value"] != `{"address":"0xdef","privateKey":"02"}` || secret["contentType"] != "application/json" {
+		t.Errorf("secret %v", secret)
+	}
+	tags, _ := secret["tags"].(map[string]any)
+	if tags["keyType"] != "evm" || tags["index"] != "6" || tags["env"] != "prod" {
+		t.Errorf("secret tags %v", tags)
+	}
+}
+
+func TestWriteAzureSecretsRefusesExistingSecrets(t *testing.T) {
+	secrets := map[string]map[string]any{"evm-0xdef": {"value": "kept"}}
+	client := newTestVault(t, secrets)
+	name, err := parseAzureSecretName(azureSecretNameTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := KeyGenResult{KeyType: "evm", PrivateKeys: []string{"01", "02"}, PublicKeys: []string{"0xabc", "0xdef"}}
+	written, err := writeAzureSecrets(client, result, 0, name, nil)
+	if err == nil || err.Error() != "secret evm-0xdef already exists" || written != 1 {
+		t.Fatalf("writeAzureSecrets = %d, %v, want 1 and an already exists error", written, err)
+	}
+	if secrets["evm-0xdef"]["value"] != "kept" {
+		t.Errorf("existing secret overwritten with %v", secrets["evm-0xdef"])
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const bchP2PKHVersion = 0x00
+
+// bchNetworks pairs the Bitcoin parameters BCH shares for WIF and legacy
+// addresses with the CashAddr prefix of each -network value
+var bchNetworks = map[string]struct {
+	params *chaincfg.Params
+	prefix string
+}{
+	"mainnet": {&chaincfg.MainNetParams, "bitcoincash"},
+	"testnet": {&chaincfg.TestNet3Params, "bchtest"},
+}
+
+// generateBCHKeyPair returns a WIF key and its CashAddr P2PKH address, with
+// the legacy base58 address and compressed public key as extras
+func generateBCHKeyPair(random io.Reader, network string) (string, string, keyExtras, error) {
+	net := bchNetworks[network]
+
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	wif, err := btcutil.NewWIF(privateKey, net.params, true)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+	pubKeyHash := btcutil.Hash160(pubKeyBytes)
+
+	address, err := encodeCashAddr(net.prefix, bchP2PKHVersion, pubKeyHash)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating cashaddr address: %w", err)
+	}
+	legacy, err := btcutil.NewAddressPubKeyHash(pubKeyHash, net.params)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating p2pkh address: %w", err)
+	}
+
+	extra := keyExtras{
+		"legacyAddress": publicExtra(legacy.EncodeAddress()),
+		"publicKey":     publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	return wif.String(), address, extra, nil
+}
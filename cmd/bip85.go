@@ -0,0 +1,281 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
+)
+
+const (
+	// bip85Purpose is the first segment of every BIP85 path, "BIPE" in
+	// decimal ASCII
+	bip85Purpose = 83696968
+	bip85HMACKey = "bip-entropy-from-k"
+)
+
+// bip85Apps maps the applications of the bip85 command to their BIP85
+// application numbers
+var bip85Apps = map[string]uint32{
+	"bip39": 39,
+	"hex":   128169,
+	"wif":   2,
+	"xprv":  32,
+}
+
+// bip85Languages maps -wordlist values to BIP85 language codes
+var bip85Languages = map[string]uint32{
+	"english":             0,
+	"japanese":            1,
+	"korean":              2,
+	"spanish":             3,
+	"chinese-simplified":  4,
+	"chinese-traditional": 5,
+	"french":              6,
+	"italian":             7,
+	"czech":               8,
+}
+
+// bip85WordCounts are the mnemonic lengths BIP85 defines child mnemonics for
+var bip85WordCounts = []int{12, 18, 24}
+
+// Bip85Child is one child secret derived from the master key
+type Bip85Child struct {
+	Index          int    `json:"index"`
+	DerivationPath string `json:"derivationPath"`
+	Mnemonic       string `json:"mnemonic,omitempty"`
+	Hex            string `json:"hex,omitempty"`
+	WIF            string `json:"wif,omitempty"`
+	Xprv           string `json:"xprv,omitempty"`
+}
+
+// Bip85Result is the output of the bip85 command
+type Bip85Result struct {
+	App       string       `json:"app"`
+	Count     int          `json:"count"`
+	Timestamp string       `json:"timestamp"`
+	Language  string       `json:"language,omitempty"`
+	Words     int          `json:"words,omitempty"`
+	Bytes     int          `json:"bytes,omitempty"`
+	Children  []Bip85Child `json:"children"`
+}
+
+// bip85Entropy derives the 64 bytes of entropy of the hardened path under
+// master: HMAC-SHA512 of the private key at m/83696968'/path
+func bip85Entropy(master *hdkeychain.ExtendedKey, path []uint32) ([]byte, error) {
+	key := master
+	for _, index := range append([]uint32{bip85Purpose}, path...) {
+		var err error
+		if key, err = key.Derive(index + hardenedOffset); err != nil {
+			return nil, err
+		}
+	}
+	privateKey, err := key.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha512.New, []byte(bip85HMACKey))
+	mac.Write(privateKey.Serialize())
+	return mac.Sum(nil), nil
+}
+
+// bip85Path returns the path below m/83696968' of the index-th child of app
+func bip85Path(app string, index int, language uint32, words, size int) []uint32 {
+	switch app {
+	case "bip39":
+		return []uint32{bip85Apps[app], language, uint32(words), uint32(index)}
+	case "hex":
+		return []uint32{bip85Apps[app], uint32(size), uint32(index)}
+	default:
+		return []uint32{bip85Apps[app], uint32(index)}
+	}
+}
+
+// bip85Child renders the entropy of a child as app does
+func bip85Child(app string, entropy []byte, words, size int) (Bip85Child, error) {
+	var child Bip85Child
+	switch app {
+	case "bip39":
+		// Every word encodes 11 bits, of which one in 33 is checksum
+		mnemonic, err := bip39.NewMnemonic(entropy[:words*4/3])
+		if err != nil {
+			return child, err
+		}
+		child.Mnemonic = strings.ReplaceAll(mnemonic, " ", mnemonicSeparator)
+	case "hex":
+		child.Hex = hex.EncodeToString(entropy[:size])
+	case "wif":
+		privateKey, _ := btcec.PrivKeyFromBytes(entropy[:32])
+		wif, err := btcutil.NewWIF(privateKey, &chaincfg.MainNetParams, true)
+		if err != nil {
+			return child, err
+		}
+		child.WIF = wif.String()
+	case "xprv":
+		// The chain code comes first and the key second, the reverse of
+		// BIP32's master key split
+		key := hdkeychain.NewExtendedKey(chaincfg.MainNetParams.HDPrivateKeyID[:], entropy[32:], entropy[:32], []byte{0, 0, 0, 0}, 0, 0, true)
+		if _, err := key.ECPrivKey(); err != nil {
+			return child, err
+		}
+		child.Xprv = key.String()
+	}
+	return child, nil
+}
+
+func runBip85(args []string) {
+	fs := flag.NewFlagSet("bip85", flag.ExitOnError)
+	app := fs.String("app", "bip39", "Child to derive: "+quoteList(slices.Sorted(maps.Keys(bip85Apps))))
+	fromMnemonic := fs.String("from-mnemonic", "", "BIP39 mnemonic of the master seed, or '-' to prompt for it")
+	mnemonicFile := fs.String("mnemonic-file", "", "Read the BIP39 mnemonic of the master seed from this file")
+	fromSeed := fs.String("from-seed", "", "Hex BIP32 master seed instead of a mnemonic, or '-' to prompt for it")
+	masterXprv := fs.String("xprv", "", "BIP32 root extended private key instead of a mnemonic")
+	wordlist := fs.String("wordlist", "english", "Language of the master mnemonic: english, japanese, korean, spanish, chinese-simplified, chinese-traditional, french, italian or czech")
+	usePassphrase := fs.Bool("passphrase", false, "Prompt for the BIP39 passphrase of the master mnemonic")
+	passphraseFile := fs.String("passphrase-file", "", "Read the BIP39 passphrase of the master mnemonic from this file")
+	passphraseEnv := fs.String("passphrase-env", "", "Read the BIP39 passphrase of the master mnemonic from this environment variable")
+	language := fs.String("language", "english", "bip39 only: language of the child mnemonics")
+	words := fs.Int("words", 12, "bip39 only: number of words of the child mnemonics: 12, 18 or 24")
+	size := fs.Int("bytes", 32, "hex only: number of bytes of entropy, 16 to 64")
+	index := fs.Int("index", 0, "Application index of the first child")
+	count := fs.Int("count", 1, "Number of children to derive at consecutive indexes")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, ok := bip85Apps[*app]; !ok {
+		fmt.Printf("Error: App must be %s\n", quoteList(slices.Sorted(maps.Keys(bip85Apps))))
+		os.Exit(1)
+	}
+	sources := 0
+	for _, set := range []bool{*fromMnemonic != "" || *mnemonicFile != "", *fromSeed != "", *masterXprv != ""} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		fmt.Println("Error: Exactly one of -from-mnemonic, -mnemonic-file, -from-seed or -xprv is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *count <= 0 || *index < 0 || int64(*index)+int64(*count) > hardenedOffset {
+		fmt.Println("Error: Count must be greater than 0 and indexes between 0 and 2^31-1")
+		os.Exit(1)
+	}
+	childLanguage, ok := bip85Languages[*language]
+	if !ok {
+		fmt.Printf("Error: Language must be %s\n", quoteList(slices.Sorted(maps.Keys(bip85Languages))))
+		os.Exit(1)
+	}
+	if *app == "bip39" && !slices.Contains(bip85WordCounts, *words) {
+		fmt.Println("Error: Words must be 12, 18 or 24")
+		os.Exit(1)
+	}
+	if *app == "hex" && (*size < 16 || *size > 64) {
+		fmt.Println("Error: Bytes must be between 16 and 64")
+		os.Exit(1)
+	}
+
+	var master *hdkeychain.ExtendedKey
+	var err error
+	switch {
+	case *masterXprv != "":
+		master, err = hdkeychain.NewKeyFromString(strings.TrimSpace(*masterXprv))
+		if err == nil && (!master.IsPrivate() || master.Depth() != 0) {
+			err = fmt.Errorf("expected a root extended private key")
+		}
+	case *fromSeed != "":
+		var seed []byte
+		if seed, err = readSeed(*fromSeed); err == nil {
+			master, err = hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+		}
+	default:
+		if err = setMnemonicWordlist(*wordlist); err != nil {
+			break
+		}
+		var passphrase string
+		if passphrase, err = readPassphrase(*passphraseFile, *passphraseEnv, *usePassphrase); err != nil {
+			break
+		}
+		var seed []byte
+		if _, seed, err = readMnemonic(*fromMnemonic, *mnemonicFile, passphrase); err == nil {
+			master, err = hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error reading master key: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Child mnemonics are rendered in their own language
+	if err := setMnemonicWordlist(*language); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	children := make([]Bip85Child, 0, *count)
+	for i := *index; i < *index+*count; i++ {
+		path := bip85Path(*app, i, childLanguage, *words, *size)
+		entropy, err := bip85Entropy(master, path)
+		if err != nil {
+			fmt.Printf("Error deriving child %d: %v\n", i, err)
+			os.Exit(1)
+		}
+		child, err := bip85Child(*app, entropy, *words, *size)
+		if err != nil {
+			fmt.Printf("Error deriving child %d: %v\n", i, err)
+			os.Exit(1)
+		}
+		hardened := make([]uint32, 0, len(path)+1)
+		for _, segment := range append([]uint32{bip85Purpose}, path...) {
+			hardened = append(hardened, segment+hardenedOffset)
+		}
+		child.Index = i
+		child.DerivationPath = formatDerivationPath(hardened)
+		children = append(children, child)
+	}
+
+	result := Bip85Result{
+		App:       *app,
+		Count:     *count,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Children:  children,
+	}
+	switch *app {
+	case "bip39":
+		result.Language = *language
+		result.Words = *words
+	case "hex":
+		result.Bytes = *size
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error creating JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := fmt.Sprintf("bip85_%s_%s.json", *app, time.Now().Format("20060102_150405"))
+	if err := writeOutputFile(filename, jsonData); err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully derived %d BIP85 %s children and saved to %s\n", *count, *app, filename)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// bitcoinNetworks maps the -network flag values to their chain parameters
+var bitcoinNetworks = map[string]*chaincfg.Params{
+	"mainnet": &chaincfg.MainNetParams,
+	"testnet": &chaincfg.TestNet3Params,
+	"signet":  &chaincfg.SigNetParams,
+	"regtest": &chaincfg.RegressionNetParams,
+}
+
+// generateBitcoinKeyPair returns a WIF-encoded private key, its native segwit
+// (P2WPKH) address and, as extras, the taproot (P2TR) address, compressed
+// public key and wpkh/tr descriptors
+func generateBitcoinKeyPair(random io.Reader, params *chaincfg.Params) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return bitcoinKeyPair(params, privateKey)
+}
+
+// bitcoinKeyPair returns the WIF key and P2WPKH address of a key, with the
+// P2TR address, public key and descriptors as extras
+func bitcoinKeyPair(params *chaincfg.Params, privateKey *btcec.PrivateKey) (string, string, keyExtras, error) {
+	wif, err := btcutil.NewWIF(privateKey, params, true)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	publicKey := privateKey.PubKey()
+	pubKeyBytes := publicKey.SerializeCompressed()
+
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKeyBytes), params)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating p2wpkh address: %w", err)
+	}
+
+	// BIP86 key-path-only output: tweak the internal key with an empty script tree
+	outputKey := txscript.ComputeTaprootKeyNoScript(publicKey)
+	p2tr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating p2tr address: %w", err)
+	}
+
+	extra, err := bitcoinSingleKeyDescriptors(wif.String())
+	if err != nil {
+		return "", "", nil, err
+	}
+	extra["taprootAddress"] = publicExtra(p2tr.EncodeAddress())
+	extra["publicKey"] = publicExtra(hex.EncodeToString(pubKeyBytes))
+
+	return wif.String(), p2wpkh.EncodeAddress(), extra, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	blsKeygenSalt   = "BLS-SIG-KEYGEN-SALT-"
+	blsSignatureDST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+	blsLamportSize  = 255
+)
+
+// blsHKDFModR is the EIP-2333 HKDF_mod_r, hashing input key material to a
+// non-zero scalar
+func blsHKDFModR(ikm []byte) (*big.Int, error) {
+	salt := []byte(blsKeygenSalt)
+	sk := new(big.Int)
+	for sk.Sign() == 0 {
+		s := sha256.Sum256(salt)
+		salt = s[:]
+
+		prk := hkdf.Extract(sha256.New, append(append([]byte{}, ikm...), 0), salt)
+		okm := make([]byte, 48)
+		if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte{0, 48}), okm); err != nil {
+			return nil, err
+		}
+		sk.SetBytes(okm).Mod(sk, fr.Modulus())
+	}
+	return sk, nil
+}
+
+// blsLamportPK compresses the lamport public key EIP-2333 builds from the
+// parent key and the bitwise complement of it for one child index
+func blsLamportPK(parent *big.Int, index uint32) ([]byte, error) {
+	salt := binary.BigEndian.AppendUint32(nil, index)
+	ikm := parent.FillBytes(make([]byte, 32))
+	notIKM := make([]byte, len(ikm))
+	for i, b := range ikm {
+		notIKM[i] = ^b
+	}
+
+	h := sha256.New()
+	for _, key := range [][]byte{ikm, notIKM} {
+		okm := make([]byte, blsLamportSize*32)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, nil), okm); err != nil {
+			return nil, err
+		}
+		for i := 0; i < len(okm); i += 32 {
+			chunk := sha256.Sum256(okm[i : i+32])
+			h.Write(chunk[:])
+		}
+	}
+	return h.Sum(nil), nil
+}
+
+// blsDeriveKey derives the EIP-2333 secret key at path from a BIP39 seed
+func blsDeriveKey(seed []byte, path []uint32) (*big.Int, error) {
+	sk, err := blsHKDFModR(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		lamport, err := blsLamportPK(sk, index)
+		if err != nil {
+			return nil, err
+		}
+		if sk, err = blsHKDFModR(lamport); err != nil {
+			return nil, err
+		}
+	}
+	return sk, nil
+}
+
+// blsPublicKey returns the compressed G1 public key of sk
+func blsPublicKey(sk *big.Int) []byte {
+	pk := new(bls12381.G1Affine).ScalarMultiplicationBase(sk).Bytes()
+	return pk[:]
+}
+
+// blsSign returns the compressed G2 signature of msg under the proof of
+// possession ciphersuite used by Ethereum consensus
+func blsSign(sk *big.Int, msg []byte) ([]byte, error) {
+	h, err := bls12381.HashToG2(msg, []byte(blsSignatureDST))
+	if err != nil {
+		return nil, err
+	}
+	sig := new(bls12381.G2Affine).ScalarMultiplication(&h, sk).Bytes()
+	return sig[:], nil
+}
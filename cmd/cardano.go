@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"filippo.io/edwards25519"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	hardenedOffset = 0x80000000
+
+	cardanoPurpose  = 1852
+	cardanoCoinType = 1815
+	cardanoExternal = 0
+	cardanoStaking  = 2
+
+	cardanoEntropySize = 32
+)
+
+// cardanoExtendedKey is a BIP32-Ed25519 extended private key: the 64-byte
+// extended scalar kL||kR, its public key and the chain code
+type cardanoExtendedKey struct {
+	key       [64]byte
+	publicKey [32]byte
+	chainCode [32]byte
+}
+
+// cardanoPublicKey multiplies the base point by the little-endian scalar kL
+func cardanoPublicKey(kL []byte) ([32]byte, error) {
+	wide := make([]byte, 64)
+	copy(wide, kL)
+	s, err := edwards25519.NewScalar().SetUniformBytes(wide)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	var pub [32]byte
+	copy(pub[:], new(edwards25519.Point).ScalarBaseMult(s).Bytes())
+	return pub, nil
+}
+
+// cardanoMasterKey derives the Icarus master key from BIP39 entropy
+func cardanoMasterKey(entropy []byte) (*cardanoExtendedKey, error) {
+	data := pbkdf2.Key(nil, entropy, 4096, 96, sha512.New)
+
+	master := &cardanoExtendedKey{}
+	copy(master.key[:], data[:64])
+	copy(master.chainCode[:], data[64:])
+	master.key[0] &= 0xf8
+	master.key[31] &= 0x1f
+	master.key[31] |= 0x40
+
+	pub, err := cardanoPublicKey(master.key[:32])
+	if err != nil {
+		return nil, err
+	}
+	master.publicKey = pub
+	return master, nil
+}
+
+// leBytesToInt interprets b as a little-endian unsigned integer
+func leBytesToInt(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// intToLEBytes writes n as a little-endian integer truncated to size bytes
+func intToLEBytes(n *big.Int, size int) []byte {
+	be := n.FillBytes(make([]byte, max(size, (n.BitLen()+7)/8)))
+	be = be[len(be)-size:]
+	le := make([]byte, size)
+	for i := range be {
+		le[size-1-i] = be[i]
+	}
+	return le
+}
+
+// derive computes the child key at index using the V2 BIP32-Ed25519 scheme
+func (k *cardanoExtendedKey) derive(index uint32) (*cardanoExtendedKey, error) {
+	var indexBytes [4]byte
+	binary.LittleEndian.PutUint32(indexBytes[:], index)
+
+	zMac := hmac.New(sha512.New, k.chainCode[:])
+	ccMac := hmac.New(sha512.New, k.chainCode[:])
+	if index >= hardenedOffset {
+		zMac.Write([]byte{0x00})
+		zMac.Write(k.key[:])
+		ccMac.Write([]byte{0x01})
+		ccMac.Write(k.key[:])
+	} else {
+		zMac.Write([]byte{0x02})
+		zMac.Write(k.publicKey[:])
+		ccMac.Write([]byte{0x03})
+		ccMac.Write(k.publicKey[:])
+	}
+	zMac.Write(indexBytes[:])
+	ccMac.Write(indexBytes[:])
+	z := zMac.Sum(nil)
+	cc := ccMac.Sum(nil)
+
+	// kL' = 8*ZL[0:28] + kL, kR' = ZR + kR mod 2^256
+	kL := new(big.Int).Mul(leBytesToInt(z[:28]), big.NewInt(8))
+	kL.Add(kL, leBytesToInt(k.key[:32]))
+	kR := new(big.Int).Add(leBytesToInt(z[32:]), leBytesToInt(k.key[32:]))
+
+	child := &cardanoExtendedKey{}
+	copy(child.key[:32], intToLEBytes(kL, 32))
+	copy(child.key[32:], intToLEBytes(kR, 32))
+	copy(child.chainCode[:], cc[32:])
+
+	pub, err := cardanoPublicKey(child.key[:32])
+	if err != nil {
+		return nil, err
+	}
+	child.publicKey = pub
+	return child, nil
+}
+
+// derivePath walks a sequence of child indexes from k
+func (k *cardanoExtendedKey) derivePath(path ...uint32) (*cardanoExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.derive(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// signingKeyCBOR returns the cardano-cli extended signing key payload:
+// a CBOR byte string of kL||kR||A||c
+func (k *cardanoExtendedKey) signingKeyCBOR() string {
+	payload := append(append(append([]byte{}, k.key[:]...), k.publicKey[:]...), k.chainCode[:]...)
+	return "5880" + hex.EncodeToString(payload)
+}
+
+// verificationKeyCBOR returns the cardano-cli extended verification key
+// payload: a CBOR byte string of A||c
+func (k *cardanoExtendedKey) verificationKeyCBOR() string {
+	return "5840" + hex.EncodeToString(k.publicKey[:]) + hex.EncodeToString(k.chainCode[:])
+}
+
+// blake2b224 hashes a verification key into a Cardano credential
+func blake2b224(data []byte) ([]byte, error) {
+	h, err := blake2b.New(28, nil)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// generateCardanoKeyPair derives the first payment and stake keys of a fresh
+// Icarus wallet and returns the payment signing key (addr_xsk) and base address
+func generateCardanoKeyPair(random io.Reader, testnet bool) (string, string, keyExtras, error) {
+	entropy := make([]byte, cardanoEntropySize)
+	if _, err := io.ReadFull(random, entropy); err != nil {
+		return "", "", nil, err
+	}
+	return cardanoKeyPair(entropy, testnet)
+}
+
+// cardanoKeyPair derives the first payment and stake keys of the Icarus
+// wallet of entropy
+func cardanoKeyPair(entropy []byte, testnet bool) (string, string, keyExtras, error) {
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	master, err := cardanoMasterKey(entropy)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	account, err := master.derivePath(cardanoPurpose+hardenedOffset, cardanoCoinType+hardenedOffset, hardenedOffset)
+	if err != nil {
+		return "", "", nil, err
+	}
+	payment, err := account.derivePath(cardanoExternal, 0)
+	if err != nil {
+		return "", "", nil, err
+	}
+	stake, err := account.derivePath(cardanoStaking, 0)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	paymentHash, err := blake2b224(payment.publicKey[:])
+	if err != nil {
+		return "", "", nil, err
+	}
+	stakeHash, err := blake2b224(stake.publicKey[:])
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// header nibbles: address type (0 base, 0xe reward) and network ID
+	networkID := byte(1)
+	addrHRP, stakeHRP := "addr", "stake"
+	if testnet {
+		networkID = 0
+		addrHRP, stakeHRP = "addr_test", "stake_test"
+	}
+
+	baseAddress, err := encodeBech32(addrHRP, append(append([]byte{0x00 | networkID}, paymentHash...), stakeHash...))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding base address: %w", err)
+	}
+	stakeAddress, err := encodeBech32(stakeHRP, append([]byte{0xe0 | networkID}, stakeHash...))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding stake address: %w", err)
+	}
+
+	signingKey, err := encodeBech32("addr_xsk", append(append([]byte{}, payment.key[:]...), payment.chainCode[:]...))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding signing key: %w", err)
+	}
+
+	extra := keyExtras{
+		"mnemonic":                   secretExtra(mnemonic),
+		"stakeAddress":               publicExtra(stakeAddress),
+		"paymentSigningKeyCbor":      secretExtra(payment.signingKeyCBOR()),
+		"paymentVerificationKeyCbor": publicExtra(payment.verificationKeyCBOR()),
+		"stakeSigningKeyCbor":        secretExtra(stake.signingKeyCBOR()),
+		"stakeVerificationKeyCbor":   publicExtra(stake.verificationKeyCBOR()),
+	}
+
+	return signingKey, baseAddress, extra, nil
+}
+
+// cardanoTextEnvelope is the JSON key file format read by cardano-cli
+type cardanoTextEnvelope struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	CborHex     string `json:"cborHex"`
+}
+
+// cardanoKeyFiles renders the payment and stake keys of one account as
+// cardano-cli text envelope files
+func cardanoKeyFiles(index int, _, _ string, extra map[string]string, _ string) (map[string][]byte, error) {
+	envelopes := map[string]cardanoTextEnvelope{
+		"payment.skey": {"PaymentExtendedSigningKeyShelley_ed25519_bip32", "Payment Signing Key", extra["paymentSigningKeyCbor"]},
+		"payment.vkey": {"PaymentExtendedVerificationKeyShelley_ed25519_bip32", "Payment Verification Key", extra["paymentVerificationKeyCbor"]},
+		"stake.skey":   {"StakeExtendedSigningKeyShelley_ed25519_bip32", "Stake Signing Key", extra["stakeSigningKeyCbor"]},
+		"stake.vkey":   {"StakeExtendedVerificationKeyShelley_ed25519_bip32", "Stake Verification Key", extra["stakeVerificationKeyCbor"]},
+	}
+
+	files := make(map[string][]byte, len(envelopes))
+	for name, envelope := range envelopes {
+		data, err := json.MarshalIndent(envelope, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		files[fmt.Sprintf("%d/%s", index, name)] = append(data, '\n')
+	}
+	return files, nil
+}
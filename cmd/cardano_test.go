@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+)
+
+// The Icarus master key example of CIP-3
+func TestCardanoMasterKey(t *testing.T) {
+	entropy, err := bip39.EntropyFromMnemonic("eight country switch draw meat scout mystery blade tip drift useless good keep usage title")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := cardanoMasterKey(entropy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xprv := hex.EncodeToString(master.key[:]) + hex.EncodeToString(master.chainCode[:])
+	if want := "c065afd2832cd8b087c4d9ab7011f481ee1e0721e78ea5dd609f3ab3f156d245d176bd8fd4ec60b4731c3918a2a72a0226c0cd119ec35b47e4d55884667f552a23f7fdcd4a10c6cd2c7393ac61d877873e248f417634aa3d812af327ffe9d620"; xprv != want {
+		t.Errorf("master key %s, want %s", xprv, want)
+	}
+}
+
+// The CIP-1852 base and reward addresses of the 12-word wallet of the
+// cardano-serialization-lib tests, whose payment and stake keys are at
+// 1852'/1815'/0'/0/0 and 1852'/1815'/0'/2/0
+func TestCardanoKeyPair(t *testing.T) {
+	entropy, err := bip39.EntropyFromMnemonic("test walk nut penalty hip pave soap entry language right filter choice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		testnet                   bool
+		baseAddress, stakeAddress string
+	}{
+		{false, "addr1qx2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwqfjkjv7", "stake1uyevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqxdekzz"},
+		{true, "addr_test1qz2fxv2umyhttkxyxp8x0dlpdt3k6cwng5pxj3jhsydzer3jcu5d8ps7zex2k2xt3uqxgjqnnj83ws8lhrn648jjxtwq2ytjqp", "stake_test1uqevw2xnsc0pvn9t9r9c7qryfqfeerchgrlm3ea2nefr9hqp8n5xl"},
+	} {
+		_, baseAddress, extra, err := cardanoKeyPair(entropy, tt.testnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if baseAddress != tt.baseAddress || extra["stakeAddress"].value != tt.stakeAddress {
+			t.Errorf("testnet %v: %s, %s, want %s, %s", tt.testnet, baseAddress, extra["stakeAddress"].value, tt.baseAddress, tt.stakeAddress)
+		}
+	}
+}
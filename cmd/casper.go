@@ -0,0 +1,105 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/minio/blake2b-simd"
+)
+
+// Algorithm tags prefixed to Casper public keys
+const (
+	casperEd25519Tag   = 0x01
+	casperSecp256k1Tag = 0x02
+)
+
+// casperSchemes lists the -scheme values accepted for the casper type
+var casperSchemes = []string{"ed25519", "secp256k1"}
+
+// casperAccountHash hashes the lowercase algorithm name, a zero separator and
+// the untagged public key, as casper-types AccountHash::from does
+func casperAccountHash(scheme string, pubKey []byte) string {
+	data := append([]byte(scheme), 0x00)
+	hash := blake2b.Sum256(append(data, pubKey...))
+	return "account-hash-" + hex.EncodeToString(hash[:])
+}
+
+// generateCasperKeyPair returns a hex private key and the algorithm-tagged hex
+// public key, with the account hash as an extra
+func generateCasperKeyPair(random io.Reader, scheme string) (string, string, keyExtras, error) {
+	var secret, pubKey []byte
+	var tag byte
+
+	switch scheme {
+	case "ed25519":
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, seed); err != nil {
+			return "", "", nil, err
+		}
+		secret = seed
+		pubKey = ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		tag = casperEd25519Tag
+	case "secp256k1":
+		privateKey, err := newSecp256k1Key(random)
+		if err != nil {
+			return "", "", nil, err
+		}
+		secret = privateKey.Serialize()
+		pubKey = privateKey.PubKey().SerializeCompressed()
+		tag = casperSecp256k1Tag
+	default:
+		return "", "", nil, fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	extra := keyExtras{
+		"accountHash": publicExtra(casperAccountHash(scheme, pubKey)),
+	}
+
+	return hex.EncodeToString(secret), hex.EncodeToString(append([]byte{tag}, pubKey...)), extra, nil
+}
+
+// casperKeyFiles writes each keypair as <index>/secret_key.pem,
+// public_key.pem and public_key_hex, the files casper-client keygen creates
+func casperKeyFiles(index int, privateKey, publicKey string, _ map[string]string, _ string) (map[string][]byte, error) {
+	secret, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	tagged, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// ed25519 keys use the same PKCS#8 and SubjectPublicKeyInfo DER as Hedera,
+	// secp256k1 keys the uncompressed SEC1 and SubjectPublicKeyInfo DER of dfx
+	var secretBlock, publicBlock *pem.Block
+	switch tagged[0] {
+	case casperEd25519Tag:
+		secretBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: append(append([]byte{}, hederaEd25519PrivateDERPrefix...), secret...)}
+		publicBlock = &pem.Block{Type: "PUBLIC KEY", Bytes: append(append([]byte{}, hederaEd25519PublicDERPrefix...), tagged[1:]...)}
+	case casperSecp256k1Tag:
+		pubKey, err := btcec.ParsePubKey(tagged[1:])
+		if err != nil {
+			return nil, err
+		}
+		uncompressed := pubKey.SerializeUncompressed()
+
+		der := append(append([]byte{}, icpSecp256k1SEC1Prefix...), secret...)
+		der = append(append(der, icpSecp256k1SEC1Params...), uncompressed...)
+		secretBlock = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		publicBlock = &pem.Block{Type: "PUBLIC KEY", Bytes: append(append([]byte{}, icpSecp256k1PublicDERPrefix...), uncompressed...)}
+	default:
+		return nil, fmt.Errorf("unsupported public key tag: %#x", tagged[0])
+	}
+
+	dir := fmt.Sprint(index)
+	return map[string][]byte{
+		dir + "/secret_key.pem": pem.EncodeToMemory(secretBlock),
+		dir + "/public_key.pem": pem.EncodeToMemory(publicBlock),
+		dir + "/public_key_hex": []byte(publicKey),
+	}, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+const (
+	// chiaStandardPuzzleHash is the tree hash of p2_delegated_puzzle_or_hidden_puzzle
+	chiaStandardPuzzleHash = "e9aaa49f45bad5c889b86ee3341550c155cfdd10c3a6757de618d20612fffd52"
+	// chiaDefaultHiddenPuzzleHash is the tree hash of the (=) hidden puzzle
+	// every standard wallet uses
+	chiaDefaultHiddenPuzzleHash = "711d6c4e32c92e53179b199484cf8c897542bc57f2b22582799f9d657eec4699"
+
+	chiaPurpose   = 12381
+	chiaCoinType  = 8444
+	chiaFarmerKey = 0
+	chiaPoolKey   = 1
+	chiaWalletKey = 2
+)
+
+// chiaPrefixes maps the -network flag to the address prefix
+var chiaPrefixes = map[string]string{
+	"mainnet": "xch",
+	"testnet": "txch",
+}
+
+// ChiaPlotKeys holds the keys of a Chia master key needed to create plots
+type ChiaPlotKeys struct {
+	Fingerprint      uint32 `json:"fingerprint"`
+	FarmerPublicKey  string `json:"farmerPublicKey"`
+	PoolPublicKey    string `json:"poolPublicKey"`
+	FarmerPrivateKey string `json:"farmerPrivateKey,omitempty"`
+	PoolPrivateKey   string `json:"poolPrivateKey,omitempty"`
+}
+
+// blsDeriveUnhardened derives the non-hardened child along path, where each
+// child is parent + sha256(parent public key || index) mod r
+func blsDeriveUnhardened(sk *big.Int, path []uint32) *big.Int {
+	child := new(big.Int).Set(sk)
+	for _, index := range path {
+		digest := sha256.Sum256(binary.BigEndian.AppendUint32(blsPublicKey(child), index))
+		child.Add(child, new(big.Int).SetBytes(digest[:])).Mod(child, fr.Modulus())
+	}
+	return child
+}
+
+// chiaTreeHashAtom is the CLVM sha256tree hash of an atom
+func chiaTreeHashAtom(atom []byte) []byte {
+	return sha256Concat([]byte{1}, atom)
+}
+
+// chiaTreeHashPair is the CLVM sha256tree hash of a cons pair
+func chiaTreeHashPair(left, right []byte) []byte {
+	return sha256Concat([]byte{2}, left, right)
+}
+
+// chiaSyntheticPublicKey offsets pk by the hidden puzzle hash, as the standard
+// wallet puzzle is curried with pk + (sha256(pk || hidden) mod r) * G
+func chiaSyntheticPublicKey(pk []byte) ([]byte, error) {
+	hidden, err := hex.DecodeString(chiaDefaultHiddenPuzzleHash)
+	if err != nil {
+		return nil, err
+	}
+
+	// the offset is read as a signed big-endian integer
+	offset := new(big.Int).SetBytes(sha256Concat(pk, hidden))
+	if offset.Bit(255) == 1 {
+		offset.Sub(offset, new(big.Int).Lsh(big.NewInt(1), 256))
+	}
+	offset.Mod(offset, fr.Modulus())
+
+	var point bls12381.G1Affine
+	if _, err := point.SetBytes(pk); err != nil {
+		return nil, err
+	}
+	var synthetic bls12381.G1Affine
+	synthetic.Add(&point, new(bls12381.G1Affine).ScalarMultiplicationBase(offset))
+	b := synthetic.Bytes()
+	return b[:], nil
+}
+
+// chiaPuzzleHash is the tree hash of the standard puzzle curried with the
+// synthetic key: (a (q . MOD) (c (q . synthetic_pk) 1))
+func chiaPuzzleHash(syntheticPublicKey []byte) ([]byte, error) {
+	modHash, err := hex.DecodeString(chiaStandardPuzzleHash)
+	if err != nil {
+		return nil, err
+	}
+
+	empty := chiaTreeHashAtom(nil)
+	apply, quote, cons, args := chiaTreeHashAtom([]byte{2}), chiaTreeHashAtom([]byte{1}), chiaTreeHashAtom([]byte{4}), chiaTreeHashAtom([]byte{1})
+
+	quotedMod := chiaTreeHashPair(quote, modHash)
+	quotedKey := chiaTreeHashPair(quote, chiaTreeHashAtom(syntheticPublicKey))
+	environment := chiaTreeHashPair(cons, chiaTreeHashPair(quotedKey, chiaTreeHashPair(args, empty)))
+	return chiaTreeHashPair(apply, chiaTreeHashPair(quotedMod, chiaTreeHashPair(environment, empty))), nil
+}
+
+// chiaMasterKeys returns the fingerprint and farmer/pool keys of a seed
+func chiaMasterKeys(seed []byte) (*ChiaPlotKeys, error) {
+	master, err := blsDeriveKey(seed, nil)
+	if err != nil {
+		return nil, err
+	}
+	farmer, err := blsDeriveKey(seed, []uint32{chiaPurpose, chiaCoinType, chiaFarmerKey, 0})
+	if err != nil {
+		return nil, err
+	}
+	pool, err := blsDeriveKey(seed, []uint32{chiaPurpose, chiaCoinType, chiaPoolKey, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := sha256.Sum256(blsPublicKey(master))
+	return &ChiaPlotKeys{
+		Fingerprint:      binary.BigEndian.Uint32(fingerprint[:4]),
+		FarmerPublicKey:  hex.EncodeToString(blsPublicKey(farmer)),
+		PoolPublicKey:    hex.EncodeToString(blsPublicKey(pool)),
+		FarmerPrivateKey: hex.EncodeToString(farmer.FillBytes(make([]byte, 32))),
+		PoolPrivateKey:   hex.EncodeToString(pool.FillBytes(make([]byte, 32))),
+	}, nil
+}
+
+// deriveChiaKeyPair derives the index-th wallet key at the unhardened path
+// m/12381/8444/2/index the Chia wallet uses, returning it with the xch
+// address of its standard puzzle
+func deriveChiaKeyPair(seed []byte, index int, network string) (string, string, keyExtras, error) {
+	master, err := blsDeriveKey(seed, nil)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	path := []uint32{chiaPurpose, chiaCoinType, chiaWalletKey, uint32(index)}
+	sk := blsDeriveUnhardened(master, path)
+	pk := blsPublicKey(sk)
+
+	synthetic, err := chiaSyntheticPublicKey(pk)
+	if err != nil {
+		return "", "", nil, err
+	}
+	puzzleHash, err := chiaPuzzleHash(synthetic)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	converted, err := bech32.ConvertBits(puzzleHash, 8, 5, true)
+	if err != nil {
+		return "", "", nil, err
+	}
+	address, err := bech32.EncodeM(chiaPrefixes[network], converted)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding address: %w", err)
+	}
+
+	extra := keyExtras{
+		"derivationPath": publicExtra(formatDerivationPath(path)),
+		"publicKey":      publicExtra(hex.EncodeToString(pk)),
+		"puzzleHash":     publicExtra(hex.EncodeToString(puzzleHash)),
+	}
+
+	return hex.EncodeToString(sk.FillBytes(make([]byte, 32))), address, extra, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/minio/blake2b-simd"
+)
+
+const (
+	ckbHashPersonalization = "ckb-default-hash"
+	// ckbSighashCodeHash is the type script hash of the secp256k1/blake160
+	// sighash_all lock system script
+	ckbSighashCodeHash = "9bd7e06f3ecf4be0f2fcd2188b23f1b9fcc88e5d4b65a8637b17723bbda3cce8"
+
+	ckbFullAddressFormat  = 0x00
+	ckbShortAddressFormat = 0x01
+	ckbHashTypeType       = 0x01
+	ckbSighashCodeIndex   = 0x00
+)
+
+// ckbPrefixes maps the -network flag to the address prefix
+var ckbPrefixes = map[string]string{
+	"mainnet": "ckb",
+	"testnet": "ckt",
+}
+
+// ckbHash is blake2b-256 personalized with "ckb-default-hash"
+func ckbHash(data []byte) ([]byte, error) {
+	h, err := blake2b.New(&blake2b.Config{Size: 32, Person: []byte(ckbHashPersonalization)})
+	if err != nil {
+		return nil, err
+	}
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// generateCKBKeyPair returns a hex private key and the CKB2021 full address of
+// its sighash lock, with the blake160 lock args and the deprecated short
+// address as extras
+func generateCKBKeyPair(random io.Reader, network string) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+	hash, err := ckbHash(pubKeyBytes)
+	if err != nil {
+		return "", "", nil, err
+	}
+	args := hash[:20]
+
+	codeHash, err := hex.DecodeString(ckbSighashCodeHash)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	full := append([]byte{ckbFullAddressFormat}, codeHash...)
+	full = append(full, ckbHashTypeType)
+	full = append(full, args...)
+	converted, err := bech32.ConvertBits(full, 8, 5, true)
+	if err != nil {
+		return "", "", nil, err
+	}
+	address, err := bech32.EncodeM(ckbPrefixes[network], converted)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding full address: %w", err)
+	}
+
+	short, err := encodeBech32(ckbPrefixes[network], append([]byte{ckbShortAddressFormat, ckbSighashCodeIndex}, args...))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding short address: %w", err)
+	}
+
+	extra := keyExtras{
+		"lockArgs":     publicExtra("0x" + hex.EncodeToString(args)),
+		"shortAddress": publicExtra(short),
+		"publicKey":    publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	return hex.EncodeToString(privateKey.Serialize()), address, extra, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Amino type names CometBFT tags its ed25519 keys with in JSON
+const (
+	cometPubKeyType  = "tendermint/PubKeyEd25519"
+	cometPrivKeyType = "tendermint/PrivKeyEd25519"
+)
+
+// cometKey is the amino JSON form of a CometBFT key
+type cometKey struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// cometPrivValidatorKey is the layout of config/priv_validator_key.json
+type cometPrivValidatorKey struct {
+	Address string   `json:"address"`
+	PubKey  cometKey `json:"pub_key"`
+	PrivKey cometKey `json:"priv_key"`
+}
+
+// cometPrivValidatorState is the layout of data/priv_validator_state.json,
+// which a validator needs next to its key before it first signs
+type cometPrivValidatorState struct {
+	Height string `json:"height"`
+	Round  int    `json:"round"`
+	Step   int    `json:"step"`
+}
+
+// cometNodeKey is the layout of config/node_key.json, the node's p2p identity
+type cometNodeKey struct {
+	PrivKey cometKey `json:"priv_key"`
+}
+
+// cometAddress returns the address bytes of an ed25519 public key,
+// the first 20 bytes of its SHA-256
+func cometAddress(pubKey ed25519.PublicKey) []byte {
+	hash := sha256.Sum256(pubKey)
+	return hash[:20]
+}
+
+// generateCometBFTKeyPair returns a base64 ed25519 consensus key and the
+// validator address, with the base64 public key genesis files take and the
+// <hrp>valcons address of the validator as extras. With nodeKey, a separate
+// p2p key and its node ID are generated as extras too
+func generateCometBFTKeyPair(random io.Reader, hrp string, nodeKey bool) (string, string, keyExtras, error) {
+	pubKey, privKey, err := newEd25519Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	address := cometAddress(pubKey)
+	valcons, err := encodeBech32(hrp+"valcons", address)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding valcons address: %w", err)
+	}
+
+	extra := keyExtras{
+		"publicKey":      publicExtra(base64.StdEncoding.EncodeToString(pubKey)),
+		"valconsAddress": publicExtra(valcons),
+	}
+
+	if nodeKey {
+		nodePubKey, nodePrivKey, err := newEd25519Key(random)
+		if err != nil {
+			return "", "", nil, err
+		}
+		extra["nodeId"] = publicExtra(hex.EncodeToString(cometAddress(nodePubKey)))
+		extra["nodePrivateKey"] = secretExtra(base64.StdEncoding.EncodeToString(nodePrivKey))
+	}
+
+	return base64.StdEncoding.EncodeToString(privKey), strings.ToUpper(hex.EncodeToString(address)), extra, nil
+}
+
+// cometBFTKeyFiles writes each validator as <index>/config/priv_validator_key.json
+// with an initial <index>/data/priv_validator_state.json, the layout of a
+// node home directory, and <index>/config/node_key.json when it has a node key
+func cometBFTKeyFiles(index int, privateKey, publicKey string, extra map[string]string, _ string) (map[string][]byte, error) {
+	key, err := json.MarshalIndent(cometPrivValidatorKey{
+		Address: publicKey,
+		PubKey:  cometKey{Type: cometPubKeyType, Value: extra["publicKey"]},
+		PrivKey: cometKey{Type: cometPrivKeyType, Value: privateKey},
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	state, err := json.MarshalIndent(cometPrivValidatorState{Height: "0"}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	dir := fmt.Sprint(index)
+	files := map[string][]byte{
+		dir + "/config/priv_validator_key.json": key,
+		dir + "/data/priv_validator_state.json": state,
+	}
+
+	if extra["nodePrivateKey"] != "" {
+		nodeKey, err := json.MarshalIndent(cometNodeKey{
+			PrivKey: cometKey{Type: cometPrivKeyType, Value: extra["nodePrivateKey"]},
+		}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[dir+"/config/node_key.json"] = nodeKey
+	}
+	return files, nil
+}
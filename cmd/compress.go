@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressions are the methods of -compress, with the extension they add to
+// the name of the results file
+var compressions = []string{"gzip", "zstd"}
+
+// compressionExtension returns the extension of files compressed with method
+func compressionExtension(method string) string {
+	if method == "gzip" {
+		return "gz"
+	}
+	return "zst"
+}
+
+// newCompressor returns a writer compressing to w with method, which must
+// be closed to complete the stream
+func newCompressor(w io.Writer, method string) (io.WriteCloser, error) {
+	switch method {
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unknown compression %q", method)
+}
+
+// compress returns data compressed with method
+func compress(data []byte, method string) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := newCompressor(&buf, method)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// keyConverters re-encode an existing EVM private key for another secp256k1
+// chain, returning the key, address and extras as the generators do
+var keyConverters = map[string]func(privateKeyHex string) (string, string, map[string]string, error){
+	"tron": convertEVMToTron,
+}
+
+// convertEVMToTron returns the Tron T-address controlled by an EVM private key,
+// with its hex form and the original EVM address as extras
+func convertEVMToTron(privateKeyHex string) (string, string, map[string]string, error) {
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	address, hexAddress := tronAddress(privateKey.PublicKey)
+	extra := map[string]string{
+		"hexAddress": hexAddress,
+		"evmAddress": crypto.PubkeyToAddress(privateKey.PublicKey).Hex(),
+	}
+
+	return hex.EncodeToString(crypto.FromECDSA(privateKey)), address, extra, nil
+}
+
+// readEVMPrivateKeys reads the private keys of an evm keygen output file, or of
+// a text file with one hex key per line where blank and # lines are skipped
+func readEVMPrivateKeys(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var result KeyGenResult
+	if json.Unmarshal(data, &result) == nil {
+		if result.KeyType != "evm" {
+			return nil, fmt.Errorf("%s holds %s keys, not evm keys", path, result.KeyType)
+		}
+		return result.PrivateKeys, nil
+	}
+
+	var keys []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys = append(keys, line)
+	}
+	return keys, scanner.Err()
+}
+
+// runConvert implements the convert subcommand, which writes the keys of
+// another chain controlled by existing EVM private keys to a keygen output file
+func runConvert(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	in := fs.String("in", "", "File of EVM private keys: an evm keygen output or one hex key per line")
+	to := fs.String("to", "tron", "Chain to convert to: "+quoteList(slices.Sorted(maps.Keys(keyConverters))))
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *in == "" {
+		fmt.Println("Error: Input file is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	converter, ok := keyConverters[*to]
+	if !ok {
+		fmt.Printf("Error: Target must be %s\n", quoteList(slices.Sorted(maps.Keys(keyConverters))))
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	evmKeys, err := readEVMPrivateKeys(*in)
+	if err != nil {
+		fmt.Printf("Error reading private keys: %v\n", err)
+		os.Exit(1)
+	}
+	if len(evmKeys) == 0 {
+		fmt.Printf("Error: No private keys found in %s\n", *in)
+		os.Exit(1)
+	}
+
+	privateKeys := make([]string, 0, len(evmKeys))
+	publicKeys := make([]string, 0, len(evmKeys))
+	extras := make(map[string][]string)
+	for i, evmKey := range evmKeys {
+		privateKey, publicKey, extra, err := converter(evmKey)
+		if err != nil {
+			fmt.Printf("Error converting private key %d: %v\n", i+1, err)
+			os.Exit(1)
+		}
+		privateKeys = append(privateKeys, privateKey)
+		publicKeys = append(publicKeys, publicKey)
+		for k, v := range extra {
+			extras[k] = append(extras[k], v)
+		}
+	}
+
+	result := KeyGenResult{
+		KeyType:     *to,
+		Count:       len(privateKeys),
+		Timestamp:   time.Now().Format(time.RFC3339),
+		PrivateKeys: privateKeys,
+		PublicKeys:  publicKeys,
+		Extra:       extras,
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error creating JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := fmt.Sprintf("%s_keys_%s.json", *to, time.Now().Format("20060102_150405"))
+	if err := writeOutputFile(filename, jsonData); err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully converted %d evm keys to %s and saved to %s\n", len(privateKeys), *to, filename)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const injectiveHRP = "inj"
+
+// encodeBech32 converts raw bytes to 5-bit groups and encodes them under hrp
+func encodeBech32(hrp string, data []byte) (string, error) {
+	converted, err := bech32.ConvertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+	return bech32.Encode(hrp, converted)
+}
+
+// generateCosmosKeyPair returns a hex private key and the bech32 account
+// address under hrp, with the valoper form of the same address as an extra
+func generateCosmosKeyPair(random io.Reader, hrp string) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return cosmosKeyPair(hrp, privateKey)
+}
+
+// cosmosKeyPair returns the hex private key and bech32 account address of a
+// key under hrp, with the valoper form as an extra. The valcons address is
+// not derived, as it is the hash of a validator's ed25519 consensus key
+// rather than of the account key; -type=cometbft generates those
+func cosmosKeyPair(hrp string, privateKey *btcec.PrivateKey) (string, string, keyExtras, error) {
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+	addrBytes := btcutil.Hash160(pubKeyBytes)
+
+	address, err := encodeBech32(hrp, addrBytes)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding account address: %w", err)
+	}
+	valoper, err := encodeBech32(hrp+"valoper", addrBytes)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding valoper address: %w", err)
+	}
+
+	extra := keyExtras{
+		"valoperAddress": publicExtra(valoper),
+		"publicKey":      publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	return hex.EncodeToString(privateKey.Serialize()), address, extra, nil
+}
+
+// generateEthCosmosKeyPair returns a hex private key and the bech32 account
+// address of an eth_secp256k1 chain such as Injective or Evmos, where the
+// address bytes are the Ethereum keccak address rather than hash160
+func generateEthCosmosKeyPair(random io.Reader, hrp string) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	evmAddress := crypto.PubkeyToAddress(*privateKey.PubKey().ToECDSA())
+	address, err := encodeBech32(hrp, evmAddress.Bytes())
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding account address: %w", err)
+	}
+
+	extra := keyExtras{
+		"evmAddress": publicExtra(evmAddress.Hex()),
+		"publicKey":  publicExtra(hex.EncodeToString(privateKey.PubKey().SerializeCompressed())),
+	}
+
+	return hex.EncodeToString(privateKey.Serialize()), address, extra, nil
+}
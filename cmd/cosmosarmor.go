@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"golang.org/x/crypto/blowfish"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Cosmos SDK armored private keys, as written by `keys export` and read by
+// `keys import`
+const (
+	cosmosArmorType = "TENDERMINT PRIVATE KEY"
+	// cosmosBcryptCost is not stored in the armor, so it must be the
+	// SDK's BcryptSecurityParameter
+	cosmosBcryptCost = 12
+	// cosmosMinPasswordLength is the shortest password the SDK CLIs accept
+	cosmosMinPasswordLength = 8
+)
+
+// bcryptEncoding is bcrypt's base64 alphabet
+var bcryptEncoding = base64.NewEncoding("./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789").WithPadding(base64.NoPadding)
+
+// cosmosBcrypt returns the $2a$ bcrypt hash of password under a caller-chosen
+// 16-byte salt, which the SDK's bcrypt fork takes and x/crypto/bcrypt does not
+func cosmosBcrypt(password, salt []byte, cost int) ([]byte, error) {
+	key := append(append([]byte{}, password...), 0)
+	c, err := blowfish.NewSaltedCipher(key, salt)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < 1<<cost; i++ {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(salt, c)
+	}
+
+	data := []byte("OrpheanBeholderScryDoubt")
+	for i := 0; i < len(data); i += blowfish.BlockSize {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(data[i:i+blowfish.BlockSize], data[i:i+blowfish.BlockSize])
+		}
+	}
+	// Only 23 of the 24 bytes are encoded, as in the C implementations
+	return fmt.Appendf(nil, "$2a$%02d$%s%s", cost, bcryptEncoding.EncodeToString(salt), bcryptEncoding.EncodeToString(data[:23])), nil
+}
+
+// aminoPrefix returns the 4-byte prefix amino gives the binary encoding of
+// the concrete type registered as name
+func aminoPrefix(name string) []byte {
+	hash := sha256.Sum256([]byte(name))
+	bz := bytes.TrimLeft(hash[:], "\x00")
+	// The first 3 bytes disambiguate, and the next non-zero 4 are the prefix
+	bz = bytes.TrimLeft(bz[3:], "\x00")
+	return bz[:4]
+}
+
+// cosmosArmorKeyFiles returns the writer of armored private keys of the
+// algorithm algo, registered with amino as aminoName by the chain. Each
+// account is written as <address>.armor, for `<daemon> keys import <name> <address>.armor`
+func cosmosArmorKeyFiles(algo, aminoName string) keyFileWriter {
+	return func(_ int, privateKey, publicKey string, _ map[string]string, password string) (map[string][]byte, error) {
+		armored, err := cosmosArmorPrivateKey(privateKey, password, algo, aminoName)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{publicKey + ".armor": armored}, nil
+	}
+}
+
+// cosmosArmorPrivateKey encrypts a hex private key under password as the SDK's
+// EncryptArmorPrivKey does: xsalsa20poly1305 under the SHA-256 of a bcrypt
+// hash, in an armor naming the kdf, salt and algorithm
+func cosmosArmorPrivateKey(privateKey, password, algo, aminoName string) ([]byte, error) {
+	if len(password) < cosmosMinPasswordLength {
+		return nil, fmt.Errorf("Cosmos SDK CLIs require passwords of at least %d characters", cosmosMinPasswordLength)
+	}
+	secret, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	hash, err := cosmosBcrypt([]byte(password), salt, cosmosBcryptCost)
+	if err != nil {
+		return nil, err
+	}
+	key := sha256.Sum256(hash)
+
+	// The amino binary encoding of the key: its prefix and the
+	// length-prefixed key bytes
+	plaintext := append(aminoPrefix(aminoName), byte(len(secret)))
+	plaintext = append(plaintext, secret...)
+
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	sealed := secretbox.Seal(nonce[:], plaintext, &nonce, &key)
+
+	var buf bytes.Buffer
+	w, err := armor.Encode(&buf, cosmosArmorType, map[string]string{
+		"kdf":  "bcrypt",
+		"salt": strings.ToUpper(hex.EncodeToString(salt)),
+		"type": algo,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(sealed); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// numsInternalKey is the BIP341 provably unspendable "H" point, used as
+	// the taproot internal key so multisig outputs are script-path only
+	numsInternalKey = "50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"
+
+	maxWSHMultisigKeys = 20
+)
+
+// DescriptorImport is a single entry of a Bitcoin Core importdescriptors request
+type DescriptorImport struct {
+	Desc      string `json:"desc"`
+	Timestamp string `json:"timestamp"`
+	Label     string `json:"label,omitempty"`
+}
+
+// BitcoinMultisig describes the M-of-N outputs built from a generated batch
+type BitcoinMultisig struct {
+	Threshold      int    `json:"threshold"`
+	Keys           int    `json:"keys"`
+	P2WSHAddress   string `json:"p2wshAddress"`
+	WitnessScript  string `json:"witnessScript"`
+	WSHDescriptor  string `json:"wshDescriptor"`
+	P2TRAddress    string `json:"p2trAddress"`
+	TapLeafScript  string `json:"tapLeafScript"`
+	TRDescriptor   string `json:"trDescriptor"`
+	InternalPubKey string `json:"internalPubKey"`
+}
+
+// descriptorChecksum computes the BIP380 checksum of a descriptor string
+func descriptorChecksum(desc string) (string, error) {
+	generators := []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+	polymod := func(chk uint64, value uint64) uint64 {
+		top := chk >> 35
+		chk = (chk&0x7ffffffff)<<5 ^ value
+		for i, g := range generators {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+		return chk
+	}
+
+	chk := uint64(1)
+	groups := make([]uint64, 0, 3)
+	for _, c := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, c)
+		if pos < 0 {
+			return "", fmt.Errorf("invalid descriptor character %q", c)
+		}
+		chk = polymod(chk, uint64(pos)&31)
+		groups = append(groups, uint64(pos)>>5)
+		if len(groups) == 3 {
+			chk = polymod(chk, groups[0]*9+groups[1]*3+groups[2])
+			groups = groups[:0]
+		}
+	}
+	switch len(groups) {
+	case 1:
+		chk = polymod(chk, groups[0])
+	case 2:
+		chk = polymod(chk, groups[0]*3+groups[1])
+	}
+	for i := 0; i < 8; i++ {
+		chk = polymod(chk, 0)
+	}
+	chk ^= 1
+
+	checksum := make([]byte, 8)
+	for i := range checksum {
+		checksum[i] = descriptorChecksumCharset[(chk>>(5*(7-i)))&31]
+	}
+	return string(checksum), nil
+}
+
+// withDescriptorChecksum appends the "#checksum" suffix to a descriptor
+func withDescriptorChecksum(desc string) (string, error) {
+	checksum, err := descriptorChecksum(desc)
+	if err != nil {
+		return "", err
+	}
+	return desc + "#" + checksum, nil
+}
+
+// bitcoinSingleKeyDescriptors returns the wpkh and tr descriptors for one WIF key
+func bitcoinSingleKeyDescriptors(wif string) (keyExtras, error) {
+	wpkh, err := withDescriptorChecksum("wpkh(" + wif + ")")
+	if err != nil {
+		return nil, err
+	}
+	tr, err := withDescriptorChecksum("tr(" + wif + ")")
+	if err != nil {
+		return nil, err
+	}
+	return keyExtras{"wpkhDescriptor": secretExtra(wpkh), "trDescriptor": secretExtra(tr)}, nil
+}
+
+// multisigKey pairs a WIF private key with its public key for sorting
+type multisigKey struct {
+	wif    string
+	pubKey *btcec.PublicKey
+}
+
+// buildBitcoinMultisig builds sortedmulti P2WSH and sortedmulti_a P2TR outputs
+// from the batch, returning the public summary and the importable private descriptors
+func buildBitcoinMultisig(threshold int, wifs []string, params *chaincfg.Params) (*BitcoinMultisig, []DescriptorImport, error) {
+	if threshold < 1 || threshold > len(wifs) {
+		return nil, nil, fmt.Errorf("threshold must be between 1 and %d", len(wifs))
+	}
+
+	keys := make([]multisigKey, 0, len(wifs))
+	for _, s := range wifs {
+		wif, err := btcutil.DecodeWIF(s)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode WIF: %w", err)
+		}
+		keys = append(keys, multisigKey{wif: s, pubKey: wif.PrivKey.PubKey()})
+	}
+
+	multisig := &BitcoinMultisig{
+		Threshold:      threshold,
+		Keys:           len(keys),
+		InternalPubKey: numsInternalKey,
+	}
+	var imports []DescriptorImport
+
+	if len(keys) <= maxWSHMultisigKeys {
+		slices.SortFunc(keys, func(a, b multisigKey) int {
+			return bytes.Compare(a.pubKey.SerializeCompressed(), b.pubKey.SerializeCompressed())
+		})
+
+		addrPubKeys := make([]*btcutil.AddressPubKey, 0, len(keys))
+		pubHex := make([]string, 0, len(keys))
+		privs := make([]string, 0, len(keys))
+		for _, k := range keys {
+			addr, err := btcutil.NewAddressPubKey(k.pubKey.SerializeCompressed(), params)
+			if err != nil {
+				return nil, nil, err
+			}
+			addrPubKeys = append(addrPubKeys, addr)
+			pubHex = append(pubHex, hex.EncodeToString(k.pubKey.SerializeCompressed()))
+			privs = append(privs, k.wif)
+		}
+
+		witnessScript, err := txscript.MultiSigScript(addrPubKeys, threshold)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating witness script: %w", err)
+		}
+		scriptHash := sha256.Sum256(witnessScript)
+		p2wsh, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], params)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating p2wsh address: %w", err)
+		}
+
+		multisig.P2WSHAddress = p2wsh.EncodeAddress()
+		multisig.WitnessScript = hex.EncodeToString(witnessScript)
+		multisig.WSHDescriptor, err = withDescriptorChecksum(fmt.Sprintf("wsh(sortedmulti(%d,%s))", threshold, strings.Join(pubHex, ",")))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		desc, err := withDescriptorChecksum(fmt.Sprintf("wsh(sortedmulti(%d,%s))", threshold, strings.Join(privs, ",")))
+		if err != nil {
+			return nil, nil, err
+		}
+		imports = append(imports, DescriptorImport{Desc: desc, Timestamp: "now", Label: "p2wsh-multisig"})
+	}
+
+	// sortedmulti_a orders keys by their x-only serialization
+	slices.SortFunc(keys, func(a, b multisigKey) int {
+		return bytes.Compare(schnorr.SerializePubKey(a.pubKey), schnorr.SerializePubKey(b.pubKey))
+	})
+
+	builder := txscript.NewScriptBuilder()
+	xonly := make([]string, 0, len(keys))
+	privs := make([]string, 0, len(keys))
+	for i, k := range keys {
+		builder.AddData(schnorr.SerializePubKey(k.pubKey))
+		if i == 0 {
+			builder.AddOp(txscript.OP_CHECKSIG)
+		} else {
+			builder.AddOp(txscript.OP_CHECKSIGADD)
+		}
+		xonly = append(xonly, hex.EncodeToString(schnorr.SerializePubKey(k.pubKey)))
+		privs = append(privs, k.wif)
+	}
+	builder.AddInt64(int64(threshold))
+	builder.AddOp(txscript.OP_NUMEQUAL)
+	leafScript, err := builder.Script()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating tapscript: %w", err)
+	}
+
+	internalKeyBytes, err := hex.DecodeString(numsInternalKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	internalKey, err := schnorr.ParsePubKey(internalKeyBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	leafHash := txscript.NewBaseTapLeaf(leafScript).TapHash()
+	outputKey := txscript.ComputeTaprootOutputKey(internalKey, leafHash[:])
+	p2tr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating p2tr address: %w", err)
+	}
+
+	multisig.P2TRAddress = p2tr.EncodeAddress()
+	multisig.TapLeafScript = hex.EncodeToString(leafScript)
+	multisig.TRDescriptor, err = withDescriptorChecksum(fmt.Sprintf("tr(%s,sortedmulti_a(%d,%s))", numsInternalKey, threshold, strings.Join(xonly, ",")))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	desc, err := withDescriptorChecksum(fmt.Sprintf("tr(%s,sortedmulti_a(%d,%s))", numsInternalKey, threshold, strings.Join(privs, ",")))
+	if err != nil {
+		return nil, nil, err
+	}
+	imports = append(imports, DescriptorImport{Desc: desc, Timestamp: "now", Label: "p2tr-multisig"})
+
+	return multisig, imports, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// discoverKeyTypes are the key types the discover command scans, with the
+// API their -rpc endpoint speaks
+var discoverKeyTypes = []string{"evm", "solana", "bitcoin"}
+
+// DiscoveredAccount is a derived address with on-chain history
+type DiscoveredAccount struct {
+	Index          int    `json:"index"`
+	DerivationPath string `json:"derivationPath"`
+	Address        string `json:"address"`
+	// Balance is in the chain's smallest unit: wei, lamports or satoshis
+	Balance      string `json:"balance"`
+	Transactions int    `json:"transactions"`
+}
+
+// DiscoveryResult is the output of the discover command
+type DiscoveryResult struct {
+	KeyType    string              `json:"keyType"`
+	Timestamp  string              `json:"timestamp"`
+	Network    string              `json:"network,omitempty"`
+	StartIndex int                 `json:"startIndex"`
+	LastIndex  int                 `json:"lastIndex"`
+	GapLimit   int                 `json:"gapLimit"`
+	Accounts   []DiscoveredAccount `json:"accounts"`
+}
+
+var discoverClient = &http.Client{Timeout: 30 * time.Second}
+
+// rpcCall calls a JSON-RPC 2.0 method of endpoint and decodes its result
+func rpcCall(endpoint, method string, params []any, result any) error {
+	body, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+	resp, err := discoverClient.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: HTTP %s", method, resp.Status)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("%s: %s (%d)", method, response.Error.Message, response.Error.Code)
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// evmActivity returns the balance and nonce of an EVM address; the nonce
+// only counts the transactions it sent
+func evmActivity(endpoint, address string) (*big.Int, int, error) {
+	var balance, nonce string
+	if err := rpcCall(endpoint, "eth_getBalance", []any{address, "latest"}, &balance); err != nil {
+		return nil, 0, err
+	}
+	if err := rpcCall(endpoint, "eth_getTransactionCount", []any{address, "latest"}, &nonce); err != nil {
+		return nil, 0, err
+	}
+	wei, err := hexutil.DecodeBig(balance)
+	if err != nil {
+		return nil, 0, fmt.Errorf("eth_getBalance: %w", err)
+	}
+	count, err := hexutil.DecodeUint64(nonce)
+	if err != nil {
+		return nil, 0, fmt.Errorf("eth_getTransactionCount: %w", err)
+	}
+	return wei, int(count), nil
+}
+
+// solanaSignatureLimit caps the signatures counted per Solana address, one
+// page of getSignaturesForAddress
+const solanaSignatureLimit = 1000
+
+// solanaActivity returns the balance and number of signatures of a Solana
+// address, counting at most solanaSignatureLimit
+func solanaActivity(endpoint, address string) (*big.Int, int, error) {
+	var balance struct {
+		Value uint64 `json:"value"`
+	}
+	if err := rpcCall(endpoint, "getBalance", []any{address}, &balance); err != nil {
+		return nil, 0, err
+	}
+	var signatures []json.RawMessage
+	if err := rpcCall(endpoint, "getSignaturesForAddress", []any{address, map[string]any{"limit": solanaSignatureLimit}}, &signatures); err != nil {
+		return nil, 0, err
+	}
+	return new(big.Int).SetUint64(balance.Value), len(signatures), nil
+}
+
+// bitcoinActivity returns the balance and number of transactions of a
+// bitcoin address, confirmed or in the mempool, from an Esplora API such as
+// https://blockstream.info/api
+func bitcoinActivity(endpoint, address string) (*big.Int, int, error) {
+	resp, err := discoverClient.Get(strings.TrimRight(endpoint, "/") + "/address/" + address)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, 0, fmt.Errorf("HTTP %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	type stats struct {
+		Funded  int64 `json:"funded_txo_sum"`
+		Spent   int64 `json:"spent_txo_sum"`
+		TxCount int   `json:"tx_count"`
+	}
+	var info struct {
+		Chain   stats `json:"chain_stats"`
+		Mempool stats `json:"mempool_stats"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, 0, err
+	}
+	balance := info.Chain.Funded - info.Chain.Spent + info.Mempool.Funded - info.Mempool.Spent
+	return big.NewInt(balance), info.Chain.TxCount + info.Mempool.TxCount, nil
+}
+
+// discoverActivity looks up the balance and transaction count of address
+func discoverActivity(keyType, endpoint, address string) (*big.Int, int, error) {
+	switch keyType {
+	case "evm":
+		return evmActivity(endpoint, address)
+	case "solana":
+		return solanaActivity(endpoint, address)
+	case "bitcoin":
+		return bitcoinActivity(endpoint, address)
+	default:
+		return nil, 0, fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+func runDiscover(args []string) {
+	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	keyType := fs.String("type", "evm", "Key type to scan: "+quoteList(discoverKeyTypes))
+	endpoint := fs.String("rpc", "", "evm and solana: JSON-RPC endpoint; bitcoin: Esplora API base URL, e.g. https://blockstream.info/api")
+	fromMnemonic := fs.String("from-mnemonic", "-", "BIP39 mnemonic to scan, or '-' to prompt for it")
+	mnemonicFile := fs.String("mnemonic-file", "", "Scan the BIP39 mnemonic in this file instead")
+	wordlist := fs.String("wordlist", "english", "Language of the mnemonic: english, japanese, korean, spanish, chinese-simplified, chinese-traditional, french, italian or czech")
+	usePassphrase := fs.Bool("passphrase", false, "Prompt for a BIP39 passphrase to derive the seed under")
+	passphraseFile := fs.String("passphrase-file", "", "Read the BIP39 passphrase from this file")
+	passphraseEnv := fs.String("passphrase-env", "", "Read the BIP39 passphrase from this environment variable")
+	pathTemplate := fs.String("path", "", "Scan this path instead of the chain's standard one; i stands for the index, e.g. m/44'/60'/i'/0/0")
+	network := fs.String("network", "mainnet", "bitcoin only: network of the addresses, which must match the endpoint")
+	startIndex := fs.Int("start-index", 0, "Index to start scanning at")
+	gapLimit := fs.Int("gap-limit", 20, "Stop after this many consecutive indexes without history")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	deriver, ok := mnemonicDerivers[*keyType]
+	if !ok || !slices.Contains(discoverKeyTypes, *keyType) {
+		fmt.Printf("Error: Key type must be %s\n", quoteList(discoverKeyTypes))
+		os.Exit(1)
+	}
+	if *endpoint == "" {
+		fmt.Println("Error: RPC endpoint is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *gapLimit <= 0 || *startIndex < 0 {
+		fmt.Println("Error: Gap limit must be greater than 0 and the start index not negative")
+		os.Exit(1)
+	}
+	if _, ok := bitcoinNetworks[*network]; !ok {
+		fmt.Println("Error: Network must be 'mainnet', 'testnet', 'signet', or 'regtest'")
+		os.Exit(1)
+	}
+	if *pathTemplate != "" {
+		pathFunc, indexed, err := parsePathTemplate(*pathTemplate)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !indexed {
+			fmt.Println("Error: -path must contain i to scan indexes")
+			os.Exit(1)
+		}
+		deriver.path = pathFunc
+	}
+
+	if err := setMnemonicWordlist(*wordlist); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	passphrase, err := readPassphrase(*passphraseFile, *passphraseEnv, *usePassphrase)
+	if err != nil {
+		fmt.Printf("Error reading passphrase: %v\n", err)
+		os.Exit(1)
+	}
+	_, seed, err := readMnemonic(*fromMnemonic, *mnemonicFile, passphrase)
+	if err != nil {
+		fmt.Printf("Error reading mnemonic: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := derivationOptions{network: *network, passphrase: passphrase}
+	accounts := []DiscoveredAccount{}
+	index, gap := *startIndex, 0
+	for ; gap < *gapLimit && int64(index) < hardenedOffset; index++ {
+		path, err := deriver.path(index, opts)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		_, address, _, err := deriver.derive(seed, path, opts)
+		if err != nil {
+			fmt.Printf("Error deriving index %d: %v\n", index, err)
+			os.Exit(1)
+		}
+		balance, transactions, err := discoverActivity(*keyType, *endpoint, address)
+		if err != nil {
+			fmt.Printf("Error querying %s: %v\n", address, err)
+			os.Exit(1)
+		}
+
+		if transactions == 0 && balance.Sign() == 0 {
+			gap++
+			continue
+		}
+		gap = 0
+		account := DiscoveredAccount{
+			Index:          index,
+			DerivationPath: formatDerivationPath(path),
+			Address:        address,
+			Balance:        balance.String(),
+			Transactions:   transactions,
+		}
+		accounts = append(accounts, account)
+		fmt.Printf("Index %d %s %s: %d transactions, balance %s\n", index, account.DerivationPath, address, transactions, account.Balance)
+	}
+
+	result := DiscoveryResult{
+		KeyType:    *keyType,
+		Timestamp:  time.Now().Format(time.RFC3339),
+		StartIndex: *startIndex,
+		LastIndex:  index - 1,
+		GapLimit:   *gapLimit,
+		Accounts:   accounts,
+	}
+	if *keyType == "bitcoin" {
+		result.Network = *network
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error creating JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := fmt.Sprintf("%s-discovery_%s.json", *keyType, time.Now().Format("20060102_150405"))
+	if err := writeOutputFile(filename, jsonData); err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Scanned indexes %d to %d and found %d used %s accounts, saved to %s\n", *startIndex, index-1, len(accounts), *keyType, filename)
+}
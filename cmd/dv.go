@@ -0,0 +1,101 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+)
+
+// DistributedValidator describes how each validator key of a batch was split
+// among operators with -dv-operators
+type DistributedValidator struct {
+	Operators int `json:"operators"`
+	Threshold int `json:"threshold"`
+}
+
+// defaultDVThreshold is the smallest threshold tolerating a faulty third of
+// the operators, ceil(2n/3), which Obol and SSV clusters use
+func defaultDVThreshold(operators int) int {
+	return (2*operators + 2) / 3
+}
+
+// splitBLSKey splits sk into shares 1..n of a random degree threshold-1
+// polynomial over the BLS12-381 scalar field, any threshold of which recover
+// sk and whose signatures aggregate to a signature of sk
+func splitBLSKey(random io.Reader, sk *big.Int, operators, threshold int) ([]*big.Int, error) {
+	coefficients := []*big.Int{sk}
+	for range threshold - 1 {
+		c, err := rand.Int(random, fr.Modulus())
+		if err != nil {
+			return nil, err
+		}
+		coefficients = append(coefficients, c)
+	}
+
+	shares := make([]*big.Int, operators)
+	for i := range shares {
+		// Evaluate with Horner's rule at x = i+1
+		x := big.NewInt(int64(i + 1))
+		share := new(big.Int)
+		for j := len(coefficients) - 1; j >= 0; j-- {
+			share.Mul(share, x).Add(share, coefficients[j]).Mod(share, fr.Modulus())
+		}
+		shares[i] = share
+	}
+	return shares, nil
+}
+
+// dvShareExtras splits a hex validator signing key into operator shares,
+// keyed as shareKey_<i> and sharePublicKey_<i> for share index i from 1
+func dvShareExtras(random io.Reader, privateKey string, operators, threshold int) (keyExtras, error) {
+	secret, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := splitBLSKey(random, new(big.Int).SetBytes(secret), operators, threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	extra := make(keyExtras, 2*len(shares))
+	for i, share := range shares {
+		extra[fmt.Sprintf("shareKey_%d", i+1)] = secretExtra(hex.EncodeToString(share.FillBytes(make([]byte, 32))))
+		extra[fmt.Sprintf("sharePublicKey_%d", i+1)] = publicExtra(hex.EncodeToString(blsPublicKey(share)))
+	}
+	return extra, nil
+}
+
+// ethValidatorKeyFiles encrypts each validator's key shares as EIP-2335
+// keystores in charon's cluster layout, node<n>/validator_keys/keystore-<index>.json
+// with the password in keystore-<index>.txt, where node n holds share n+1.
+// Validators without -dv-operators get a single staking-deposit-cli keystore
+func ethValidatorKeyFiles(index int, privateKey, publicKey string, extra map[string]string, password string) (map[string][]byte, error) {
+	if extra["shareKey_1"] == "" {
+		return ethKeystoreFiles(privateKey, publicKey, extra, password)
+	}
+
+	files := make(map[string][]byte)
+	for i := 1; extra[fmt.Sprintf("shareKey_%d", i)] != ""; i++ {
+		secret, err := hex.DecodeString(extra[fmt.Sprintf("shareKey_%d", i)])
+		if err != nil {
+			return nil, err
+		}
+		pubKey, err := hex.DecodeString(extra[fmt.Sprintf("sharePublicKey_%d", i)])
+		if err != nil {
+			return nil, err
+		}
+
+		keystore, err := eip2335Encrypt(secret, pubKey, "", password)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i, err)
+		}
+		name := fmt.Sprintf("node%d/validator_keys/keystore-%d", i-1, index)
+		files[name+".json"] = keystore
+		files[name+".txt"] = []byte(password)
+	}
+	return files, nil
+}
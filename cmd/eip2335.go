@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"unicode"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	eip2335Version     = 4
+	eip2335ScryptN     = 262144
+	eip2335ScryptR     = 8
+	eip2335ScryptP     = 1
+	eip2335PBKDF2C     = 262144
+	eip2335DerivedSize = 32
+)
+
+// eip2335KDFs are the key derivation functions of the -kdf flag
+var eip2335KDFs = []string{"scrypt", "pbkdf2"}
+
+// eip2335KDF is the key derivation function new keystores are encrypted
+// with, set by -kdf
+var eip2335KDF = "scrypt"
+
+// eip2335Module is one step of an EIP-2335 keystore's crypto pipeline
+type eip2335Module struct {
+	Function string         `json:"function"`
+	Params   map[string]any `json:"params"`
+	Message  string         `json:"message"`
+}
+
+// eip2335Keystore is the BLS keystore format consensus clients import
+type eip2335Keystore struct {
+	Crypto struct {
+		KDF      eip2335Module `json:"kdf"`
+		Checksum eip2335Module `json:"checksum"`
+		Cipher   eip2335Module `json:"cipher"`
+	} `json:"crypto"`
+	Description string `json:"description"`
+	PubKey      string `json:"pubkey"`
+	Path        string `json:"path"`
+	UUID        string `json:"uuid"`
+	Version     int    `json:"version"`
+}
+
+// eip2335Password strips the control codes EIP-2335 removes from passwords;
+// its NFKD normalization is the identity on ASCII, so other passwords are
+// rejected rather than encrypted under a key clients would not derive
+func eip2335Password(password string) ([]byte, error) {
+	var stripped []byte
+	for _, r := range password {
+		if r > unicode.MaxASCII {
+			return nil, fmt.Errorf("keystore passwords must be ASCII")
+		}
+		if !unicode.IsControl(r) {
+			stripped = append(stripped, byte(r))
+		}
+	}
+	return stripped, nil
+}
+
+// eip2335DeriveKey derives the decryption key of a keystore from the
+// normalized password with kdf, returning it with the kdf module's params
+func eip2335DeriveKey(kdf string, password, salt []byte) ([]byte, map[string]any, error) {
+	switch kdf {
+	case "scrypt":
+		derivedKey, err := scrypt.Key(password, salt, eip2335ScryptN, eip2335ScryptR, eip2335ScryptP, eip2335DerivedSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		return derivedKey, map[string]any{
+			"dklen": eip2335DerivedSize,
+			"n":     eip2335ScryptN,
+			"r":     eip2335ScryptR,
+			"p":     eip2335ScryptP,
+			"salt":  hex.EncodeToString(salt),
+		}, nil
+	case "pbkdf2":
+		derivedKey := pbkdf2.Key(password, salt, eip2335PBKDF2C, eip2335DerivedSize, sha256.New)
+		return derivedKey, map[string]any{
+			"dklen": eip2335DerivedSize,
+			"c":     eip2335PBKDF2C,
+			"prf":   "hmac-sha256",
+			"salt":  hex.EncodeToString(salt),
+		}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported keystore kdf: %s", kdf)
+}
+
+// eip2335Encrypt encrypts a BLS secret key with AES-128-CTR under a key of
+// password derived with eip2335KDF, returning the keystore JSON
+func eip2335Encrypt(secret, pubKey []byte, path, password string) ([]byte, error) {
+	normalized, err := eip2335Password(password)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	derivedKey, kdfParams, err := eip2335DeriveKey(eip2335KDF, normalized, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(secret))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, secret)
+	checksum := sha256.Sum256(append(append([]byte{}, derivedKey[16:32]...), ciphertext...))
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	var keystore eip2335Keystore
+	keystore.Crypto.KDF = eip2335Module{Function: eip2335KDF, Params: kdfParams}
+	keystore.Crypto.Checksum = eip2335Module{Function: "sha256", Params: map[string]any{}, Message: hex.EncodeToString(checksum[:])}
+	keystore.Crypto.Cipher = eip2335Module{
+		Function: "aes-128-ctr",
+		Params:   map[string]any{"iv": hex.EncodeToString(iv)},
+		Message:  hex.EncodeToString(ciphertext),
+	}
+	keystore.PubKey = hex.EncodeToString(pubKey)
+	keystore.Path = path
+	keystore.UUID = id
+	keystore.Version = eip2335Version
+
+	return json.MarshalIndent(keystore, "", "    ")
+}
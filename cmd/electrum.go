@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// electrumSegwitPrefix is the seed version prefix of native segwit wallets
+	electrumSegwitPrefix = "100"
+	// electrumStandardPrefix is the seed version prefix of legacy P2PKH wallets
+	electrumStandardPrefix = "01"
+	electrumEntropyBits    = 132
+	electrumSeedIterations = 2048
+)
+
+// electrumNormalize normalizes a seed or passphrase as Electrum does before
+// hashing it: NFKD, lower case, accents removed and whitespace collapsed
+func electrumNormalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(norm.NFKD.String(s)) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// electrumSeedVersion returns the hex version hash of a seed, whose prefix
+// tells the wallet type it was generated for
+func electrumSeedVersion(mnemonic string) string {
+	mac := hmac.New(sha512.New, []byte("Seed version"))
+	mac.Write([]byte(electrumNormalize(mnemonic)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// electrumEncode writes i in base 2048 with the English BIP39 words as
+// digits, least significant first
+func electrumEncode(i *big.Int) string {
+	n := big.NewInt(int64(len(wordlists.English)))
+	i = new(big.Int).Set(i)
+	var words []string
+	for i.Sign() > 0 {
+		digit := new(big.Int)
+		i.DivMod(i, n, digit)
+		words = append(words, wordlists.English[digit.Int64()])
+	}
+	return strings.Join(words, " ")
+}
+
+// newElectrumSeed returns a new Electrum segwit seed. As Electrum does, it
+// draws 132 bits of entropy and increments them until the encoded phrase
+// has the segwit version prefix
+func newElectrumSeed(random io.Reader) (string, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), electrumEntropyBits)
+	// Phrases shorter than 12 words are redrawn
+	minimum := new(big.Int).Lsh(big.NewInt(1), electrumEntropyBits-11)
+	var entropy *big.Int
+	for entropy == nil || entropy.Cmp(minimum) < 0 {
+		var err error
+		if entropy, err = rand.Int(random, limit); err != nil {
+			return "", err
+		}
+	}
+
+	for nonce := int64(1); ; nonce++ {
+		mnemonic := electrumEncode(new(big.Int).Add(entropy, big.NewInt(nonce)))
+		if strings.HasPrefix(electrumSeedVersion(mnemonic), electrumSegwitPrefix) {
+			return mnemonic, nil
+		}
+	}
+}
+
+// electrumSeed stretches an Electrum seed and its extension into the BIP32
+// seed of the wallet
+func electrumSeed(mnemonic, passphrase string) []byte {
+	salt := "electrum" + electrumNormalize(passphrase)
+	return pbkdf2.Key([]byte(electrumNormalize(mnemonic)), []byte(salt), electrumSeedIterations, 64, sha512.New)
+}
+
+// readElectrumSeed returns the Electrum segwit seed given as phrase, read
+// from path, or prompted for when phrase is "-", and its BIP32 seed
+func readElectrumSeed(phrase, path, passphrase string) (string, []byte, error) {
+	phrase, err := readMnemonicPhrase(phrase, path)
+	if err != nil {
+		return "", nil, err
+	}
+	mnemonic := electrumNormalize(phrase)
+	switch version := electrumSeedVersion(mnemonic); {
+	case strings.HasPrefix(version, electrumSegwitPrefix):
+		return mnemonic, electrumSeed(mnemonic, passphrase), nil
+	case strings.HasPrefix(version, electrumStandardPrefix):
+		return "", nil, fmt.Errorf("legacy standard Electrum seeds are not supported, only segwit ones")
+	default:
+		return "", nil, fmt.Errorf("not an Electrum segwit seed")
+	}
+}
+
+// electrumPath is m/0'/0/i, where Electrum segwit wallets derive their
+// receive addresses
+func electrumPath(index int, _ derivationOptions) ([]uint32, error) {
+	return []uint32{0 + hardenedOffset, 0, uint32(index)}, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id parameters of -encrypt, the second recommended option of RFC 9106
+// for memory-constrained machines
+const (
+	passwordArgon2Time    = 3
+	passwordArgon2Memory  = 64 * 1024 // KiB
+	passwordArgon2Threads = 4
+)
+
+// passwordEnvelope is a file encrypted with -encrypt: AES-256-GCM under a key
+// derived from a password with argon2id, whose parameters are stored so they
+// can be raised without breaking older files
+type passwordEnvelope struct {
+	KDF        string `json:"kdf"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"`
+	Threads    uint8  `json:"threads"`
+	Salt       []byte `json:"salt"`
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// passwordGCM returns the AES-256-GCM cipher under the key derived from
+// password with the argon2id parameters of envelope
+func passwordGCM(envelope passwordEnvelope, password string) (cipher.AEAD, error) {
+	key := argon2.IDKey([]byte(password), envelope.Salt, envelope.Time, envelope.Memory, envelope.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// passwordEncrypt seals plaintext in a passwordEnvelope under password
+func passwordEncrypt(plaintext []byte, password string) ([]byte, error) {
+	envelope := passwordEnvelope{
+		KDF:     "argon2id",
+		Time:    passwordArgon2Time,
+		Memory:  passwordArgon2Memory,
+		Threads: passwordArgon2Threads,
+		Salt:    make([]byte, 16),
+		Cipher:  "aes-256-gcm",
+	}
+	if _, err := rand.Read(envelope.Salt); err != nil {
+		return nil, err
+	}
+	gcm, err := passwordGCM(envelope, password)
+	if err != nil {
+		return nil, err
+	}
+	envelope.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(envelope.Nonce); err != nil {
+		return nil, err
+	}
+	envelope.Ciphertext = gcm.Seal(nil, envelope.Nonce, plaintext, nil)
+	return json.MarshalIndent(envelope, "", "  ")
+}
+
+// passwordDecrypt opens a passwordEnvelope with password
+func passwordDecrypt(data []byte, password string) ([]byte, error) {
+	var envelope passwordEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.KDF != "argon2id" || envelope.Cipher != "aes-256-gcm" {
+		return nil, fmt.Errorf("unsupported encryption %s with %s", envelope.KDF, envelope.Cipher)
+	}
+	// Refuse parameters that would exhaust memory or never finish
+	if envelope.Time == 0 || envelope.Time > 100 || envelope.Memory > 4*1024*1024 || envelope.Threads == 0 {
+		return nil, fmt.Errorf("invalid argon2id parameters")
+	}
+	gcm, err := passwordGCM(envelope, password)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	plaintext, err := gcm.Open(nil, envelope.Nonce, envelope.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("wrong password or corrupted file")
+	}
+	return plaintext, nil
+}
+
+// runDecrypt implements the decrypt subcommand, which opens a results file
+// encrypted with -encrypt
+func runDecrypt(args []string) {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	in := fs.String("in", "", "Results file encrypted with -encrypt")
+	out := fs.String("out", "-", "Write the decrypted results to this file, '-' for stdout")
+	passwordFile := fs.String("password-file", "", "Read the password from this file instead of prompting")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *in == "" {
+		fmt.Println("Error: Input file is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", *in, err)
+		os.Exit(1)
+	}
+	password, err := readExistingPassword(*passwordFile)
+	if err != nil {
+		fmt.Printf("Error reading password: %v\n", err)
+		os.Exit(1)
+	}
+	plaintext, err := passwordDecrypt(data, password)
+	if err != nil {
+		fmt.Printf("Error decrypting %s: %v\n", *in, err)
+		os.Exit(1)
+	}
+	if *out == "-" {
+		os.Stdout.Write(plaintext)
+		return
+	}
+	if err := writeOutputFile(*out, plaintext); err != nil {
+		fmt.Printf("Error writing %s: %v\n", *out, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	antelopeK1Suffix     = "K1"
+	antelopeLegacyPrefix = "EOS"
+)
+
+// antelopeChecksum returns the first four bytes of ripemd160(data || suffix)
+func antelopeChecksum(data []byte, suffix string) []byte {
+	h := ripemd160.New()
+	h.Write(data)
+	h.Write([]byte(suffix))
+	return h.Sum(nil)[:4]
+}
+
+// antelopeK1String renders a key as <prefix>base58(key || checksum), the
+// checksum covering the "K1" curve suffix
+func antelopeK1String(prefix string, key []byte) string {
+	return prefix + base58.Encode(append(append([]byte{}, key...), antelopeChecksum(key, antelopeK1Suffix)...))
+}
+
+// generateEOSKeyPair returns a PVT_K1_ private key and its PUB_K1_ public key,
+// with the legacy EOS... public key and uncompressed WIF private key, which
+// older wallets and cleos versions expect, as extras
+func generateEOSKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+
+	wif, err := btcutil.NewWIF(privateKey, &chaincfg.MainNetParams, false)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	extra := keyExtras{
+		"legacyPublicKey":  publicExtra(antelopeLegacyPrefix + base58.Encode(append(append([]byte{}, pubKeyBytes...), antelopeChecksum(pubKeyBytes, "")...))),
+		"legacyPrivateKey": secretExtra(wif.String()),
+	}
+
+	return antelopeK1String("PVT_K1_", privateKey.Serialize()), antelopeK1String("PUB_K1_", pubKeyBytes), extra, nil
+}
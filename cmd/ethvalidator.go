@@ -0,0 +1,181 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// ethDepositAmountGwei is the 32 ETH deposit of a new validator
+	ethDepositAmountGwei = 32_000_000_000
+	// ethDepositCLIVersion is reported in deposit data for the launchpad, which
+	// rejects files produced by old staking-deposit-cli releases
+	ethDepositCLIVersion = "2.7.0"
+
+	ethBLSWithdrawalPrefix  = 0x00
+	ethEth1WithdrawalPrefix = 0x01
+)
+
+var ethDepositDomainType = []byte{0x03, 0x00, 0x00, 0x00}
+
+// ethValidatorForkVersions maps the -network flag to the genesis fork version
+// deposit signatures are bound to
+var ethValidatorForkVersions = map[string][]byte{
+	"mainnet": {0x00, 0x00, 0x00, 0x00},
+	"sepolia": {0x90, 0x00, 0x00, 0x69},
+	"holesky": {0x01, 0x01, 0x70, 0x00},
+	"hoodi":   {0x10, 0x00, 0x09, 0x10},
+}
+
+// EthDepositData is one entry of a staking-deposit-cli deposit_data.json
+type EthDepositData struct {
+	PubKey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+	Signature             string `json:"signature"`
+	DepositMessageRoot    string `json:"deposit_message_root"`
+	DepositDataRoot       string `json:"deposit_data_root"`
+	ForkVersion           string `json:"fork_version"`
+	NetworkName           string `json:"network_name"`
+	DepositCLIVersion     string `json:"deposit_cli_version"`
+}
+
+// sha256Concat hashes the concatenation of parts, the SSZ merkle node hash
+func sha256Concat(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// sszChunk right-pads b to a 32-byte chunk
+func sszChunk(b []byte) []byte {
+	chunk := make([]byte, 32)
+	copy(chunk, b)
+	return chunk
+}
+
+// ethDepositRoots returns the SSZ hash tree roots of the DepositMessage and,
+// given its signature, the DepositData for one validator
+func ethDepositRoots(pubKey, withdrawalCredentials, signature []byte) ([]byte, []byte) {
+	zero := make([]byte, 32)
+	amount := sszChunk(binary.LittleEndian.AppendUint64(nil, ethDepositAmountGwei))
+	pubKeyRoot := sha256Concat(pubKey[:32], sszChunk(pubKey[32:]))
+
+	messageRoot := sha256Concat(sha256Concat(pubKeyRoot, withdrawalCredentials), sha256Concat(amount, zero))
+	if signature == nil {
+		return messageRoot, nil
+	}
+
+	signatureRoot := sha256Concat(sha256Concat(signature[:32], signature[32:64]), sha256Concat(signature[64:], zero))
+	dataRoot := sha256Concat(sha256Concat(pubKeyRoot, withdrawalCredentials), sha256Concat(amount, signatureRoot))
+	return messageRoot, dataRoot
+}
+
+// ethDepositDomain computes DOMAIN_DEPOSIT for a fork version; deposits are
+// valid across forks so the genesis validators root is always zero
+func ethDepositDomain(forkVersion []byte) []byte {
+	forkDataRoot := sha256Concat(sszChunk(forkVersion), make([]byte, 32))
+	return append(append([]byte{}, ethDepositDomainType...), forkDataRoot[:28]...)
+}
+
+// deriveEthValidatorKeyPair derives the index-th validator's EIP-2334 signing
+// key (m/12381/3600/index/0/0) and withdrawal key (m/12381/3600/index/0) and
+// signs its 32 ETH deposit; withdrawals go to withdrawalAddress when set and
+// to the BLS withdrawal key otherwise
+func deriveEthValidatorKeyPair(seed []byte, index int, network, withdrawalAddress string) (string, string, keyExtras, error) {
+	forkVersion, ok := ethValidatorForkVersions[network]
+	if !ok {
+		return "", "", nil, fmt.Errorf("unsupported network: %s", network)
+	}
+
+	withdrawalPath := []uint32{12381, 3600, uint32(index), 0}
+	signingPath := append(append([]uint32{}, withdrawalPath...), 0)
+
+	signingKey, err := blsDeriveKey(seed, signingPath)
+	if err != nil {
+		return "", "", nil, err
+	}
+	withdrawalKey, err := blsDeriveKey(seed, withdrawalPath)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKey := blsPublicKey(signingKey)
+	withdrawalPubKey := blsPublicKey(withdrawalKey)
+
+	var credentials []byte
+	if withdrawalAddress != "" {
+		credentials = append([]byte{ethEth1WithdrawalPrefix}, make([]byte, 11)...)
+		credentials = append(credentials, common.HexToAddress(withdrawalAddress).Bytes()...)
+	} else {
+		hash := sha256.Sum256(withdrawalPubKey)
+		credentials = append([]byte{ethBLSWithdrawalPrefix}, hash[1:]...)
+	}
+
+	messageRoot, _ := ethDepositRoots(pubKey, credentials, nil)
+	signature, err := blsSign(signingKey, sha256Concat(messageRoot, ethDepositDomain(forkVersion)))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error signing deposit: %w", err)
+	}
+	_, dataRoot := ethDepositRoots(pubKey, credentials, signature)
+
+	extra := keyExtras{
+		"derivationPath":        publicExtra(formatDerivationPath(signingPath)),
+		"withdrawalPublicKey":   publicExtra(hex.EncodeToString(withdrawalPubKey)),
+		"withdrawalCredentials": publicExtra(hex.EncodeToString(credentials)),
+		"depositSignature":      publicExtra(hex.EncodeToString(signature)),
+		"depositMessageRoot":    publicExtra(hex.EncodeToString(messageRoot)),
+		"depositDataRoot":       publicExtra(hex.EncodeToString(dataRoot)),
+	}
+
+	return hex.EncodeToString(signingKey.FillBytes(make([]byte, 32))), hex.EncodeToString(pubKey), extra, nil
+}
+
+// ethDepositDataJSON assembles the launchpad deposit_data.json for a batch
+func ethDepositDataJSON(network string, publicKeys []string, extras map[string][]string) ([]byte, error) {
+	deposits := make([]EthDepositData, 0, len(publicKeys))
+	for i, pubKey := range publicKeys {
+		deposits = append(deposits, EthDepositData{
+			PubKey:                pubKey,
+			WithdrawalCredentials: extras["withdrawalCredentials"][i],
+			Amount:                ethDepositAmountGwei,
+			Signature:             extras["depositSignature"][i],
+			DepositMessageRoot:    extras["depositMessageRoot"][i],
+			DepositDataRoot:       extras["depositDataRoot"][i],
+			ForkVersion:           hex.EncodeToString(ethValidatorForkVersions[network]),
+			NetworkName:           network,
+			DepositCLIVersion:     ethDepositCLIVersion,
+		})
+	}
+	return json.Marshal(deposits)
+}
+
+// ethKeystoreFiles encrypts a validator's signing key as the EIP-2335
+// keystore staking-deposit-cli writes, keystore-m_12381_3600_<i>_0_0-<time>.json,
+// so the directory can be imported by any consensus client
+func ethKeystoreFiles(privateKey, publicKey string, extra map[string]string, password string) (map[string][]byte, error) {
+	secret, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	pubKey, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	path := extra["derivationPath"]
+	keystore, err := eip2335Encrypt(secret, pubKey, path, password)
+	if err != nil {
+		return nil, err
+	}
+	name := fmt.Sprintf("keystore-%s-%d.json", strings.ReplaceAll(path, "/", "_"), time.Now().Unix())
+	return map[string][]byte{name: keystore}, nil
+}
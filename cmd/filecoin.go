@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	filecoinProtocolSecp256k1 = 1
+	filecoinProtocolDelegated = 4
+	filecoinEAMNamespace      = 10
+)
+
+var filecoinBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// filecoinLotusKey is the key info Lotus hex-encodes for wallet export/import
+type filecoinLotusKey struct {
+	Type       string
+	PrivateKey []byte
+}
+
+// blake2bSum returns the blake2b digest of data truncated to size bytes
+func blake2bSum(size int, data ...[]byte) ([]byte, error) {
+	h, err := blake2b.New(size, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil), nil
+}
+
+// filecoinAddress renders protocol, sub-address header and payload in the
+// textual form, checksumming the full binary address with blake2b-32
+func filecoinAddress(networkPrefix string, protocol byte, header string, binaryHeader, payload []byte) (string, error) {
+	checksum, err := blake2bSum(4, []byte{protocol}, binaryHeader, payload)
+	if err != nil {
+		return "", err
+	}
+	encoded := strings.ToLower(filecoinBase32.EncodeToString(append(append([]byte{}, payload...), checksum...)))
+	return networkPrefix + header + encoded, nil
+}
+
+// generateFilecoinKeyPair returns a Lotus-exportable key and the f1 address,
+// with the paired FEVM f410 and 0x addresses as extras
+func generateFilecoinKeyPair(random io.Reader, testnet bool) (string, string, keyExtras, error) {
+	privateKey, err := newEthereumKey(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	networkPrefix := "f"
+	if testnet {
+		networkPrefix = "t"
+	}
+
+	pubKeyHash, err := blake2bSum(20, crypto.FromECDSAPub(&privateKey.PublicKey))
+	if err != nil {
+		return "", "", nil, err
+	}
+	f1, err := filecoinAddress(networkPrefix, filecoinProtocolSecp256k1, "1", nil, pubKeyHash)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	ethAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	f410, err := filecoinAddress(networkPrefix, filecoinProtocolDelegated, "410f", []byte{filecoinEAMNamespace}, ethAddress.Bytes())
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	keyInfo, err := json.Marshal(filecoinLotusKey{Type: "secp256k1", PrivateKey: crypto.FromECDSA(privateKey)})
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	extra := keyExtras{
+		"f410Address": publicExtra(f410),
+		"ethAddress":  publicExtra(ethAddress.Hex()),
+	}
+
+	return hex.EncodeToString(keyInfo), f1, extra, nil
+}
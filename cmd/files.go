@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+)
+
+// Settings of the files written by a run
+var (
+	// filePerm is the mode of every results, key and companion file
+	filePerm os.FileMode = 0o600
+	// overwriteFiles replaces existing files instead of failing
+	overwriteFiles bool
+	// syncFiles flushes every file to disk before it is reported written
+	syncFiles bool
+	// createdFiles are the files createOutputFile created, rather than
+	// overwrote or wrote through, which are the only ones -archive removes
+	createdFiles = map[string]bool{}
+)
+
+// parseFilePerm parses the octal mode of -perm, e.g. 0640
+func parseFilePerm(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("-perm must be an octal file mode such as 0600, got %q", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// fileFlags are the -force, -perm and -fsync flags of a command that writes
+// files
+type fileFlags struct {
+	force, fsync *bool
+	perm         *string
+}
+
+// addFileFlags registers -force, -perm and -fsync on fs
+func addFileFlags(fs *flag.FlagSet) *fileFlags {
+	return &fileFlags{
+		force: fs.Bool("force", false, "Overwrite existing output files instead of failing"),
+		perm:  fs.String("perm", "0600", "Octal mode of the output files"),
+		fsync: fs.Bool("fsync", false, "Flush every output file to disk before reporting success"),
+	}
+}
+
+// apply sets filePerm, overwriteFiles and syncFiles from the parsed flags
+func (f *fileFlags) apply() error {
+	mode, err := parseFilePerm(*f.perm)
+	if err != nil {
+		return err
+	}
+	filePerm, overwriteFiles, syncFiles = mode, *f.force, *f.fsync
+	return nil
+}
+
+// createOutputFile creates path with filePerm, regardless of the umask, or
+// fails if it exists unless overwriteFiles is set. Devices and pipes such as
+// /dev/null are written to as they are
+func createOutputFile(path string) (*os.File, error) {
+	if info, err := os.Stat(path); err == nil && !info.Mode().IsRegular() {
+		return os.OpenFile(path, os.O_WRONLY, 0)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePerm)
+	if err == nil {
+		createdFiles[path] = true
+	} else if errors.Is(err, fs.ErrExist) {
+		if !overwriteFiles {
+			return nil, fmt.Errorf("%s already exists, use -force to overwrite it", path)
+		}
+		f, err = os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, filePerm)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// An overwritten file keeps its mode otherwise
+	if err := f.Chmod(filePerm); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// closeOutputFile closes a file of createOutputFile, syncing it first with
+// syncFiles
+func closeOutputFile(f *os.File) error {
+	if syncFiles {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// writeOutputFile writes data to path as createOutputFile creates it
+func writeOutputFile(path string, data []byte) error {
+	f, err := createOutputFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return closeOutputFile(f)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withFileSettings sets the -perm and -force settings for the rest of the
+// test, with no file created yet
+func withFileSettings(t *testing.T, perm os.FileMode, overwrite bool) {
+	t.Helper()
+	t.Cleanup(func() { filePerm, overwriteFiles, createdFiles = 0o600, false, map[string]bool{} })
+	filePerm, overwriteFiles, createdFiles = perm, overwrite, map[string]bool{}
+}
+
+func TestWriteOutputFileRefusesExistingFiles(t *testing.T) {
+	withFileSettings(t, 0o600, false)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte("kept"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := writeOutputFile(path, []byte("new"))
+	if err == nil || !strings.HasSuffix(err.Error(), "already exists, use -force to overwrite it") {
+		t.Fatalf("writeOutputFile = %v, want an already exists error", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "kept" {
+		t.Errorf("existing file overwritten with %q", data)
+	}
+	if createdFiles[path] {
+		t.Error("existing file recorded as created")
+	}
+}
+
+func TestWriteOutputFileForceResetsMode(t *testing.T) {
+	withFileSettings(t, 0o640, true)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, []byte("old contents"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeOutputFile(path, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" || info.Mode().Perm() != 0o640 {
+		t.Errorf("overwritten file holds %q with mode %v, want \"new\" with 0640", data, info.Mode().Perm())
+	}
+	if createdFiles[path] {
+		t.Error("overwritten file recorded as created")
+	}
+}
+
+func TestWriteOutputFileIgnoresUmask(t *testing.T) {
+	withFileSettings(t, 0o664, false)
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := writeOutputFile(path, []byte("{}")); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o664 || !createdFiles[path] {
+		t.Errorf("created file has mode %v and created %v, want 0664 and true", info.Mode().Perm(), createdFiles[path])
+	}
+}
+
+func TestParseFilePerm(t *testing.T) {
+	if mode, err := parseFilePerm("0640"); err != nil || mode != 0o640 {
+		t.Errorf("parseFilePerm(0640) = %v, %v", mode, err)
+	}
+	for _, s := range []string{"0800", "1777", "rw-r--r--", ""} {
+		if _, err := parseFilePerm(s); err == nil {
+			t.Errorf("parseFilePerm(%q) succeeded", s)
+		}
+	}
+}
+
+func TestWriteArchiveOnlyRemovesCreatedFiles(t *testing.T) {
+	withFileSettings(t, 0o600, true)
+	dir := t.TempDir()
+	created := filepath.Join(dir, "keys", "0.json")
+	existing := filepath.Join(dir, "existing.json")
+	if err := os.Mkdir(filepath.Dir(created), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, []byte("before the run"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{created, existing} {
+		if err := writeOutputFile(path, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	archive := filepath.Join(dir, "keys.zip")
+	if err := writeArchive(archive, "archive password", []string{created, existing}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("archive not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(created)); !os.IsNotExist(err) {
+		t.Errorf("created file and its directory not removed: %v", err)
+	}
+	if _, err := os.Stat(existing); err != nil {
+		t.Errorf("file of before the run removed: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// outputFormats are the formats of the -format flag
+var outputFormats = []string{"json", "csv", "yaml", "env", "k8s-secret", "ndjson", "solana-json"}
+
+// keyFileFormats are the formats of the -format flag that write a file per
+// key in place of the results file
+var keyFileFormats = []string{"keystore", "paper"}
+
+// csvHeader names the columns of the csv format
+var csvHeader = []string{"index", "type", "address", "privateKey", "derivationPath"}
+
+// encodeCSV renders one row per keypair, numbered from startIndex, for
+// spreadsheets and airdrop tools. A mnemonic column is added when the keys
+// were derived from one, so the backup is not lost; the other extras only
+// appear in the json format. Results without private keys, such as those of
+// -public-file, have no privateKey column. Keys of -insecure-seed get an
+// insecure column, true on every row, so the rows stay marked when copied
+func encodeCSV(result KeyGenResult, startIndex int) ([]byte, error) {
+	mnemonics := result.Extra["mnemonic"]
+	header := csvHeader
+	withMnemonic := result.Mnemonic != "" || len(mnemonics) > 0
+	if withMnemonic {
+		header = append(slices.Clip(csvHeader), "mnemonic")
+	}
+	if result.Insecure {
+		header = append(slices.Clip(header), "insecure")
+	}
+	public := len(result.PrivateKeys) == 0
+	if public {
+		header = slices.Delete(slices.Clone(header), 3, 4)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	paths := result.Extra["derivationPath"]
+	for i, publicKey := range result.PublicKeys {
+		row := []string{strconv.Itoa(startIndex + i), result.KeyType, publicKey, "", ""}
+		if i < len(result.PrivateKeys) {
+			row[3] = result.PrivateKeys[i]
+		}
+		if i < len(paths) {
+			row[4] = paths[i]
+		}
+		switch {
+		case i < len(mnemonics):
+			row = append(row, mnemonics[i])
+		case withMnemonic:
+			row = append(row, result.Mnemonic)
+		}
+		if result.Insecure {
+			row = append(row, "true")
+		}
+		if public {
+			row = slices.Delete(row, 3, 4)
+		}
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// encodeYAML renders result as block-style YAML with the keys and omitted
+// fields of its JSON encoding
+func encodeYAML(result KeyGenResult) ([]byte, error) {
+	jsonData, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is YAML in flow style, so parse it and drop the styles
+	var node yaml.Node
+	if err := yaml.Unmarshal(jsonData, &node); err != nil {
+		return nil, err
+	}
+	clearYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearYAMLStyle resets node and its children to the default block style
+// and plain scalars. Strings that YAML 1.1 parsers would read as numbers,
+// such as 0x addresses, stay quoted
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && looksNumeric(node.Value) {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
+
+// looksNumeric reports whether s parses as an integer in any base or a float
+func looksNumeric(s string) bool {
+	if _, ok := new(big.Int).SetString(s, 0); ok {
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// envPrefix is prepended to the variable names of the env format
+var envPrefix string
+
+// validEnvName reports whether name can start a shell variable name
+func validEnvName(name string) bool {
+	for i, r := range name {
+		if r != '_' && (r > unicode.MaxASCII || !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return true
+}
+
+// envValue quotes value for dotenv files if it is not a plain word, in
+// single quotes, which dotenv parsers take literally, unless it has one
+func envValue(value string) string {
+	plain := strings.IndexFunc(value, func(r rune) bool {
+		return !strings.ContainsRune("_-.,:/+=@", r) && (r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r))
+	}) < 0
+	switch {
+	case plain:
+		return value
+	case !strings.Contains(value, "'"):
+		return "'" + value + "'"
+	default:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+}
+
+// encodeEnv renders a PRIVATE_KEY_<index> and ADDRESS_<index> variable per
+// keypair, numbered from startIndex, for bots and test configs that read
+// dotenv files. Keys derived from a mnemonic are followed by MNEMONIC, or
+// MNEMONIC_<index> with one mnemonic per key. Keys of -insecure-seed are
+// preceded by a warning and INSECURE=true
+func encodeEnv(result KeyGenResult, startIndex int) ([]byte, error) {
+	var buf bytes.Buffer
+	if result.Insecure {
+		fmt.Fprintf(&buf, "# %s\n%sINSECURE=true\n", insecureSeedWarning, envPrefix)
+	}
+	mnemonics := result.Extra["mnemonic"]
+	for i, publicKey := range result.PublicKeys {
+		index := startIndex + i
+		if i < len(result.PrivateKeys) {
+			fmt.Fprintf(&buf, "%sPRIVATE_KEY_%d=%s\n", envPrefix, index, envValue(result.PrivateKeys[i]))
+		}
+		fmt.Fprintf(&buf, "%sADDRESS_%d=%s\n", envPrefix, index, envValue(publicKey))
+		if i < len(mnemonics) {
+			fmt.Fprintf(&buf, "%sMNEMONIC_%d=%s\n", envPrefix, index, envValue(mnemonics[i]))
+		}
+	}
+	if result.Mnemonic != "" {
+		fmt.Fprintf(&buf, "%sMNEMONIC=%s\n", envPrefix, envValue(result.Mnemonic))
+	}
+	return buf.Bytes(), nil
+}
+
+// formatExtension returns the file extension of format
+func formatExtension(format string) string {
+	switch format {
+	case "solana-json":
+		return "json"
+	case "k8s-secret":
+		return "yaml"
+	}
+	return format
+}
+
+// resultsFilename returns the default name of the results file
+func resultsFilename(keyType, extension string, insecure bool) string {
+	if insecure {
+		return fmt.Sprintf("%s_insecure_keys_%s.%s", keyType, time.Now().Format("20060102_150405"), extension)
+	}
+	return fmt.Sprintf("%s_keys_%s.%s", keyType, time.Now().Format("20060102_150405"), extension)
+}
+
+// splitResult returns the results of one keypair of the batch result, for
+// -split-files. Only values of the keypair itself and settings describing it
+// are kept: batch-wide secrets, such as a shared mnemonic, descriptors or
+// extended keys, would give every file the keys of the others
+func splitResult(result KeyGenResult, privateKey, publicKey string, extra map[string]string) KeyGenResult {
+	part := KeyGenResult{
+		KeyType:              result.KeyType,
+		Count:                1,
+		Timestamp:            result.Timestamp,
+		PrivateKeys:          []string{privateKey},
+		PublicKeys:           []string{publicKey},
+		Network:              result.Network,
+		HRP:                  result.HRP,
+		Scheme:               result.Scheme,
+		SS58Prefix:           result.SS58Prefix,
+		WalletVersion:        result.WalletVersion,
+		Workchain:            result.Workchain,
+		DistributedValidator: result.DistributedValidator,
+		MnemonicFormat:       result.MnemonicFormat,
+		Passphrase:           result.Passphrase,
+		Insecure:             result.Insecure,
+		publicExtras:         result.publicExtras,
+	}
+	if len(extra) > 0 {
+		part.Extra = make(map[string][]string, len(extra))
+		for k, v := range extra {
+			part.Extra[k] = []string{v}
+		}
+	}
+	return part
+}
+
+// splitFileNames are the ways -split-name names the files of -split-files
+var splitFileNames = []string{"index", "address"}
+
+// encodeResult renders result in format, one of outputFormats other than
+// ndjson, which is written as the keys are generated
+func encodeResult(result KeyGenResult, format string, startIndex int) ([]byte, error) {
+	switch format {
+	case "csv":
+		return encodeCSV(result, startIndex)
+	case "yaml":
+		return encodeYAML(result)
+	case "env":
+		return encodeEnv(result, startIndex)
+	case "k8s-secret":
+		return encodeK8sSecret(result, startIndex)
+	case "solana-json":
+		return encodeSolanaJSON(result)
+	default:
+		return json.MarshalIndent(result, "", "  ")
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+)
+
+// testResult is a batch of two evm keys derived from abandonMnemonic
+var testResult = KeyGenResult{
+	KeyType:      "evm",
+	Count:        2,
+	Timestamp:    "2026-01-02T03:04:05Z",
+	PrivateKeys:  []string{"0x1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727", "0x9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6"},
+	PublicKeys:   []string{"0x9858EfFD232B4033E47d90003D41EC34EcaEda94", "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"},
+	Mnemonic:     abandonMnemonic,
+	Extra:        map[string][]string{"derivationPath": {"m/44'/60'/0'/0/0", "m/44'/60'/0'/0/1"}},
+	publicExtras: []string{"derivationPath"},
+}
+
+func TestEncodeCSV(t *testing.T) {
+	data, err := encodeResult(testResult, "csv", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `index,type,address,privateKey,derivationPath,mnemonic
+3,evm,0x9858EfFD232B4033E47d90003D41EC34EcaEda94,0x1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727,m/44'/60'/0'/0/0,` + abandonMnemonic + `
+4,evm,0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0,0x9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6,m/44'/60'/0'/0/1,` + abandonMnemonic + `
+`
+	if string(data) != want {
+		t.Errorf("csv:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestEncodeYAMLQuotesNumericStrings(t *testing.T) {
+	data, err := encodeResult(testResult, "yaml", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// YAML 1.1 parsers would read the unquoted addresses as hex numbers
+	for _, line := range []string{
+		"keyType: evm\n",
+		"timestamp: \"2026-01-02T03:04:05Z\"\n",
+		"  - \"0x9858EfFD232B4033E47d90003D41EC34EcaEda94\"\n",
+		"mnemonic: " + abandonMnemonic + "\n",
+		"    - m/44'/60'/0'/0/0\n",
+	} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("yaml has no line %q:\n%s", line, data)
+		}
+	}
+}
+
+func TestEncodeEnv(t *testing.T) {
+	envPrefix = "BOT_"
+	t.Cleanup(func() { envPrefix = "" })
+	data, err := encodeResult(testResult, "env", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `BOT_PRIVATE_KEY_0=0x1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727
+BOT_ADDRESS_0=0x9858EfFD232B4033E47d90003D41EC34EcaEda94
+BOT_PRIVATE_KEY_1=0x9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6
+BOT_ADDRESS_1=0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0
+BOT_MNEMONIC='` + abandonMnemonic + `'
+`
+	if string(data) != want {
+		t.Errorf("env:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestEnvValue(t *testing.T) {
+	for value, want := range map[string]string{
+		"0xabc":          "0xabc",
+		"m/44'/60'/0'/0": `"m/44'/60'/0'/0"`,
+		"two words":      "'two words'",
+		`say "hi" it's`:  `"say \"hi\" it's"`,
+		"":               "",
+	} {
+		if got := envValue(value); got != want {
+			t.Errorf("envValue(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
+
+func TestSplitResultDropsBatchSecrets(t *testing.T) {
+	part := splitResult(testResult, testResult.PrivateKeys[1], testResult.PublicKeys[1], map[string]string{"derivationPath": "m/44'/60'/0'/0/1"})
+	if part.Count != 1 || part.Mnemonic != "" || len(part.PrivateKeys) != 1 || part.PublicKeys[0] != testResult.PublicKeys[1] {
+		t.Errorf("splitResult = %+v", part)
+	}
+}
+
+func TestEncodeK8sSecret(t *testing.T) {
+	t.Cleanup(func() { k8sName, k8sNamespace, k8sPerKey = nil, "", false })
+	k8sNamespace = "bots"
+	k8sName = template.Must(template.New("k8s-name").Funcs(templateFuncs).Option("missingkey=error").Parse(k8sBatchNameTemplate))
+	data, err := encodeResult(testResult, "k8s-secret", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: v1
+kind: Secret
+metadata:
+  name: evm-keys
+  namespace: bots
+  labels:
+    account-generator/key-type: evm
+    app.kubernetes.io/managed-by: account-generator
+type: Opaque
+stringData:
+  PRIVATE_KEY_0: "0x1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727"
+  ADDRESS_0: "0x9858EfFD232B4033E47d90003D41EC34EcaEda94"
+  PRIVATE_KEY_1: "0x9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6"
+  ADDRESS_1: "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0"
+  MNEMONIC: ` + abandonMnemonic + `
+`
+	if string(data) != want {
+		t.Errorf("k8s-secret:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Addresses are not valid Secret names
+	k8sPerKey = true
+	k8sName = template.Must(template.New("k8s-name").Funcs(templateFuncs).Option("missingkey=error").Parse("{{.Address}}"))
+	if _, err := encodeResult(testResult, "k8s-secret", 0); err == nil || !strings.Contains(err.Error(), "is not lowercase") {
+		t.Errorf("encodeK8sSecret = %v, want an invalid name error", err)
+	}
+}
+
+func TestEncodePublicCSV(t *testing.T) {
+	public := publicResult(testResult)
+	public.Insecure = true
+	data, err := encodeResult(public, "csv", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `index,type,address,derivationPath,insecure
+0,evm,0x9858EfFD232B4033E47d90003D41EC34EcaEda94,m/44'/60'/0'/0/0,true
+1,evm,0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0,m/44'/60'/0'/0/1,true
+`
+	if string(data) != want {
+		t.Errorf("public csv:\n%s\nwant:\n%s", data, want)
+	}
+}
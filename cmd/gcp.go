@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/api/secretmanager/v1"
+)
+
+// Secret Manager and Cloud Storage are called with Google's API client
+// libraries, authorized with a token source of Application Default
+// Credentials
+
+const gcpScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// gcpClientOptions authorizes a Google API client with the access token of
+// GOOGLE_OAUTH_ACCESS_TOKEN, or with Application Default Credentials: the
+// credentials file of GOOGLE_APPLICATION_CREDENTIALS or of gcloud auth
+// application-default login, which may configure workload identity
+// federation, or the metadata server of GCE or GKE
+func gcpClientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return []option.ClientOption{option.WithTokenSource(oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))}, nil
+	}
+	creds, err := google.FindDefaultCredentials(ctx, gcpScope)
+	if err != nil {
+		return nil, fmt.Errorf("no Google credentials, set GOOGLE_APPLICATION_CREDENTIALS or run on GCP: %w", err)
+	}
+	return []option.ClientOption{option.WithTokenSource(creds.TokenSource)}, nil
+}
+
+// gcpSecretIDTemplate names the secrets of -gcp-project by default
+const gcpSecretIDTemplate = "{{.Type}}-{{.Address}}"
+
+// parseGCPSecretID parses a -gcp-secret-id template, executed with each
+// keyRecord
+func parseGCPSecretID(id string) (*template.Template, error) {
+	return template.New("gcp-secret-id").Funcs(templateFuncs).Option("missingkey=error").Parse(id)
+}
+
+// validGCPLabel reports whether s can be a label key or value: lowercase
+// letters, digits, underscores and dashes, at most 63 of them. Keys must
+// also start with a letter
+func validGCPLabel(s string, key bool) bool {
+	if len(s) > 63 || key && (s == "" || s[0] < 'a' || s[0] > 'z') {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseGCPLabels parses comma-separated key=value labels
+func parseGCPLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if s == "" {
+		return labels, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || !validGCPLabel(key, true) || !validGCPLabel(value, false) {
+			return nil, fmt.Errorf("label %q is not key=value of lowercase letters, digits, _ and -", pair)
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
+
+// newGCPBatchID returns an ID shared by the secrets of one run, valid as a
+// label value
+func newGCPBatchID() (string, error) {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix), nil
+}
+
+// writeGCPSecrets creates a Secret Manager secret in project per keypair of
+// result, numbered from startIndex, with the ID of executing id with its
+// keyRecord, and adds its first version: the JSON of its address, private
+// key and chain-specific values. Secrets are labelled with the chain, the
+// batch ID and labels. Existing secrets are never written to. The number
+// of secrets created is returned
+func writeGCPSecrets(project string, result KeyGenResult, startIndex int, id *template.Template, labels map[string]string, batchID string) (int, error) {
+	ctx := context.Background()
+	opts, err := gcpClientOptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// gcloud's override of the endpoint, e.g. for a regional one
+	if override := os.Getenv("CLOUDSDK_API_ENDPOINT_OVERRIDES_SECRETMANAGER"); override != "" {
+		opts = append(opts, option.WithEndpoint(strings.TrimRight(override, "/")+"/"))
+	}
+	service, err := secretmanager.NewService(ctx, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	secretLabels := map[string]string{"chain": result.KeyType, "batch": batchID}
+	for k, v := range labels {
+		secretLabels[k] = v
+	}
+	for i, record := range keyRecords(result, startIndex) {
+		var secretID strings.Builder
+		if err := id.Execute(&secretID, record); err != nil {
+			return i, err
+		}
+		value := map[string]string{"address": record.Address, "privateKey": record.PrivateKey}
+		for k, v := range record.Extra {
+			value[k] = v
+		}
+		payload, err := json.Marshal(value)
+		if err != nil {
+			return i, err
+		}
+
+		secret := &secretmanager.Secret{
+			Replication: &secretmanager.Replication{Automatic: &secretmanager.Automatic{}},
+			Labels:      secretLabels,
+		}
+		created, err := service.Projects.Secrets.Create("projects/"+project, secret).SecretId(secretID.String()).Context(ctx).Do()
+		if err != nil {
+			return i, err
+		}
+		version := &secretmanager.AddSecretVersionRequest{Payload: &secretmanager.SecretPayload{Data: base64.StdEncoding.EncodeToString(payload)}}
+		if _, err := service.Projects.Secrets.AddVersion(created.Name, version).Context(ctx).Do(); err != nil {
+			return i, err
+		}
+	}
+	return len(result.PublicKeys), nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteGCPSecrets(t *testing.T) {
+	type request struct {
+		path, query, auth string
+		body              map[string]any
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, request{r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), body})
+		if strings.HasSuffix(r.URL.Path, "/secrets") {
+			io.WriteString(w, `{"name":"projects/123/secrets/`+r.URL.Query().Get("secretId")+`"}`)
+			return
+		}
+		io.WriteString(w, `{}`)
+	}))
+	defer server.Close()
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "ya29.test")
+	t.Setenv("CLOUDSDK_API_ENDPOINT_OVERRIDES_SECRETMANAGER", server.URL+"/")
+
+	id, err := parseGCPSecretID(gcpSecretIDTemplate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := KeyGenResult{KeyType: "evm", PrivateKeys: []string{"01"}, PublicKeys: []string{"0xabc"}}
+	written, err := writeGCPSecrets("keys-project", result, 3, id, map[string]string{"team": "ops"}, "batch1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 1 || len(requests) != 2 {
+		t.Fatalf("%d secrets written with %d requests, want 1 with 2", written, len(requests))
+	}
+
+	create, add := requests[0], requests[1]
+	if create.path != "/v1/projects/keys-project/secrets" || create.query != "alt=json&prettyPrint=false&secretId=evm-0xabc" {
+		t.Errorf("secret created with %s?%s", create.path, create.query)
+	}
+	labels, _ := create.body["labels"].(map[string]any)
+	if labels["chain"] != "evm" || labels["batch"] != "batch1" || labels["team"] != "ops" {
+		t.Errorf("secret labels %v", labels)
+	}
+	if add.path != "/v1/projects/123/secrets/evm-0xabc:addVersion" {
+		t.Errorf("version added with %s", add.path)
+	}
+	payload, _ := add.body["payload"].(map[string]any)
+	data, _ := base64.StdEncoding.DecodeString(payload["data"].(string))
+	if string(data) != `{"address":"0xabc","privateKey":"01"}` {
+		t.Errorf("secret payload %s", data)
+	}
+	for _, r := range requests {
+		if r.auth != "Bearer ya29.test" {
+			t.Errorf("%s authorized with %q", r.path, r.auth)
+		}
+	}
+}
+
+func TestUploadGCS(t *testing.T) {
+	var query, kmsKey string
+	var body []byte
+	exists := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload/storage/v1/b/keys/o" {
+			http.NotFound(w, r)
+			return
+		}
+		if exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			io.WriteString(w, `{"error":{"code":412,"message":"At least one of the pre-conditions you specified did not hold."}}`)
+			return
+		}
+		query, kmsKey = r.URL.RawQuery, r.URL.Query().Get("kmsKeyName")
+		body, _ = io.ReadAll(r.Body)
+		io.WriteString(w, `{"name":"runs/evm.json","bucket":"keys"}`)
+	}))
+	defer server.Close()
+	t.Setenv("STORAGE_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+	uploadKMSKey = "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	t.Cleanup(func() { uploadKMSKey = "" })
+
+	if err := uploadGCS("keys", "runs/evm.json", []byte(`{"count":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(query, "ifGenerationMatch=0") || kmsKey != uploadKMSKey {
+		t.Errorf("uploaded with query %s", query)
+	}
+	if !strings.Contains(string(body), `{"count":1}`) || !strings.Contains(string(body), `"name":"runs/evm.json"`) {
+		t.Errorf("upload body %q does not hold the object", body)
+	}
+
+	exists = true
+	err := uploadGCS("keys", "runs/evm.json", []byte("{}"))
+	if err == nil || err.Error() != "gs://keys/runs/evm.json already exists" {
+		t.Fatalf("uploadGCS = %v, want an already exists error", err)
+	}
+}
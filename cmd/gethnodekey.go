@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// generateGethNodeKeyPair returns a hex devp2p node key and the node's
+// 64-byte public key, with its enode URL at host and port as an extra
+func generateGethNodeKeyPair(random io.Reader, host string, port int) (string, string, keyExtras, error) {
+	privateKey, err := newEthereumKey(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// The node ID drops the 0x04 prefix of the uncompressed public key
+	nodeID := hex.EncodeToString(crypto.FromECDSAPub(&privateKey.PublicKey)[1:])
+	extra := keyExtras{
+		"enode": publicExtra(fmt.Sprintf("enode://%s@%s", nodeID, net.JoinHostPort(host, strconv.Itoa(port)))),
+	}
+
+	return hex.EncodeToString(crypto.FromECDSA(privateKey)), nodeID, extra, nil
+}
+
+// gethNodeKeyFiles writes each key as <index>/geth/nodekey, its path under a
+// geth --datadir, in the hex form geth reads with --nodekey
+func gethNodeKeyFiles(index int, privateKey, _ string, _ map[string]string, _ string) (map[string][]byte, error) {
+	return map[string][]byte{
+		fmt.Sprintf("%d/geth/nodekey", index): []byte(privateKey),
+	}, nil
+}
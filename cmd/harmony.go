@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const harmonyHRP = "one"
+
+// evmAltEncodings lists the -alt-encoding values accepted for the evm type,
+// each rendering an EVM address in another chain's format
+var evmAltEncodings = map[string]func(address common.Address) (string, error){
+	"harmony": harmonyAddress,
+}
+
+// harmonyAddress returns the one1... bech32 form of an EVM address, which
+// Harmony wallets and explorers show for the same account
+func harmonyAddress(address common.Address) (string, error) {
+	return encodeBech32(harmonyHRP, address.Bytes())
+}
+
+// evmAltEncodingExtra encodes address with the named -alt-encoding, keyed as
+// <encoding>Address
+func evmAltEncodingExtra(encoding, address string) (keyExtras, error) {
+	encode, ok := evmAltEncodings[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unsupported alt encoding: %s", encoding)
+	}
+	encoded, err := encode(common.HexToAddress(address))
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s address: %w", encoding, err)
+	}
+	return keyExtras{encoding + "Address": publicExtra(encoded)}, nil
+}
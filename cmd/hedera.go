@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// DER prefixes the Hedera SDKs use for PrivateKey.toStringDer and
+// PublicKey.toStringDer; the raw key bytes follow directly
+var (
+	hederaEd25519PrivateDERPrefix   = []byte{0x30, 0x2e, 0x02, 0x01, 0x00, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x04, 0x22, 0x04, 0x20}
+	hederaEd25519PublicDERPrefix    = []byte{0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00}
+	hederaSecp256k1PrivateDERPrefix = []byte{0x30, 0x30, 0x02, 0x01, 0x00, 0x30, 0x07, 0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a, 0x04, 0x22, 0x04, 0x20}
+	hederaSecp256k1PublicDERPrefix  = []byte{0x30, 0x2d, 0x30, 0x07, 0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a, 0x03, 0x22, 0x00}
+)
+
+// hederaSchemes lists the -scheme values accepted for the hedera type
+var hederaSchemes = []string{"ed25519", "secp256k1"}
+
+// generateHederaKeyPair returns DER-hex private and public keys; secp256k1
+// keys also get the EVM alias address as an extra
+func generateHederaKeyPair(random io.Reader, scheme string) (string, string, keyExtras, error) {
+	extra := make(keyExtras)
+	var privateDER, publicDER []byte
+
+	switch scheme {
+	case "ed25519":
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, seed); err != nil {
+			return "", "", nil, err
+		}
+		pubKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		privateDER = append(append([]byte{}, hederaEd25519PrivateDERPrefix...), seed...)
+		publicDER = append(append([]byte{}, hederaEd25519PublicDERPrefix...), pubKey...)
+	case "secp256k1":
+		privateKey, err := newSecp256k1Key(random)
+		if err != nil {
+			return "", "", nil, err
+		}
+		privateDER = append(append([]byte{}, hederaSecp256k1PrivateDERPrefix...), privateKey.Serialize()...)
+		publicDER = append(append([]byte{}, hederaSecp256k1PublicDERPrefix...), privateKey.PubKey().SerializeCompressed()...)
+		extra["evmAddress"] = publicExtra(crypto.PubkeyToAddress(*privateKey.PubKey().ToECDSA()).Hex())
+	default:
+		return "", "", nil, fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	return hex.EncodeToString(privateDER), hex.EncodeToString(publicDER), extra, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// hyperliquidSignatureChainID is the chain ID Hyperliquid's user-signed
+// actions are signed under, Arbitrum Sepolia's 421614
+const hyperliquidSignatureChainID = 0x66eee
+
+var (
+	hyperliquidDomainType  = []byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)")
+	hyperliquidApproveType = []byte("HyperliquidTransaction:ApproveAgent(string hyperliquidChain,address agentAddress,string agentName,uint64 nonce)")
+)
+
+// hyperliquidChains maps the -network flag to the hyperliquidChain of actions
+var hyperliquidChains = map[string]string{
+	"mainnet": "Mainnet",
+	"testnet": "Testnet",
+}
+
+// HyperliquidApproveAgent is the approveAgent action of the /exchange API
+type HyperliquidApproveAgent struct {
+	Type             string `json:"type"`
+	HyperliquidChain string `json:"hyperliquidChain"`
+	SignatureChainID string `json:"signatureChainId"`
+	AgentAddress     string `json:"agentAddress"`
+	AgentName        string `json:"agentName,omitempty"`
+	Nonce            uint64 `json:"nonce"`
+}
+
+// HyperliquidSignature is the r, s, v form of an action signature
+type HyperliquidSignature struct {
+	R string `json:"r"`
+	S string `json:"s"`
+	V byte   `json:"v"`
+}
+
+// HyperliquidExchangeRequest is the /exchange request body approving an agent;
+// Signature is nil until the master account signs the action
+type HyperliquidExchangeRequest struct {
+	Action    HyperliquidApproveAgent `json:"action"`
+	Nonce     uint64                  `json:"nonce"`
+	Signature *HyperliquidSignature   `json:"signature,omitempty"`
+}
+
+// hyperliquidApproveAgentHash returns the EIP-712 digest of an approveAgent
+// action under the HyperliquidSignTransaction domain
+func hyperliquidApproveAgentHash(action HyperliquidApproveAgent) []byte {
+	chainID := common.LeftPadBytes(big.NewInt(hyperliquidSignatureChainID).Bytes(), 32)
+	domain := crypto.Keccak256(
+		crypto.Keccak256(hyperliquidDomainType),
+		crypto.Keccak256([]byte("HyperliquidSignTransaction")),
+		crypto.Keccak256([]byte("1")),
+		chainID,
+		make([]byte, 32),
+	)
+	message := crypto.Keccak256(
+		crypto.Keccak256(hyperliquidApproveType),
+		crypto.Keccak256([]byte(action.HyperliquidChain)),
+		common.LeftPadBytes(common.HexToAddress(action.AgentAddress).Bytes(), 32),
+		crypto.Keccak256([]byte(action.AgentName)),
+		common.LeftPadBytes(binary.BigEndian.AppendUint64(nil, action.Nonce), 32),
+	)
+	return crypto.Keccak256([]byte{0x19, 0x01}, domain, message)
+}
+
+// readHyperliquidMasterKey reads the hex private key of the account approving
+// the agents from path
+func readHyperliquidMasterKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+}
+
+// generateHyperliquidAgentKeyPair returns a hex agent wallet key and its
+// address, with the /exchange request approving it as an agent of the master
+// account as an extra; the request is signed when masterKey is set
+func generateHyperliquidAgentKeyPair(random io.Reader, network, agentName string, index int, masterKey *ecdsa.PrivateKey) (string, string, keyExtras, error) {
+	privateKey, address, err := generateEVMKeyPair(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Nonces are millisecond timestamps and must be unique per master account
+	nonce := uint64(time.Now().UnixMilli()) + uint64(index)
+	action := HyperliquidApproveAgent{
+		Type:             "approveAgent",
+		HyperliquidChain: hyperliquidChains[network],
+		SignatureChainID: hexutil.EncodeUint64(hyperliquidSignatureChainID),
+		AgentAddress:     strings.ToLower(address),
+		AgentName:        agentName,
+		Nonce:            nonce,
+	}
+	request := HyperliquidExchangeRequest{Action: action, Nonce: nonce}
+	digest := hyperliquidApproveAgentHash(action)
+
+	extra := keyExtras{
+		"approveAgentHash": secretExtra(hexutil.Encode(digest)),
+	}
+
+	if masterKey != nil {
+		sig, err := crypto.Sign(digest, masterKey)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("error signing approveAgent: %w", err)
+		}
+		request.Signature = &HyperliquidSignature{
+			R: hexutil.Encode(sig[:32]),
+			S: hexutil.Encode(sig[32:64]),
+			V: sig[64] + 27,
+		}
+		extra["masterAddress"] = publicExtra(crypto.PubkeyToAddress(masterKey.PublicKey).Hex())
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", "", nil, err
+	}
+	extra["approveAgentRequest"] = secretExtra(string(payload))
+
+	return privateKey, address, extra, nil
+}
+
+// hyperliquidAgentName names the index-th agent of a batch; Hyperliquid
+// requires distinct names, so batches number them from 1
+func hyperliquidAgentName(name string, index, count int) string {
+	if name == "" || count == 1 {
+		return name
+	}
+	return fmt.Sprintf("%s %d", name, index+1)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+)
+
+const icpSelfAuthenticatingSuffix = 0x02
+
+// DER prefixes of the SubjectPublicKeyInfo encodings hashed into a principal,
+// and of the PKCS#8 v2 and SEC1 private keys dfx writes to identity.pem
+var (
+	icpEd25519PublicDERPrefix   = []byte{0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00}
+	icpSecp256k1PublicDERPrefix = []byte{0x30, 0x56, 0x30, 0x10, 0x06, 0x07, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x02, 0x01, 0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a, 0x03, 0x42, 0x00}
+
+	icpEd25519PKCS8Prefix    = []byte{0x30, 0x53, 0x02, 0x01, 0x01, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x04, 0x22, 0x04, 0x20}
+	icpEd25519PKCS8PubPrefix = []byte{0xa1, 0x23, 0x03, 0x21, 0x00}
+	icpSecp256k1SEC1Prefix   = []byte{0x30, 0x74, 0x02, 0x01, 0x01, 0x04, 0x20}
+	icpSecp256k1SEC1Params   = []byte{0xa0, 0x07, 0x06, 0x05, 0x2b, 0x81, 0x04, 0x00, 0x0a, 0xa1, 0x44, 0x03, 0x42, 0x00}
+)
+
+// icpSchemes lists the -scheme values accepted for the icp type; secp256k1
+// matches what dfx identity new creates by default
+var icpSchemes = []string{"secp256k1", "ed25519"}
+
+// icpPrincipalText renders a principal as crc32||bytes in lowercase base32,
+// grouped by five characters
+func icpPrincipalText(principal []byte) string {
+	data := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(principal))
+	data = append(data, principal...)
+	encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(data))
+
+	groups := make([]string, 0, len(encoded)/5+1)
+	for len(encoded) > 5 {
+		groups = append(groups, encoded[:5])
+		encoded = encoded[5:]
+	}
+	return strings.Join(append(groups, encoded), "-")
+}
+
+// icpAccountIdentifier returns the ledger account identifier of the default
+// (all-zero) subaccount of a principal
+func icpAccountIdentifier(principal []byte) string {
+	h := sha256.New224()
+	h.Write([]byte("\x0aaccount-id"))
+	h.Write(principal)
+	h.Write(make([]byte, 32))
+	hash := h.Sum(nil)
+	return hex.EncodeToString(append(binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(hash)), hash...))
+}
+
+// generateICPKeyPair returns a hex private key and the self-authenticating
+// principal, with the ledger account ID, DER public key and dfx PEM as extras
+func generateICPKeyPair(random io.Reader, scheme string) (string, string, keyExtras, error) {
+	var secret, publicDER []byte
+	var block *pem.Block
+
+	switch scheme {
+	case "ed25519":
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, seed); err != nil {
+			return "", "", nil, err
+		}
+		pubKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		secret = seed
+		publicDER = append(append([]byte{}, icpEd25519PublicDERPrefix...), pubKey...)
+
+		der := append(append([]byte{}, icpEd25519PKCS8Prefix...), seed...)
+		der = append(append(der, icpEd25519PKCS8PubPrefix...), pubKey...)
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	case "secp256k1":
+		privateKey, err := newSecp256k1Key(random)
+		if err != nil {
+			return "", "", nil, err
+		}
+		pubKey := privateKey.PubKey().SerializeUncompressed()
+		secret = privateKey.Serialize()
+		publicDER = append(append([]byte{}, icpSecp256k1PublicDERPrefix...), pubKey...)
+
+		der := append(append([]byte{}, icpSecp256k1SEC1Prefix...), secret...)
+		der = append(append(der, icpSecp256k1SEC1Params...), pubKey...)
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		return "", "", nil, fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	hash := sha256.Sum224(publicDER)
+	principal := append(hash[:], icpSelfAuthenticatingSuffix)
+
+	extra := keyExtras{
+		"accountId": publicExtra(icpAccountIdentifier(principal)),
+		"publicKey": publicExtra(hex.EncodeToString(publicDER)),
+		"pem":       secretExtra(string(pem.EncodeToMemory(block))),
+	}
+
+	return hex.EncodeToString(secret), icpPrincipalText(principal), extra, nil
+}
+
+// icpKeyFiles writes each identity as <index>/identity.pem, the layout of
+// ~/.config/dfx/identity/<name>
+func icpKeyFiles(index int, _, _ string, extra map[string]string, _ string) (map[string][]byte, error) {
+	return map[string][]byte{fmt.Sprintf("%d/identity.pem", index): []byte(extra["pem"])}, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/sha256"
+	"io"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// insecureSeedDomain separates the keystream of -insecure-seed from any other
+// use of the same string
+const insecureSeedDomain = "account-generator insecure seed\x00"
+
+// insecureSeedWarning is printed before and after generating with
+// -insecure-seed
+const insecureSeedWarning = "WARNING: -insecure-seed makes every key reproducible by anyone who knows the seed. Use these keys for tests and fixtures only, never for real funds."
+
+// insecureReader is a ChaCha20 keystream the generators draw from in place
+// of crypto/rand with -insecure-seed, so that the same seed generates the
+// same keys
+type insecureReader struct {
+	stream *chacha20.Cipher
+}
+
+func (r *insecureReader) Read(p []byte) (int, error) {
+	clear(p)
+	r.stream.XORKeyStream(p, p)
+	return len(p), nil
+}
+
+// newInsecureReader returns the deterministic keystream of seed
+func newInsecureReader(seed string) (io.Reader, error) {
+	key := sha256.Sum256([]byte(insecureSeedDomain + seed))
+	stream, err := chacha20.NewUnauthenticatedCipher(key[:], make([]byte, chacha20.NonceSize))
+	if err != nil {
+		return nil, err
+	}
+	return &insecureReader{stream: stream}, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// insecureStream returns the keystream of seed
+func insecureStream(t *testing.T, seed string) io.Reader {
+	t.Helper()
+	random, err := newInsecureReader(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return random
+}
+
+func TestInsecureReaderAdvancesOnSingleByteReads(t *testing.T) {
+	random := insecureStream(t, "single bytes")
+	var single [4]byte
+	for i := range single {
+		if _, err := random.Read(single[i : i+1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var whole [4]byte
+	if _, err := insecureStream(t, "single bytes").Read(whole[:]); err != nil {
+		t.Fatal(err)
+	}
+	if single != whole {
+		t.Fatalf("single byte reads gave %x, want the stream %x", single, whole)
+	}
+}
+
+// The private keys are the first 32 bytes of the ChaCha20 keystream under
+// sha256(insecureSeedDomain + "pinned"), so a change of the standard library
+// or of a generator that makes -insecure-seed fixtures drift fails here
+func TestInsecureSeedPinsKeys(t *testing.T) {
+	for _, test := range []struct {
+		keyType  string
+		generate func(io.Reader) (string, string, error)
+		private  string
+		public   string
+	}{
+		{"evm", generateEVMKeyPair, "b57c2301147ce8f229a30fbf109545b704a99c2566cc42cba58e8c2f515c6989", "0x9E5fB5d3C4F2DA5d8154AD815e3633f8752d1D12"},
+		{"solana", generateSolanaKeyPair, "4dT9zNwfwtk8UEnFTuXxEDBDc1QeLerdMBsryqwqH33E8PSe3UK2hT4tJsKsPwSDBdcQQLBbHMbmVZ4Vk8eUxoWW", "BZ1UQXugcBK1cjHi6PMzVrNQNCZKgPVPgn27zWSp7B66"},
+		{"nostr", func(random io.Reader) (string, string, error) {
+			privateKey, publicKey, _, err := generateNostrKeyPair(random)
+			return privateKey, publicKey, err
+		}, "nsec1k47zxqg50n50y2drp7l3p929kuz2n8p9vmxy9ja936xz752udxysw4r8p8", "npub1sunl0armrt9wes62qq2pl9ntw05fzptpc6s5vxg8566qeret8rgsc88vjn"},
+	} {
+		t.Run(test.keyType, func(t *testing.T) {
+			privateKey, publicKey, err := test.generate(insecureStream(t, "pinned"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if privateKey != test.private || publicKey != test.public {
+				t.Fatalf("got %s, %s, want %s, %s", privateKey, publicKey, test.private, test.public)
+			}
+		})
+	}
+}
+
+func TestInsecureSeedStreamsDiffer(t *testing.T) {
+	var a, b [32]byte
+	insecureStream(t, "a").Read(a[:])
+	insecureStream(t, "b").Read(b[:])
+	if bytes.Equal(a[:], b[:]) {
+		t.Fatal("different seeds gave the same stream")
+	}
+}
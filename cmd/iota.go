@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/minio/blake2b-simd"
+)
+
+const iotaEd25519AddressType = 0x00
+
+// iotaCoinTypes are the SLIP-44 coin types Firefly derives Stardust accounts with
+var iotaCoinTypes = map[string]uint32{
+	"iota":    4218,
+	"shimmer": 4219,
+}
+
+// iotaPrefixes maps each key type and the -network flag to the address prefix
+var iotaPrefixes = map[string]map[string]string{
+	"iota":    {"mainnet": "iota", "testnet": "atoi"},
+	"shimmer": {"mainnet": "smr", "testnet": "rms"},
+}
+
+// deriveIOTAKeyPair derives the first address of the index-th Firefly account
+// at m/44'/coin'/index'/0'/0', returning the hex ed25519 seed and the bech32
+// Ed25519 address
+func deriveIOTAKeyPair(seed []byte, index int, keyType, network string) (string, string, keyExtras, error) {
+	path := []uint32{44 + hardenedOffset, iotaCoinTypes[keyType] + hardenedOffset, uint32(index) + hardenedOffset, 0 + hardenedOffset, 0 + hardenedOffset}
+	key, err := slip10Ed25519(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKey := ed25519.NewKeyFromSeed(key).Public().(ed25519.PublicKey)
+	hash := blake2b.Sum256(pubKey)
+	address, err := encodeBech32(iotaPrefixes[keyType][network], append([]byte{iotaEd25519AddressType}, hash[:]...))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding address: %w", err)
+	}
+
+	extra := keyExtras{
+		"derivationPath": publicExtra(formatDerivationPath(path)),
+		"publicKey":      publicExtra(hex.EncodeToString(pubKey)),
+	}
+
+	return hex.EncodeToString(key), address, extra, nil
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings of the k8s-secret format
+var (
+	// k8sNamespace is the namespace of the manifests, if any
+	k8sNamespace string
+	// k8sName names the Secrets: executed with a keyRecord with
+	// k8sPerKey, and with the templateData of the batch otherwise
+	k8sName *template.Template
+	// k8sPerKey writes a Secret per keypair rather than one for the batch
+	k8sPerKey bool
+	// k8sSealKey is the public key of the sealed-secrets controller, to
+	// write SealedSecrets instead of Secrets
+	k8sSealKey *rsa.PublicKey
+)
+
+// Default -k8s-name templates, for a batch and per key. Secret names must be
+// lowercase, so addresses are left out
+const (
+	k8sBatchNameTemplate = "{{.KeyType}}-keys"
+	k8sKeyNameTemplate   = "{{.Type}}-key-{{.Index}}"
+)
+
+// k8sMetadata is the metadata of a Kubernetes object
+type k8sMetadata struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace,omitempty"`
+	Labels    map[string]string `yaml:"labels,omitempty"`
+}
+
+// k8sManifest is a Secret, or a SealedSecret with Spec
+type k8sManifest struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	StringData *yaml.Node        `yaml:"stringData,omitempty"`
+	Spec       *sealedSecretSpec `yaml:"spec,omitempty"`
+}
+
+type sealedSecretSpec struct {
+	EncryptedData *yaml.Node `yaml:"encryptedData"`
+	Template      struct {
+		Metadata k8sMetadata `yaml:"metadata"`
+		Type     string      `yaml:"type"`
+	} `yaml:"template"`
+}
+
+// readSealCert reads the PEM certificate of a sealed-secrets controller, as
+// kubeseal --fetch-cert prints it
+func readSealCert(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s is not a PEM certificate", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s does not hold an RSA key", path)
+	}
+	return key, nil
+}
+
+// sealValue encrypts value as kubeseal does for the strict scope: a one-time
+// AES-256-GCM key, encrypted with RSA-OAEP labelled with the namespace and
+// name of the Secret, so the sealed value cannot be moved to another one
+func sealValue(key *rsa.PublicKey, namespace, name, value string) (string, error) {
+	sessionKey := make([]byte, 32)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return "", err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, key, sessionKey, []byte(namespace+"/"+name))
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	// The key is used once, so the nonce may be zero
+	sealed := binary.BigEndian.AppendUint16(nil, uint16(len(encryptedKey)))
+	sealed = append(sealed, encryptedKey...)
+	sealed = gcm.Seal(sealed, make([]byte, gcm.NonceSize()), []byte(value), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// validK8sName reports whether name is a DNS subdomain, as Secret names must be
+func validK8sName(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for i, r := range name {
+		alnum := r >= 'a' && r <= 'z' || r >= '0' && r <= '9'
+		if !alnum && (r != '-' && r != '.' || i == 0 || i == len(name)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+// k8sSecret renders the manifest of a Secret named name holding the ordered
+// keys and values of data, labeled insecure for keys of -insecure-seed
+func k8sSecret(name, keyType string, insecure bool, data []string) (k8sManifest, error) {
+	if !validK8sName(name) {
+		return k8sManifest{}, fmt.Errorf("secret name %q is not lowercase letters, digits, '-' and '.'", name)
+	}
+	metadata := k8sMetadata{
+		Name:      name,
+		Namespace: k8sNamespace,
+		Labels:    map[string]string{"app.kubernetes.io/managed-by": "account-generator", "account-generator/key-type": keyType},
+	}
+	if insecure {
+		metadata.Labels["account-generator/insecure"] = "true"
+	}
+	values := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < len(data); i += 2 {
+		value := data[i+1]
+		if k8sSealKey != nil {
+			var err error
+			if value, err = sealValue(k8sSealKey, k8sNamespace, name, value); err != nil {
+				return k8sManifest{}, err
+			}
+		}
+		// kubectl reads YAML 1.1, where 0x addresses are numbers
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+		if looksNumeric(value) {
+			node.Style = yaml.DoubleQuotedStyle
+		}
+		values.Content = append(values.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: data[i]}, node)
+	}
+
+	if k8sSealKey == nil {
+		return k8sManifest{APIVersion: "v1", Kind: "Secret", Metadata: metadata, Type: "Opaque", StringData: values}, nil
+	}
+	spec := &sealedSecretSpec{EncryptedData: values}
+	spec.Template.Metadata = metadata
+	spec.Template.Type = "Opaque"
+	return k8sManifest{APIVersion: "bitnami.com/v1alpha1", Kind: "SealedSecret", Metadata: metadata, Spec: spec}, nil
+}
+
+// encodeK8sSecret renders Kubernetes Secret manifests of result, numbered
+// from startIndex: one for the batch with the variables of the env format,
+// or with k8sPerKey one per keypair with PRIVATE_KEY, ADDRESS and, with a
+// mnemonic of its own, MNEMONIC, so containers can load them with envFrom
+func encodeK8sSecret(result KeyGenResult, startIndex int) ([]byte, error) {
+	var manifests []k8sManifest
+	if k8sPerKey {
+		for _, record := range keyRecords(result, startIndex) {
+			var name strings.Builder
+			if err := k8sName.Execute(&name, record); err != nil {
+				return nil, err
+			}
+			data := []string{envPrefix + "PRIVATE_KEY", record.PrivateKey, envPrefix + "ADDRESS", record.Address}
+			if mnemonic, ok := record.Extra["mnemonic"]; ok {
+				data = append(data, envPrefix+"MNEMONIC", mnemonic)
+			}
+			if result.Insecure {
+				data = append(data, envPrefix+"INSECURE", "true")
+			}
+			manifest, err := k8sSecret(name.String(), result.KeyType, result.Insecure, data)
+			if err != nil {
+				return nil, err
+			}
+			manifests = append(manifests, manifest)
+		}
+	} else {
+		var name strings.Builder
+		if err := k8sName.Execute(&name, templateData{KeyGenResult: result, Keys: keyRecords(result, startIndex)}); err != nil {
+			return nil, err
+		}
+		var data []string
+		if result.Insecure {
+			data = append(data, envPrefix+"INSECURE", "true")
+		}
+		mnemonics := result.Extra["mnemonic"]
+		for i, publicKey := range result.PublicKeys {
+			index := strconv.Itoa(startIndex + i)
+			if i < len(result.PrivateKeys) {
+				data = append(data, envPrefix+"PRIVATE_KEY_"+index, result.PrivateKeys[i])
+			}
+			data = append(data, envPrefix+"ADDRESS_"+index, publicKey)
+			if i < len(mnemonics) {
+				data = append(data, envPrefix+"MNEMONIC_"+index, mnemonics[i])
+			}
+		}
+		if result.Mnemonic != "" {
+			data = append(data, envPrefix+"MNEMONIC", result.Mnemonic)
+		}
+		manifest, err := k8sSecret(name.String(), result.KeyType, result.Insecure, data)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, manifest := range manifests {
+		if err := enc.Encode(manifest); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+const (
+	cashAddrCharset    = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	kaspaPubKeyVersion = 0x00
+)
+
+// kaspaPrefixes maps the -network flag to the address prefix
+var kaspaPrefixes = map[string]string{
+	"mainnet": "kaspa",
+	"testnet": "kaspatest",
+}
+
+// cashAddrPolymod is the 40-bit BCH checksum shared by CashAddr and Kaspa addresses
+func cashAddrPolymod(values []byte) uint64 {
+	generators := []uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+	chk := uint64(1)
+	for _, v := range values {
+		top := chk >> 35
+		chk = (chk&0x07ffffffff)<<5 ^ uint64(v)
+		for i, g := range generators {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	return chk ^ 1
+}
+
+// encodeCashAddr encodes a version byte and payload as prefix:payload+checksum
+func encodeCashAddr(prefix string, version byte, payload []byte) (string, error) {
+	data, err := bech32.ConvertBits(append([]byte{version}, payload...), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	values := make([]byte, 0, len(prefix)+1+len(data)+8)
+	for i := 0; i < len(prefix); i++ {
+		values = append(values, prefix[i]&0x1f)
+	}
+	values = append(values, 0)
+	values = append(values, data...)
+	checksum := cashAddrPolymod(append(values, make([]byte, 8)...))
+
+	for i := 0; i < 8; i++ {
+		data = append(data, byte(checksum>>(5*(7-i)))&0x1f)
+	}
+
+	encoded := make([]byte, len(data))
+	for i, d := range data {
+		encoded[i] = cashAddrCharset[d]
+	}
+	return prefix + ":" + string(encoded), nil
+}
+
+// generateKaspaKeyPair returns a hex private key and its schnorr pay-to-pubkey
+// address, with the x-only public key as an extra
+func generateKaspaKeyPair(random io.Reader, network string) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKeyBytes := schnorr.SerializePubKey(privateKey.PubKey())
+	address, err := encodeCashAddr(kaspaPrefixes[network], kaspaPubKeyVersion, pubKeyBytes)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating kaspa address: %w", err)
+	}
+
+	extra := keyExtras{
+		"publicKey": publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	return hex.EncodeToString(privateKey.Serialize()), address, extra, nil
+}
@@ -1,18 +1,25 @@
 package main
 
 import (
+	"crypto/ecdh"
 	"crypto/ecdsa"
 	"crypto/ed25519"
-	"crypto/rand"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/blocto/solana-go-sdk/types"
-	"github.com/btcsuite/btcutil/bech32"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/bech32"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/mr-tron/base58"
 	"golang.org/x/crypto/blake2b"
@@ -21,43 +28,189 @@ import (
 const (
 	suiPrivateKeyPrefix = "suiprivkey"
 	ed25519Flag         = 0x00
+	secp256k1Flag       = 0x01
+	secp256r1Flag       = 0x02
+	zkLoginFlag         = 0x05
 	addressLength       = 64
 )
 
+// keyTypes lists the values accepted by the -type flag
+var keyTypes = []string{"evm", "solana", "sui", "bitcoin", "cosmos", "aptos", "ton", "tron", "substrate", "cardano", "near", "starknet", "algorand", "tezos", "filecoin", "litecoin", "dogecoin", "monero", "zcash", "kaspa", "hedera", "icp", "sei", "injective", "eth-cosmos", "bch", "nostr", "eth-validator", "multiversx", "chia", "ckb", "mina", "aleo", "iota", "shimmer", "casper", "eos", "lightning", "cometbft", "geth-nodekey", "hyperliquid", "passkey"}
+
+// suiSchemes lists the -scheme values accepted for the sui type
+var suiSchemes = []string{"ed25519", "secp256k1", "secp256r1"}
+
+// keySchemes lists the -scheme values each key type accepts; the first entry
+// is the default
+var keySchemes = map[string][]string{
+	"sui":       suiSchemes,
+	"substrate": substrateSchemes,
+	"tezos":     tezosSchemes,
+	"hedera":    hederaSchemes,
+	"icp":       icpSchemes,
+	"casper":    casperSchemes,
+}
+
+// keyFileWriter renders one keypair's files in a chain's native format, keyed
+// by file path relative to the -key-dir directory
+type keyFileWriter func(index int, privateKey, publicKey string, extra map[string]string, password string) (map[string][]byte, error)
+
+// keyFileWriters maps key types to their -key-dir writer
+var keyFileWriters = map[string]keyFileWriter{
+	"evm":           evmKeystoreFiles,
+	"cardano":       cardanoKeyFiles,
+	"near":          nearKeyFiles,
+	"icp":           icpKeyFiles,
+	"multiversx":    multiversxKeyFiles,
+	"mina":          minaKeyFiles,
+	"casper":        casperKeyFiles,
+	"lightning":     lightningKeyFiles,
+	"cometbft":      cometBFTKeyFiles,
+	"geth-nodekey":  gethNodeKeyFiles,
+	"eth-validator": ethValidatorKeyFiles,
+	"solana":        solanaKeyFiles,
+	"cosmos":        cosmosArmorKeyFiles("secp256k1", "tendermint/PrivKeySecp256k1"),
+	"sei":           cosmosArmorKeyFiles("secp256k1", "tendermint/PrivKeySecp256k1"),
+	"injective":     cosmosArmorKeyFiles("eth_secp256k1", "injective/PrivKeyEthSecp256k1"),
+	"eth-cosmos":    cosmosArmorKeyFiles("eth_secp256k1", "ethermint/PrivKeyEthSecp256k1"),
+}
+
+// encryptedKeyFiles lists the key types whose key files are encrypted with a
+// password from -password-file or the terminal
+var encryptedKeyFiles = []string{"evm", "multiversx", "mina", "eth-validator", "cosmos", "sei", "injective", "eth-cosmos"}
+
 // KeyGenResult represents the generated keys result
 type KeyGenResult struct {
 	KeyType     string   `json:"keyType"`
 	Count       int      `json:"count"`
 	Timestamp   string   `json:"timestamp"`
-	PrivateKeys []string `json:"privateKeys"`
+	PrivateKeys []string `json:"privateKeys,omitempty"`
 	PublicKeys  []string `json:"publicKeys"`
+	Network     string   `json:"network,omitempty"`
+	HRP         string   `json:"hrp,omitempty"`
+	Scheme      string   `json:"scheme,omitempty"`
+	SS58Prefix  *int     `json:"ss58Prefix,omitempty"`
+	// WalletVersion and Workchain describe the TON wallet contract
+	WalletVersion string `json:"walletVersion,omitempty"`
+	Workchain     *int   `json:"workchain,omitempty"`
+	// Descriptors can be passed as-is to Bitcoin Core's importdescriptors
+	Descriptors []DescriptorImport `json:"descriptors,omitempty"`
+	Multisig    *BitcoinMultisig   `json:"multisig,omitempty"`
+	// DistributedValidator is the operator split of eth-validator keys
+	DistributedValidator *DistributedValidator `json:"distributedValidator,omitempty"`
+	// ChiaPlotKeys are the farmer and pool keys of the batch's Chia master key
+	ChiaPlotKeys *ChiaPlotKeys `json:"chiaPlotKeys,omitempty"`
+	// ZkLogin is the Sui zkLogin account the ephemeral keys of the zklogin
+	// command sign for
+	ZkLogin *SuiZkLogin `json:"zkLogin,omitempty"`
+	// Mnemonic is the BIP39 phrase every keypair was derived from with -mnemonic
+	Mnemonic string `json:"mnemonic,omitempty"`
+	// WatchOnly is the extended public key the addresses of the watch
+	// command are derived from
+	WatchOnly *WatchOnly `json:"watchOnly,omitempty"`
+	// ExtendedKeys are the account-level extended keys of the keypairs
+	// with -xpub
+	ExtendedKeys []ExtendedKey `json:"extendedKeys,omitempty"`
+	// Slip39Shares are the SLIP-39 share mnemonics of the master secret every
+	// keypair was derived from with -slip39
+	Slip39Shares []string `json:"slip39Shares,omitempty"`
+	// MnemonicFormat is "electrum-segwit" when Mnemonic is an Electrum seed
+	// rather than a BIP39 mnemonic
+	MnemonicFormat string `json:"mnemonicFormat,omitempty"`
+	// Passphrase reports that the seeds were derived under a BIP39
+	// passphrase, which is not stored
+	Passphrase bool `json:"passphrase,omitempty"`
+	// Insecure marks keys generated deterministically with -insecure-seed
+	Insecure bool `json:"insecure,omitempty"`
+	// Extra holds chain-specific values, each list parallel to PublicKeys
+	Extra map[string][]string `json:"extra,omitempty"`
+	// publicExtras names the extras their generator marked as public
+	publicExtras []string
 }
 
-func generateEVMKeyPair() (string, string, error) {
-	privateKey, err := crypto.GenerateKey()
+func generateEVMKeyPair(random io.Reader) (string, string, error) {
+	privateKey, err := newEthereumKey(random)
 	if err != nil {
 		return "", "", err
 	}
 
-	privateKeyBytes := crypto.FromECDSA(privateKey)
-	privateKeyHex := hex.EncodeToString(privateKeyBytes)
+	privateKeyHex, address := evmKeyPair(privateKey)
+	return privateKeyHex, address, nil
+}
+
+// evmKeyPair returns the hex private key and checksummed address of a key
+func evmKeyPair(privateKey *ecdsa.PrivateKey) (string, string) {
+	privateKeyHex := hex.EncodeToString(crypto.FromECDSA(privateKey))
+	address := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	return privateKeyHex, address
+}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return "", "", fmt.Errorf("error casting public key to ECDSA")
+// newSecp256k1Key returns a secp256k1 key of 32 bytes read from random,
+// reading again in the rare case they are not a valid scalar
+func newSecp256k1Key(random io.Reader) (*btcec.PrivateKey, error) {
+	b := make([]byte, 32)
+	defer clear(b)
+	for {
+		if _, err := io.ReadFull(random, b); err != nil {
+			return nil, err
+		}
+		var k btcec.ModNScalar
+		if overflow := k.SetByteSlice(b); !overflow && !k.IsZero() {
+			return btcec.PrivKeyFromScalar(&k), nil
+		}
 	}
+}
 
-	address := crypto.PubkeyToAddress(*publicKeyECDSA).Hex()
+// newEthereumKey returns a secp256k1 key read from random in the form
+// go-ethereum's crypto package takes
+func newEthereumKey(random io.Reader) (*ecdsa.PrivateKey, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.ToECDSA(privateKey.Serialize())
+}
 
-	return privateKeyHex, address, nil
+// newEd25519Key returns the ed25519 key of a 32-byte seed read from random
+func newEd25519Key(random io.Reader) (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	defer clear(seed)
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return nil, nil, err
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	return privateKey.Public().(ed25519.PublicKey), privateKey, nil
 }
 
-func generateSolanaKeyPair() (string, string, error) {
-	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
-	if err != nil {
+// newP256Key returns a P-256 key of 32 bytes read from random, reading
+// again in the rare case they are not a valid scalar. It reads the scalar
+// itself because crypto/ecdh and crypto/ecdsa do not reliably draw their
+// keys from the reader they are given
+func newP256Key(random io.Reader) (*ecdh.PrivateKey, error) {
+	b := make([]byte, 32)
+	defer clear(b)
+	for {
+		if _, err := io.ReadFull(random, b); err != nil {
+			return nil, err
+		}
+		if privateKey, err := ecdh.P256().NewPrivateKey(b); err == nil {
+			return privateKey, nil
+		}
+	}
+}
+
+func generateSolanaKeyPair(random io.Reader) (string, string, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(random, seed); err != nil {
 		return "", "", err
 	}
+	return solanaKeyPair(seed)
+}
+
+// solanaKeyPair returns the base58 64-byte secret key and base58 public key
+// of an ed25519 seed
+func solanaKeyPair(seed []byte) (string, string, error) {
+	privateKey := ed25519.NewKeyFromSeed(seed)
 
 	account, err := types.AccountFromBytes(privateKey)
 	if err != nil {
@@ -70,32 +223,94 @@ func generateSolanaKeyPair() (string, string, error) {
 	return privateKeyBase58, publicKeyBase58, nil
 }
 
-func generateSuiKeyPair() (string, string, error) {
-	seed := make([]byte, 32)
-	if _, err := rand.Read(seed); err != nil {
-		return "", "", err
+func generateSuiKeyPair(random io.Reader, scheme string) (string, string, error) {
+	var secret []byte
+	switch scheme {
+	case "ed25519":
+		secret = make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, secret); err != nil {
+			return "", "", err
+		}
+	case "secp256k1":
+		privateKey, err := newSecp256k1Key(random)
+		if err != nil {
+			return "", "", err
+		}
+		secret = privateKey.Serialize()
+	case "secp256r1":
+		privateKey, err := newP256Key(random)
+		if err != nil {
+			return "", "", err
+		}
+		secret = privateKey.Bytes()
+	default:
+		return "", "", fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	return suiKeyPair(scheme, secret)
+}
+
+// suiKeyPair returns the suiprivkey private key and address of a 32-byte
+// ed25519 seed or secp256k1/secp256r1 scalar
+func suiKeyPair(scheme string, secret []byte) (string, string, error) {
+	var schemeFlag byte
+	switch scheme {
+	case "ed25519":
+		schemeFlag = ed25519Flag
+	case "secp256k1":
+		schemeFlag = secp256k1Flag
+	case "secp256r1":
+		schemeFlag = secp256r1Flag
+	default:
+		return "", "", fmt.Errorf("unsupported scheme: %s", scheme)
 	}
 
-	keyData := append([]byte{ed25519Flag}, seed...)
-	converted, err := bech32.ConvertBits(keyData, 8, 5, true)
+	pubKey, err := suiPublicKey(schemeFlag, secret)
 	if err != nil {
 		return "", "", err
 	}
 
-	privateKeyStr, err := bech32.Encode(suiPrivateKeyPrefix, converted)
+	privateKeyStr, err := encodeSuiPrivateKey(schemeFlag, secret)
 	if err != nil {
 		return "", "", err
 	}
 
-	priKey := ed25519.NewKeyFromSeed(seed)
-	pubKey := priKey.Public().(ed25519.PublicKey)
+	return privateKeyStr, suiAddress(schemeFlag, pubKey), nil
+}
 
-	tmp := []byte{byte(ed25519Flag)}
-	tmp = append(tmp, pubKey...)
-	addrBytes := blake2b.Sum256(tmp)
-	addr := "0x" + hex.EncodeToString(addrBytes[:])[:addressLength]
+// suiPublicKey returns the public key of a secret of the scheme of
+// schemeFlag, compressed for secp256k1 and secp256r1
+func suiPublicKey(schemeFlag byte, secret []byte) ([]byte, error) {
+	switch schemeFlag {
+	case ed25519Flag:
+		return ed25519.NewKeyFromSeed(secret).Public().(ed25519.PublicKey), nil
+	case secp256k1Flag:
+		privateKey, _ := btcec.PrivKeyFromBytes(secret)
+		return privateKey.PubKey().SerializeCompressed(), nil
+	case secp256r1Flag:
+		privateKey, err := ecdh.P256().NewPrivateKey(secret)
+		if err != nil {
+			return nil, err
+		}
+		// Compress the 0x04||X||Y encoding to 0x02/0x03||X by the parity of Y
+		uncompressed := privateKey.PublicKey().Bytes()
+		return append([]byte{0x02 | uncompressed[64]&1}, uncompressed[1:33]...), nil
+	}
+	return nil, fmt.Errorf("unsupported scheme flag: %d", schemeFlag)
+}
+
+// encodeSuiPrivateKey returns the suiprivkey bech32 form of flag || secret
+func encodeSuiPrivateKey(schemeFlag byte, secret []byte) (string, error) {
+	return encodeBech32(suiPrivateKeyPrefix, append([]byte{schemeFlag}, secret...))
+}
 
-	return privateKeyStr, addr, nil
+// suiAddress returns the 0x address blake2b-256(flag || data), where data is
+// the public key, or the issuer and address seed for zkLogin
+func suiAddress(schemeFlag byte, data []byte) string {
+	tmp := []byte{schemeFlag}
+	tmp = append(tmp, data...)
+	addrBytes := blake2b.Sum256(tmp)
+	return "0x" + hex.EncodeToString(addrBytes[:])[:addressLength]
 }
 
 // validateSuiPrivateKey validates that a private key can be decoded correctly
@@ -126,82 +341,818 @@ func validateSuiPrivateKey(privStr string) error {
 	return nil
 }
 
+// joinInts renders values as a comma-separated list
+func joinInts(values []int) string {
+	rendered := make([]string, len(values))
+	for i, value := range values {
+		rendered[i] = strconv.Itoa(value)
+	}
+	return strings.Join(rendered, ", ")
+}
+
+// quoteList renders values as 'a', 'b', or 'c' for help and error messages
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + v + "'"
+	}
+	if len(quoted) < 2 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
+}
+
 func main() {
-	keyType := flag.String("type", "", "Key type: 'evm', 'solana', or 'sui'")
-	count := flag.Int("count", 1, "Number of keypairs to generate")
+	disableCoreDumps()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert":
+			runConvert(os.Args[2:])
+			return
+		case "zklogin":
+			runZkLogin(os.Args[2:])
+			return
+		case "watch":
+			runWatch(os.Args[2:])
+			return
+		case "wallet":
+			runWallet(os.Args[2:])
+			return
+		case "mnemonic":
+			runMnemonic(os.Args[2:])
+			return
+		case "discover":
+			runDiscover(os.Args[2:])
+			return
+		case "bip85":
+			runBip85(os.Args[2:])
+			return
+		case "kms-decrypt":
+			runKMSDecrypt(os.Args[2:])
+			return
+		case "decrypt":
+			runDecrypt(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		}
+	}
+	if err := run(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if errors.As(err, new(usageError)) {
+			flag.Usage()
+		}
+		os.Exit(1)
+	}
+}
+
+// usageError is a mistake on the command line, reported with the usage
+type usageError struct{ error }
 
+// run generates a batch and writes it as the flags ask. The keys are wiped
+// from memory once it returns, whether it succeeded or not
+func run() error {
+	o := addFlags(flag.CommandLine)
 	flag.Parse()
 
-	if *keyType != "evm" && *keyType != "solana" && *keyType != "sui" {
-		fmt.Println("Error: Key type must be 'evm', 'solana', or 'sui'")
-		flag.Usage()
-		os.Exit(1)
+	// With -output -, stdout only carries the results, and every message
+	// goes to stderr instead
+	resultOut := os.Stdout
+	if o.output == "-" || o.privateOut == "-" {
+		os.Stdout = os.Stderr
 	}
 
-	if *count <= 0 {
-		fmt.Println("Error: Count must be greater than 0")
-		flag.Usage()
-		os.Exit(1)
+	if err := o.validate(); err != nil {
+		return err
+	}
+	c, err := o.prepare()
+	if err != nil {
+		return err
 	}
+	c.resultOut = resultOut
 
-	privateKeys := make([]string, 0, *count)
-	publicKeys := make([]string, 0, *count)
+	mnemonic, seed, slip39Shares, err := c.masterSecret()
+	if err != nil {
+		return err
+	}
+
+	// The private keys and secret extras of the batch are kept in locked
+	// memory while it is generated, and wiped once everything is written
+	var secrets secretArena
+	defer secrets.wipe()
+	defer clear(seed)
+
+	var stream *ndjsonOutput
+	if c.format == "ndjson" {
+		if stream, err = c.openNDJSON(mnemonic); err != nil {
+			return err
+		}
+	}
+	result, err := c.generate(&secrets, stream, mnemonic, seed, slip39Shares)
+	if err != nil {
+		return err
+	}
+	filename, err := c.writeResults(result, stream)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Successfully generated %d %s keypairs and saved to %s\n", c.count, c.keyType, filename)
+	if c.publicOnly {
+		switch c.privateOut {
+		case "":
+			fmt.Println("Private keys were discarded")
+		case "-":
+			fmt.Println("Private keys written to stdout")
+		default:
+			fmt.Printf("Private keys saved to %s\n", c.privateOut)
+		}
+	}
+	if c.insecureSeed != "" {
+		fmt.Fprintln(os.Stderr, insecureSeedWarning)
+	}
+
+	return c.writeCompanions(result)
+}
 
-	for i := 0; i < *count; i++ {
+// masterSecret returns the mnemonic, seed and new SLIP-39 shares every
+// keypair of the run is derived from, read or generated as the flags ask.
+// All are empty when the keys are generated independently
+func (c *runConfig) masterSecret() (mnemonic string, seed []byte, slip39Shares []string, err error) {
+	// eth-validator, chia, iota and shimmer keys are always derived, like their
+	// own wallets do
+	switch {
+	case c.fromSeed != "":
+		if seed, err = readSeed(c.fromSeed); err != nil {
+			return "", nil, nil, fmt.Errorf("reading seed: %v", err)
+		}
+	case c.slip39File != "":
+		// SLIP-39 master secrets are used as the BIP32 seed directly
+		mnemonics, err := readSlip39Shares(c.slip39File)
+		if err == nil {
+			seed, err = slip39CombineMnemonics(mnemonics, c.passphrase)
+		}
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("recovering SLIP-39 master secret: %v", err)
+		}
+	case c.slip39 != "":
+		if seed, slip39Shares, err = newSlip39MasterSecret(c.random, c.words, c.passphrase, c.slip39Threshold, c.slip39Count); err != nil {
+			return "", nil, nil, fmt.Errorf("generating SLIP-39 shares: %v", err)
+		}
+	case c.importsMnemonic() && c.electrum:
+		if mnemonic, seed, err = readElectrumSeed(c.fromMnemonic, c.mnemonicFile, c.passphrase); err != nil {
+			return "", nil, nil, fmt.Errorf("reading Electrum seed: %v", err)
+		}
+	case c.electrum:
+		if mnemonic, err = newElectrumSeed(c.random); err != nil {
+			return "", nil, nil, fmt.Errorf("generating Electrum seed: %v", err)
+		}
+		seed = electrumSeed(mnemonic, c.passphrase)
+	case c.importsMnemonic():
+		if mnemonic, seed, err = readMnemonic(c.fromMnemonic, c.mnemonicFile, c.passphrase); err != nil {
+			return "", nil, nil, fmt.Errorf("reading mnemonic: %v", err)
+		}
+	case c.useMnemonic || c.alwaysDerived():
+		if mnemonic, seed, err = newMnemonic(c.random, c.words, c.passphrase); err != nil {
+			return "", nil, nil, fmt.Errorf("generating mnemonic: %v", err)
+		}
+	}
+	return mnemonic, seed, slip39Shares, nil
+}
+
+// openNDJSON opens the streams of the ndjson format, which writes each
+// keypair as it is generated, so large runs can be piped into loaders
+// without waiting for the whole batch
+func (c *runConfig) openNDJSON(mnemonic string) (*ndjsonOutput, error) {
+	s := &ndjsonOutput{publicOnly: c.publicOnly}
+	s.filename = resultsFilename(c.keyType, formatExtension(c.format), c.insecureSeed != "")
+	if c.compression != "" {
+		s.filename += "." + compressionExtension(c.compression)
+	}
+	if c.output != "" {
+		s.filename = c.output
+	}
+	out := c.resultOut
+	if s.filename == "-" {
+		s.filename = "stdout"
+	} else {
+		var err error
+		if s.file, err = createOutputFile(s.filename); err != nil {
+			return nil, fmt.Errorf("writing results: %v", err)
+		}
+		out = s.file
+		writtenFiles = append(writtenFiles, s.filename)
+	}
+	if c.compression != "" {
+		var err error
+		if s.compressor, err = newCompressor(out, c.compression); err != nil {
+			return nil, fmt.Errorf("writing results: %v", err)
+		}
+		out = s.compressor
+	}
+	if !c.publicOnly {
+		s.stream = newNDJSONStream(out, c.keyType, mnemonic, c.startIndex, c.insecureSeed != "")
+		return s, nil
+	}
+	s.stream = newNDJSONStream(out, c.keyType, "", c.startIndex, c.insecureSeed != "")
+	if c.privateOut == "-" {
+		s.privateStream = newNDJSONStream(c.resultOut, c.keyType, mnemonic, c.startIndex, c.insecureSeed != "")
+	} else if c.privateOut != "" {
+		var err error
+		if s.privateFile, err = createOutputFile(c.privateOut); err != nil {
+			return nil, fmt.Errorf("writing private keys: %v", err)
+		}
+		writtenFiles = append(writtenFiles, c.privateOut)
+		s.privateStream = newNDJSONStream(s.privateFile, c.keyType, mnemonic, c.startIndex, c.insecureSeed != "")
+	}
+	return s, nil
+}
+
+// generate generates the keypairs of the batch, derived from seed if the run
+// derives them, and assembles them into the results. Their secrets are kept
+// in secrets as they are made, and every keypair is written to stream too
+// if it is set
+func (c *runConfig) generate(secrets *secretArena, stream *ndjsonOutput, mnemonic string, seed []byte, slip39Shares []string) (KeyGenResult, error) {
+	privateKeys := make([][]byte, 0, c.count)
+	publicKeys := make([]string, 0, c.count)
+	extras := make(map[string][]string)
+	secretExtras := make(map[string][][]byte)
+	var publicExtras []string
+
+	for i := 0; i < c.count; i++ {
+		// index is the account the keypair is derived at with a mnemonic
+		index := c.startIndex + i
 		var privateKey, publicKey string
+		var extra keyExtras
 		var err error
 
-		switch *keyType {
-		case "evm":
-			privateKey, publicKey, err = generateEVMKeyPair()
-		case "solana":
-			privateKey, publicKey, err = generateSolanaKeyPair()
-		case "sui":
-			privateKey, publicKey, err = generateSuiKeyPair()
-		default:
-			fmt.Printf("Error: Invalid key type: %s\n", *keyType)
-			flag.Usage()
-			os.Exit(1)
+		if c.derived && (c.useMnemonic || c.mnemonicPerKey) {
+			privateKey, publicKey, extra, err = deriveMnemonicKeyPair(c.random, c.deriver, seed, index, c.mnemonicPerKey, c.derivation)
+			if err == nil && c.altEncoding != "" {
+				var alt keyExtras
+				alt, err = evmAltEncodingExtra(c.altEncoding, publicKey)
+				maps.Copy(extra, alt)
+			}
+		} else {
+			privateKey, publicKey, extra, err = c.generateKeyPair(i, seed)
 		}
 
 		if err != nil {
-			fmt.Printf("Error generating keypair %d: %v\n", i+1, err)
-			os.Exit(1)
+			return KeyGenResult{}, fmt.Errorf("generating keypair %d: %v", i+1, err)
 		}
 
 		// Validate Sui private key format
-		if *keyType == "sui" {
+		if c.keyType == "sui" {
 			if err := validateSuiPrivateKey(privateKey); err != nil {
-				fmt.Printf("Error validating sui keypair %d: %v\n", i+1, err)
-				os.Exit(1)
+				return KeyGenResult{}, fmt.Errorf("validating sui keypair %d: %v", i+1, err)
+			}
+		}
+
+		if stream != nil {
+			if err := stream.write(i, privateKey, publicKey, extra); err != nil {
+				return KeyGenResult{}, err
 			}
 		}
 
-		privateKeys = append(privateKeys, privateKey)
+		privateKeys = append(privateKeys, secrets.add(privateKey))
 		publicKeys = append(publicKeys, publicKey)
+		for k, v := range extra {
+			if v.public {
+				extras[k] = append(extras[k], v.value)
+				if !slices.Contains(publicExtras, k) {
+					publicExtras = append(publicExtras, k)
+				}
+			} else {
+				secretExtras[k] = append(secretExtras[k], secrets.add(v.value))
+			}
+		}
+	}
+	// The encoders take strings, so the secrets only leave the arena once
+	// the batch is complete
+	for k, values := range secretExtras {
+		extras[k] = secretStrings(values)
+	}
+
+	var extendedKeys []ExtendedKey
+	if c.exportXpub {
+		var err error
+		extendedKeys, err = accountExtendedKeys(c.deriver, seed, c.startIndex, c.count, c.derivation, c.keyType == "bitcoin")
+		if err != nil {
+			return KeyGenResult{}, fmt.Errorf("deriving extended keys: %v", err)
+		}
 	}
 
 	result := KeyGenResult{
-		KeyType:     *keyType,
-		Count:       *count,
-		Timestamp:   time.Now().Format(time.RFC3339),
-		PrivateKeys: privateKeys,
-		PublicKeys:  publicKeys,
+		KeyType:      c.keyType,
+		Count:        c.count,
+		Timestamp:    time.Now().Format(time.RFC3339),
+		PrivateKeys:  secretStrings(privateKeys),
+		PublicKeys:   publicKeys,
+		Mnemonic:     mnemonic,
+		Passphrase:   c.passphrase != "",
+		Slip39Shares: slip39Shares,
+		ExtendedKeys: extendedKeys,
+		Insecure:     c.insecureSeed != "",
+		Extra:        extras,
+		publicExtras: publicExtras,
+	}
+	if c.electrum {
+		result.MnemonicFormat = "electrum-segwit"
+	}
+	if c.keyType == "cosmos" || c.keyType == "eth-cosmos" || c.keyType == "cometbft" {
+		result.HRP = c.hrp
+	}
+	result.Scheme = c.scheme
+	if c.keyType == "substrate" {
+		result.SS58Prefix = &c.ss58Prefix
 	}
+	if c.keyType == "cardano" || c.keyType == "filecoin" || c.keyType == "monero" || c.keyType == "zcash" || c.keyType == "kaspa" || c.keyType == "bch" || c.keyType == "eth-validator" || c.keyType == "ckb" || c.keyType == "iota" || c.keyType == "shimmer" || c.keyType == "hyperliquid" || c.isUTXO {
+		result.Network = c.network
+	}
+	if c.dvOperators > 0 {
+		result.DistributedValidator = &DistributedValidator{Operators: c.dvOperators, Threshold: c.dvThreshold}
+	}
+	if c.keyType == "chia" {
+		result.Network = c.network
+		plotKeys, err := chiaMasterKeys(seed)
+		if err != nil {
+			return KeyGenResult{}, fmt.Errorf("deriving chia plot keys: %v", err)
+		}
+		result.ChiaPlotKeys = plotKeys
+	}
+	if c.keyType == "ton" {
+		result.Network = c.network
+		result.WalletVersion = c.walletVersion
+		result.Workchain = &c.workchain
+	}
+	if c.keyType == "bitcoin" {
+		result.Network = c.network
+		for i := range privateKeys {
+			result.Descriptors = append(result.Descriptors,
+				DescriptorImport{Desc: extras["wpkhDescriptor"][i], Timestamp: "now"},
+				DescriptorImport{Desc: extras["trDescriptor"][i], Timestamp: "now"},
+			)
+		}
+		if c.multisig > 0 {
+			ms, imports, err := buildBitcoinMultisig(c.multisig, result.PrivateKeys, c.btcParams)
+			if err != nil {
+				return KeyGenResult{}, fmt.Errorf("building multisig: %v", err)
+			}
+			result.Multisig = ms
+			result.Descriptors = append(result.Descriptors, imports...)
+		}
+	}
+	return result, nil
+}
 
-	jsonData, err := json.MarshalIndent(result, "", "  ")
-	if err != nil {
-		fmt.Printf("Error creating JSON: %v\n", err)
-		os.Exit(1)
+// generateKeyPair generates the i-th keypair of a batch that is not derived
+// from a mnemonic. seed is the seed of the key types that are always derived
+func (c *runConfig) generateKeyPair(i int, seed []byte) (privateKey, publicKey string, extra keyExtras, err error) {
+	index := c.startIndex + i
+	switch c.keyType {
+	case "evm":
+		privateKey, publicKey, err = generateEVMKeyPair(c.random)
+		if err == nil && c.altEncoding != "" {
+			extra, err = evmAltEncodingExtra(c.altEncoding, publicKey)
+		}
+	case "solana":
+		privateKey, publicKey, err = generateSolanaKeyPair(c.random)
+	case "sui":
+		privateKey, publicKey, err = generateSuiKeyPair(c.random, c.scheme)
+	case "bitcoin":
+		privateKey, publicKey, extra, err = generateBitcoinKeyPair(c.random, c.btcParams)
+	case "cosmos":
+		privateKey, publicKey, extra, err = generateCosmosKeyPair(c.random, c.hrp)
+	case "aptos":
+		privateKey, publicKey, extra, err = generateAptosKeyPair(c.random)
+	case "ton":
+		privateKey, publicKey, extra, err = generateTONKeyPair(c.random, c.walletVersion, int8(c.workchain), c.network == "testnet")
+	case "tron":
+		privateKey, publicKey, extra, err = generateTronKeyPair(c.random)
+	case "substrate":
+		privateKey, publicKey, extra, err = generateSubstrateKeyPair(c.random, c.scheme, uint16(c.ss58Prefix))
+	case "cardano":
+		privateKey, publicKey, extra, err = generateCardanoKeyPair(c.random, c.network == "testnet")
+	case "near":
+		privateKey, publicKey, extra, err = generateNEARKeyPair(c.random)
+	case "starknet":
+		privateKey, publicKey, extra, err = generateStarknetKeyPair(c.random)
+	case "algorand":
+		privateKey, publicKey, extra, err = generateAlgorandKeyPair(c.random)
+	case "tezos":
+		privateKey, publicKey, extra, err = generateTezosKeyPair(c.random, c.scheme)
+	case "filecoin":
+		privateKey, publicKey, extra, err = generateFilecoinKeyPair(c.random, c.network == "testnet")
+	case "litecoin", "dogecoin":
+		privateKey, publicKey, extra, err = generateUTXOKeyPair(c.random, c.utxoParams[c.network])
+	case "monero":
+		privateKey, publicKey, extra, err = generateMoneroKeyPair(c.random, c.network, moneroSubaddressRange{account: uint32(c.subaddressAccount), count: uint32(c.subaddressCount)})
+	case "zcash":
+		privateKey, publicKey, extra, err = generateZcashKeyPair(c.random, c.network)
+	case "kaspa":
+		privateKey, publicKey, extra, err = generateKaspaKeyPair(c.random, c.network)
+	case "hedera":
+		privateKey, publicKey, extra, err = generateHederaKeyPair(c.random, c.scheme)
+	case "icp":
+		privateKey, publicKey, extra, err = generateICPKeyPair(c.random, c.scheme)
+	case "sei":
+		privateKey, publicKey, extra, err = generateSeiKeyPair(c.random)
+	case "injective":
+		privateKey, publicKey, extra, err = generateEthCosmosKeyPair(c.random, injectiveHRP)
+	case "eth-cosmos":
+		privateKey, publicKey, extra, err = generateEthCosmosKeyPair(c.random, c.hrp)
+	case "bch":
+		privateKey, publicKey, extra, err = generateBCHKeyPair(c.random, c.network)
+	case "nostr":
+		privateKey, publicKey, extra, err = generateNostrKeyPair(c.random)
+	case "eth-validator":
+		privateKey, publicKey, extra, err = deriveEthValidatorKeyPair(seed, index, c.network, c.withdrawalAddress)
+		if err == nil && c.dvOperators > 0 {
+			var shares keyExtras
+			shares, err = dvShareExtras(c.random, privateKey, c.dvOperators, c.dvThreshold)
+			maps.Copy(extra, shares)
+		}
+	case "multiversx":
+		privateKey, publicKey, extra, err = generateMultiversXKeyPair(c.random)
+	case "chia":
+		privateKey, publicKey, extra, err = deriveChiaKeyPair(seed, index, c.network)
+	case "ckb":
+		privateKey, publicKey, extra, err = generateCKBKeyPair(c.random, c.network)
+	case "mina":
+		privateKey, publicKey, extra, err = generateMinaKeyPair(c.random)
+	case "aleo":
+		privateKey, publicKey, extra, err = generateAleoKeyPair()
+	case "iota", "shimmer":
+		privateKey, publicKey, extra, err = deriveIOTAKeyPair(seed, index, c.keyType, c.network)
+	case "casper":
+		privateKey, publicKey, extra, err = generateCasperKeyPair(c.random, c.scheme)
+	case "eos":
+		privateKey, publicKey, extra, err = generateEOSKeyPair(c.random)
+	case "lightning":
+		privateKey, publicKey, extra, err = generateLightningKeyPair(c.random)
+	case "cometbft":
+		privateKey, publicKey, extra, err = generateCometBFTKeyPair(c.random, c.hrp, c.nodeKey)
+	case "hyperliquid":
+		privateKey, publicKey, extra, err = generateHyperliquidAgentKeyPair(c.random, c.network, hyperliquidAgentName(c.agentName, i, c.count), i, c.masterKey)
+	case "passkey":
+		privateKey, publicKey, extra, err = generatePasskeyKeyPair(c.random)
+	case "geth-nodekey":
+		privateKey, publicKey, extra, err = generateGethNodeKeyPair(c.random, c.enodeHost, c.enodePort)
+	default:
+		err = fmt.Errorf("unsupported key type %s", c.keyType)
 	}
+	return privateKey, publicKey, extra, err
+}
 
-	filename := fmt.Sprintf("%s_keys_%s.json", *keyType, time.Now().Format("20060102_150405"))
+// writeResults writes the results where the flags ask, and the private keys
+// of -private-out, and returns the name of where the results went
+func (c *runConfig) writeResults(result KeyGenResult, stream *ndjsonOutput) (string, error) {
+	var filename string
+	// Key file formats are written in place of the results file
+	switch {
+	case c.format == "ndjson":
+		if err := stream.close(); err != nil {
+			return "", err
+		}
+		// It streamed the private keys already
+		return stream.filename, nil
+	case slices.Contains(keyFileFormats, c.format):
+		filename = c.keyDir
+		writer := keyFileWriter(evmKeystoreFiles)
+		if c.format == "paper" {
+			filename = fmt.Sprintf("%s_paper_%s", c.keyType, time.Now().Format("20060102_150405"))
+			writer = paperWalletFiles(c.keyType)
+		}
+		if err := writeKeyFiles(filename, writer, c.password, result.PrivateKeys, result.PublicKeys, result.Extra); err != nil {
+			return "", fmt.Errorf("writing %s files: %v", c.format, err)
+		}
+	case c.vaultPath != "":
+		written, err := writeVault(c.vaultAddr, c.vaultPath, result, c.startIndex)
+		if err != nil {
+			return "", fmt.Errorf("writing results after %d secrets: %v", written, err)
+		}
+		filename = fmt.Sprintf("Vault (%s)", strings.Trim(c.vaultPath, "/"))
+	case c.awsSecrets:
+		written, err := writeAWSSecrets(result, c.startIndex, c.awsSecretNames, c.awsTags, c.encryptKMS)
+		if err != nil {
+			return "", fmt.Errorf("writing results after %d secrets: %v", written, err)
+		}
+		filename = "AWS Secrets Manager"
+	case c.gcpProject != "":
+		batchID, err := newGCPBatchID()
+		if err != nil {
+			return "", err
+		}
+		written, err := writeGCPSecrets(c.gcpProject, result, c.startIndex, c.gcpSecretIDs, c.gcpLabels, batchID)
+		if err != nil {
+			return "", fmt.Errorf("writing results after %d secrets: %v", written, err)
+		}
+		filename = fmt.Sprintf("GCP Secret Manager (project %s, batch %s)", c.gcpProject, batchID)
+	case c.azureVault != "":
+		client, err := newAzureSecretsClient(c.azureVault)
+		if err != nil {
+			return "", err
+		}
+		written, err := writeAzureSecrets(client, result, c.startIndex, c.azureSecretNames, c.azureTags)
+		if err != nil {
+			return "", fmt.Errorf("writing results after %d secrets: %v", written, err)
+		}
+		filename = fmt.Sprintf("Azure Key Vault (%s)", c.azureVault)
+	case c.opVaultFlag != "":
+		var tags []string
+		if c.opTags != "" {
+			tags = strings.Split(c.opTags, ",")
+		}
+		written, err := writeOnePassword(c.opVaultFlag, result, c.startIndex, c.opTitles, tags)
+		if err != nil {
+			return "", fmt.Errorf("writing results after %d items: %v", written, err)
+		}
+		filename = fmt.Sprintf("1Password (%s)", c.opVaultFlag)
+	case c.store != "":
+		written, err := writeKeyring(result)
+		if err != nil {
+			return "", fmt.Errorf("writing results after %d keys: %v", written, err)
+		}
+		filename = "the keychain"
+	case c.postgresDSN != "":
+		runID, err := writePostgres(c.postgresDSN, result, c.startIndex)
+		if err != nil {
+			return "", fmt.Errorf("writing results: %v", err)
+		}
+		filename = fmt.Sprintf("Postgres (run %s)", runID)
+	case c.sqliteOutput:
+		runID, err := writeSQLite(c.sqlitePath, result, c.startIndex)
+		if err != nil {
+			return "", fmt.Errorf("writing results: %v", err)
+		}
+		filename = fmt.Sprintf("%s (run %d)", c.sqlitePath, runID)
+	default:
+		var err error
+		if filename, err = c.writeResultsFile(result); err != nil {
+			return "", err
+		}
+	}
+
+	if c.privateOut != "" {
+		data, err := encodeResult(result, c.format, c.startIndex)
+		if err == nil && c.privateOut == "-" {
+			_, err = c.resultOut.Write(data)
+		} else if err == nil {
+			if err = writeOutputFile(c.privateOut, data); err == nil {
+				writtenFiles = append(writtenFiles, c.privateOut)
+			}
+		}
+		clear(data)
+		if err != nil {
+			return "", fmt.Errorf("writing private keys: %v", err)
+		}
+	}
+	return filename, nil
+}
+
+// writeResultsFile writes the results as a file, or a file per keypair with
+// -split-files, to -output or under their default names
+func (c *runConfig) writeResultsFile(result KeyGenResult) (string, error) {
+	extension := formatExtension(c.format)
+	if c.tmpl != nil {
+		extension = templateExtension(c.templateFile)
+	}
+
+	if c.splitFiles {
+		filename := c.outputDir
+		if filename == "" {
+			filename = strings.TrimSuffix(resultsFilename(c.keyType, extension, c.insecureSeed != ""), "."+extension)
+		}
+		writer := func(i int, privateKey, publicKey string, extra map[string]string, _ string) (map[string][]byte, error) {
+			name := strconv.Itoa(c.startIndex + i)
+			if c.splitName == "address" {
+				name = publicKey
+			}
+			data, name, err := c.encode(splitResult(result, privateKey, publicKey, extra), c.startIndex+i, name+"."+extension)
+			if err != nil {
+				return nil, err
+			}
+			return map[string][]byte{name: data}, nil
+		}
+		if err := writeKeyFiles(filename, writer, "", result.PrivateKeys, result.PublicKeys, result.Extra); err != nil {
+			return "", fmt.Errorf("writing results: %v", err)
+		}
+		return filename, nil
+	}
 
-	err = os.WriteFile(filename, jsonData, 0o644)
+	written := result
+	if c.publicOnly {
+		written = publicResult(result)
+	}
+	data, name, err := c.encode(written, c.startIndex, resultsFilename(c.keyType, extension, c.insecureSeed != ""))
 	if err != nil {
-		fmt.Printf("Error writing to file: %v\n", err)
-		os.Exit(1)
+		return "", err
+	}
+	filename := name
+	if c.output != "" {
+		filename = c.output
+	}
+
+	if filename == "-" {
+		_, err = c.resultOut.Write(data)
+		filename = "stdout"
+	} else if isBucketOutput(filename) {
+		filename = bucketOutputName(filename, filepath.Base(name))
+		err = uploadResults(filename, data)
+	} else {
+		if err = writeOutputFile(filename, data); err == nil {
+			writtenFiles = append(writtenFiles, filename)
+		}
+	}
+	clear(data)
+	if err != nil {
+		return "", fmt.Errorf("writing results: %v", err)
+	}
+	return filename, nil
+}
+
+// encode renders result as the results file name, compressed and encrypted
+// if asked, and returns the name they give it. Every buffer but the last is
+// zeroed once the next stage replaced it
+func (c *runConfig) encode(result KeyGenResult, startIndex int, name string) ([]byte, string, error) {
+	var data []byte
+	var err error
+	next := func(out []byte, stageErr error) error {
+		if stageErr == nil {
+			clear(data)
+			data = out
+		}
+		return stageErr
+	}
+	if c.tmpl != nil {
+		if data, err = renderTemplate(c.tmpl, result, startIndex); err != nil {
+			return nil, "", fmt.Errorf("rendering template: %w", err)
+		}
+	} else if data, err = encodeResult(result, c.format, startIndex); err != nil {
+		return nil, "", fmt.Errorf("creating %s: %w", strings.ToUpper(c.format), err)
+	}
+	if c.sops {
+		if err := next(sopsEncrypt(data, c.format, c.sopsKeys)); err != nil {
+			return nil, "", fmt.Errorf("encrypting results with SOPS: %w", err)
+		}
+		return data, name, nil
+	}
+	if c.compression != "" {
+		if err := next(compress(data, c.compression)); err != nil {
+			return nil, "", fmt.Errorf("compressing results: %w", err)
+		}
+		name += "." + compressionExtension(c.compression)
+	}
+	if c.encryptPassword {
+		if err := next(passwordEncrypt(data, c.encryptionPassword)); err != nil {
+			return nil, "", fmt.Errorf("encrypting results: %w", err)
+		}
+		name += ".enc"
+	}
+	if c.encryptAge != "" || c.encryptAgePassphrase {
+		if err := next(ageEncrypt(data, c.ageRecipients, c.agePassphrase)); err != nil {
+			return nil, "", fmt.Errorf("encrypting results: %w", err)
+		}
+		name += ".age"
+	}
+	if c.encryptPGP != "" {
+		if err := next(pgpEncrypt(data, c.pgpRecipients, filepath.Base(name))); err != nil {
+			return nil, "", fmt.Errorf("encrypting results: %w", err)
+		}
+		name += ".asc"
+	}
+	if c.encryptKMS != "" {
+		// One data key seals every file of the run
+		if c.kmsKey.Plaintext == nil {
+			if c.kmsKey, err = generateKMSDataKey(c.encryptKMS); err != nil {
+				return nil, "", fmt.Errorf("generating KMS data key: %w", err)
+			}
+		}
+		if err := next(kmsEncrypt(data, c.kmsKey)); err != nil {
+			return nil, "", fmt.Errorf("encrypting results: %w", err)
+		}
+		name += ".kms"
+	}
+	return data, name, nil
+}
+
+// writeCompanions writes what the flags ask for besides the results: the
+// public file, QR codes, deposit data, key files and the Sui keystore, and
+// bundles the files of the run into -archive
+func (c *runConfig) writeCompanions(result KeyGenResult) error {
+	privateKeys, publicKeys, extras := result.PrivateKeys, result.PublicKeys, result.Extra
+
+	// The companion file is meant to be shared, so it is left out of
+	// -archive
+	if c.publicFile {
+		format := publicFormat(c.format)
+		name := publicFilename(c.output, c.keyType, formatExtension(format), c.insecureSeed != "")
+		data, err := encodeResult(publicResult(result), format, c.startIndex)
+		if err == nil && isBucketOutput(name) {
+			err = uploadResults(name, data)
+		} else if err == nil {
+			err = writeOutputFile(name, data)
+		}
+		if err != nil {
+			return fmt.Errorf("writing public file: %v", err)
+		}
+		fmt.Printf("Addresses saved to %s\n", name)
 	}
 
-	fmt.Printf("Successfully generated %d %s keypairs and saved to %s\n", *count, *keyType, filename)
+	if c.qr {
+		qrDir := fmt.Sprintf("%s_qr_%s", c.keyType, time.Now().Format("20060102_150405"))
+		if err := writeQRCodes(qrDir, privateKeys, publicKeys, c.startIndex, c.qrPrivate); err != nil {
+			return fmt.Errorf("writing QR codes: %v", err)
+		}
+		fmt.Printf("QR codes saved to %s\n", qrDir)
+	}
+
+	// The addresses are the keys of the entries to export
+	if c.store != "" {
+		fmt.Println("Addresses:")
+		for _, address := range publicKeys {
+			fmt.Println(address)
+		}
+	}
+
+	if c.nodeKey {
+		fmt.Println("Node IDs:")
+		for _, id := range extras["nodeId"] {
+			fmt.Println(id)
+		}
+	}
+
+	if c.keyType == "geth-nodekey" {
+		fmt.Println("Enode URLs:")
+		for _, enode := range extras["enode"] {
+			fmt.Println(enode)
+		}
+	}
+
+	if c.keyType == "eth-validator" {
+		depositData, err := ethDepositDataJSON(c.network, publicKeys, extras)
+		if err != nil {
+			return fmt.Errorf("creating deposit data: %v", err)
+		}
+		depositFile := fmt.Sprintf("deposit_data-%d.json", time.Now().Unix())
+		if err := writeOutputFile(depositFile, depositData); err != nil {
+			return fmt.Errorf("writing deposit data: %v", err)
+		}
+		writtenFiles = append(writtenFiles, depositFile)
+		fmt.Printf("Deposit data saved to %s\n", depositFile)
+	}
+
+	if c.keyDir != "" && c.format != "keystore" {
+		if err := writeKeyFiles(c.keyDir, keyFileWriters[c.keyType], c.password, privateKeys, publicKeys, extras); err != nil {
+			return fmt.Errorf("writing key files: %v", err)
+		}
+		fmt.Printf("Key files written to %s\n", c.keyDir)
+	}
+
+	if c.suiKeystore != "" {
+		added, err := appendSuiKeystore(c.suiKeystore, privateKeys, publicKeys)
+		if err != nil {
+			return fmt.Errorf("writing Sui keystore: %v", err)
+		}
+		fmt.Printf("Added %d keys to %s\n", added, c.suiKeystore)
+	}
+
+	if c.archive != "" {
+		if err := writeArchive(c.archive, c.archivePassword, writtenFiles); err != nil {
+			return fmt.Errorf("writing archive: %v", err)
+		}
+		fmt.Printf("Archived %d files to %s and removed them\n", len(writtenFiles), c.archive)
+	}
+
+	return nil
+}
+
+// writeKeyFiles renders every keypair with writer and stores the files under dir
+func writeKeyFiles(dir string, writer keyFileWriter, password string, privateKeys, publicKeys []string, extras map[string][]string) error {
+	for i := range privateKeys {
+		extra := make(map[string]string, len(extras))
+		for k, v := range extras {
+			extra[k] = v[i]
+		}
+
+		files, err := writer(i, privateKeys[i], publicKeys[i], extra, password)
+		if err != nil {
+			return fmt.Errorf("keypair %d: %w", i+1, err)
+		}
+
+		for name, data := range files {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+				return err
+			}
+			err := writeOutputFile(path, data)
+			clear(data)
+			if err != nil {
+				return err
+			}
+			writtenFiles = append(writtenFiles, path)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runOptions generates and writes the batch of args as run does, with the
+// results of -output - and -private-out - going to the returned buffer, and
+// returns where the results went
+func runOptions(t *testing.T, args ...string) (string, *bytes.Buffer) {
+	t.Helper()
+	withFileSettings(t, 0o600, false)
+	t.Cleanup(func() { writtenFiles = nil })
+	o := parseOptions(t, args...)
+	if err := o.validate(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := o.prepare()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	c.resultOut = &out
+
+	mnemonic, seed, shares, err := c.masterSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secrets secretArena
+	defer secrets.wipe()
+	var stream *ndjsonOutput
+	if c.format == "ndjson" {
+		if stream, err = c.openNDJSON(mnemonic); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := c.generate(&secrets, stream, mnemonic, seed, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename, err := c.writeResults(result, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename, &out
+}
+
+func TestRunDerivesFromMnemonic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.csv")
+	filename, _ := runOptions(t, "-type=evm", "-from-mnemonic="+abandonMnemonic, "-count=2", "-format=csv", "-output="+path)
+	if filename != path {
+		t.Errorf("results written to %s, want %s", filename, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `index,type,address,privateKey,derivationPath,mnemonic
+0,evm,0x9858EfFD232B4033E47d90003D41EC34EcaEda94,1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727,m/44'/60'/0'/0/0,` + abandonMnemonic + `
+1,evm,0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0,9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6,m/44'/60'/0'/0/1,` + abandonMnemonic + `
+`
+	if string(data) != want {
+		t.Errorf("results:\n%s\nwant:\n%s", data, want)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("results file mode %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+}
+
+func TestRunPublicOnlyStreamsPrivateKeysToStdout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.ndjson")
+	filename, out := runOptions(t, "-type=evm", "-from-mnemonic="+abandonMnemonic, "-start-index=1", "-format=ndjson", "-public-only", "-output="+path, "-private-out=-")
+	if filename != path {
+		t.Errorf("results written to %s, want %s", filename, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"index":1,"type":"evm","address":"0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0","extra":{"derivationPath":"m/44'/60'/0'/0/1"}}` + "\n"; string(data) != want {
+		t.Errorf("public results %s, want %s", data, want)
+	}
+	if !strings.Contains(out.String(), `"privateKey":"9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6"`) {
+		t.Errorf("private keys %s lack the key of index 1", out)
+	}
+}
+
+func TestRunWritesResultsToStdout(t *testing.T) {
+	filename, out := runOptions(t, "-type=bitcoin", "-from-mnemonic="+abandonMnemonic, "-format=env", "-output=-")
+	if filename != "stdout" {
+		t.Errorf("results written to %s, want stdout", filename)
+	}
+	if !strings.HasPrefix(out.String(), "PRIVATE_KEY_0=KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d\nADDRESS_0=bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu\n") {
+		t.Errorf("stdout:\n%s", out)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// keyringService is the service, or the prefix of the target name, of the
+// entries -store=keyring writes to the platform keychain, each keyed by its
+// address
+const keyringService = "account-generator"
+
+// storeBackends are the values -store accepts
+var storeBackends = []string{"keyring"}
+
+// writeKeyring stores the private key of each keypair of result in the
+// platform keychain under its address, replacing an entry of the same
+// address. The number of keys stored is returned
+func writeKeyring(result KeyGenResult) (int, error) {
+	for i, publicKey := range result.PublicKeys {
+		if err := keyringSet(publicKey, result.PrivateKeys[i]); err != nil {
+			return i, fmt.Errorf("storing %s: %w", publicKey, err)
+		}
+	}
+	return len(result.PublicKeys), nil
+}
+
+// runExport implements the export subcommand, which prints the private keys
+// -store=keyring stored in the platform keychain
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	addresses := fs.String("address", "", "Comma-separated addresses of the keys to print, one per line")
+	fs.Parse(args)
+
+	if *addresses == "" {
+		fmt.Println("Error: At least one address is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	for _, address := range strings.Split(*addresses, ",") {
+		privateKey, err := keyringGet(strings.TrimSpace(address))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s from the keychain: %v\n", address, err)
+			os.Exit(1)
+		}
+		fmt.Println(privateKey)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// keyringSet adds a generic password to the login keychain with the security
+// tool. The command is piped to its interactive mode, so the private key
+// never appears in a command line
+func keyringSet(address, privateKey string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %s -w %s\n",
+		strconv.Quote(keyringService), strconv.Quote(address), strconv.Quote(keyringService+" "+address), strconv.Quote(privateKey))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("security: %s", strings.TrimSpace(out.String()))
+	}
+	// Interactive mode exits 0 even when a command fails
+	if message := strings.TrimSpace(out.String()); message != "" {
+		return fmt.Errorf("security: %s", message)
+	}
+	return nil
+}
+
+// keyringGet reads the generic password of address from the keychain
+func keyringGet(address string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "find-generic-password", "-s", keyringService, "-a", address, "-w")
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("security: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,47 @@
+//go:build !darwin && !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keyringSet stores a secret with the Secret Service, e.g. GNOME Keyring or
+// KWallet, through secret-tool from libsecret. The secret is piped to it, so
+// the private key never appears in a command line
+func keyringSet(address, privateKey string) error {
+	cmd := exec.Command("secret-tool", "store", "--label="+keyringService+" "+address, "service", keyringService, "account", address)
+	cmd.Stdin = strings.NewReader(privateKey)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return errors.New("secret-tool not found, install libsecret-tools")
+		}
+		return fmt.Errorf("secret-tool: %s", strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// keyringGet looks up the secret of address in the Secret Service
+func keyringGet(address string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", keyringService, "account", address)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil && errors.Is(err, exec.ErrNotFound) {
+		return "", errors.New("secret-tool not found, install libsecret-tools")
+	}
+	// secret-tool exits 1 without a message when nothing matches
+	if err != nil || len(out) == 0 {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("secret-tool: %s", message)
+		}
+		return "", errors.New("no such key")
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	evmKeystoreVersion = 3
+	// evmKeystoreScryptN and evmKeystoreScryptP are geth's standard scrypt
+	// parameters
+	evmKeystoreScryptN         = 1 << 18
+	evmKeystoreScryptR         = 8
+	evmKeystoreScryptP         = 1
+	evmKeystoreScryptKeyLength = 32
+)
+
+// evmKeystore is a Web3 Secret Storage (version 3) key file, as written by
+// geth and imported by MetaMask and ethers.js
+type evmKeystore struct {
+	Address string `json:"address"`
+	Crypto  struct {
+		Cipher       string `json:"cipher"`
+		Ciphertext   string `json:"ciphertext"`
+		CipherParams struct {
+			IV string `json:"iv"`
+		} `json:"cipherparams"`
+		KDF       string `json:"kdf"`
+		KDFParams struct {
+			DKLen int    `json:"dklen"`
+			N     int    `json:"n"`
+			P     int    `json:"p"`
+			R     int    `json:"r"`
+			Salt  string `json:"salt"`
+		} `json:"kdfparams"`
+		MAC string `json:"mac"`
+	} `json:"crypto"`
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+}
+
+// evmKeystoreFiles encrypts each key as the UTC--<time>--<address> file geth
+// keeps in its keystore directory; the key is encrypted with AES-128-CTR
+// under an scrypt key and authenticated with Keccak-256
+func evmKeystoreFiles(_ int, privateKey, publicKey string, _ map[string]string, password string) (map[string][]byte, error) {
+	secret, err := hex.DecodeString(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	derivedKey, err := scrypt.Key([]byte(password), salt, evmKeystoreScryptN, evmKeystoreScryptR, evmKeystoreScryptP, evmKeystoreScryptKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(secret))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, secret)
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	address := strings.ToLower(strings.TrimPrefix(publicKey, "0x"))
+	keystore := evmKeystore{
+		Address: address,
+		ID:      id,
+		Version: evmKeystoreVersion,
+	}
+	keystore.Crypto.Cipher = "aes-128-ctr"
+	keystore.Crypto.Ciphertext = hex.EncodeToString(ciphertext)
+	keystore.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	keystore.Crypto.KDF = "scrypt"
+	keystore.Crypto.KDFParams.DKLen = evmKeystoreScryptKeyLength
+	keystore.Crypto.KDFParams.N = evmKeystoreScryptN
+	keystore.Crypto.KDFParams.P = evmKeystoreScryptP
+	keystore.Crypto.KDFParams.R = evmKeystoreScryptR
+	keystore.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	keystore.Crypto.MAC = hex.EncodeToString(crypto.Keccak256(derivedKey[16:32], ciphertext))
+
+	data, err := json.Marshal(keystore)
+	if err != nil {
+		return nil, err
+	}
+
+	// geth names key files after their creation time in UTC
+	name := fmt.Sprintf("UTC--%s--%s", time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z"), address)
+	return map[string][]byte{name: data}, nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	clnHSMSecretSize = 32
+	clnNodeIDInfo    = "nodeid"
+)
+
+// clnNodeKey derives the node private key from a Core Lightning hsm_secret as
+// hsmd does: HKDF-SHA256 with info "nodeid" and a little-endian u32 salt,
+// counting up from 0 until the output is a valid secp256k1 key
+func clnNodeKey(hsmSecret []byte) (*btcec.PrivateKey, error) {
+	for salt := uint32(0); ; salt++ {
+		key := make([]byte, 32)
+		r := hkdf.New(sha256.New, hsmSecret, binary.LittleEndian.AppendUint32(nil, salt), []byte(clnNodeIDInfo))
+		if _, err := io.ReadFull(r, key); err != nil {
+			return nil, err
+		}
+
+		var scalar btcec.ModNScalar
+		if overflow := scalar.SetByteSlice(key); !overflow && !scalar.IsZero() {
+			privateKey, _ := btcec.PrivKeyFromBytes(key)
+			return privateKey, nil
+		}
+	}
+}
+
+// generateLightningKeyPair returns a hex node private key and the node ID,
+// derived from a fresh Core Lightning hsm_secret listed as an extra
+func generateLightningKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	hsmSecret := make([]byte, clnHSMSecretSize)
+	if _, err := io.ReadFull(random, hsmSecret); err != nil {
+		return "", "", nil, err
+	}
+
+	nodeKey, err := clnNodeKey(hsmSecret)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error deriving node key: %w", err)
+	}
+
+	extra := keyExtras{
+		"hsmSecret": secretExtra(hex.EncodeToString(hsmSecret)),
+	}
+
+	return hex.EncodeToString(nodeKey.Serialize()), hex.EncodeToString(nodeKey.PubKey().SerializeCompressed()), extra, nil
+}
+
+// lightningKeyFiles writes each node's raw 32-byte hsm_secret as
+// <index>/hsm_secret, the file lightningd reads from its network directory
+func lightningKeyFiles(index int, _, _ string, extra map[string]string, _ string) (map[string][]byte, error) {
+	hsmSecret, err := hex.DecodeString(extra["hsmSecret"])
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		fmt.Sprintf("%d/hsm_secret", index): hsmSecret,
+	}, nil
+}
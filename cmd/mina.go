@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+
+	"filippo.io/bigmod"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Mina base58check version bytes
+const (
+	minaPublicKeyVersion  = 0xcb
+	minaPrivateKeyVersion = 0x5a
+	minaSecretBoxVersion  = 0x02
+
+	// minaBinProtVersion prefixes the bin_prot encodings of versioned types
+	minaBinProtVersion = 0x01
+
+	// libsodium's crypto_pwhash_argon2i MEMLIMIT_SENSITIVE and OPSLIMIT used by mina
+	minaArgon2Memory = 134217728
+	minaArgon2Ops    = 6
+)
+
+// pallasPoint is a Pallas point in homogeneous projective coordinates, with
+// the point at infinity as (0:1:0); the coordinates are constant-time field
+// elements of the size of pallasFp
+type pallasPoint struct {
+	x, y, z *bigmod.Nat
+}
+
+// Pallas curve y^2 = x^3 + 5 over F_p, whose scalars in F_q are Mina keys
+var (
+	pallasP  = mustHexInt("40000000000000000000000000000000224698fc094cf91b992d30ed00000001")
+	pallasQ  = mustHexInt("40000000000000000000000000000000224698fc0994a8dd8c46eb2100000001")
+	pallasFp = mustModulus(pallasP)
+	// pallasB3 is 3b, the constant of the complete addition formulas
+	pallasB3 = pallasElement(big.NewInt(15))
+	pallasG  = pallasPoint{
+		x: pallasElement(big.NewInt(1)),
+		y: pallasElement(mustHexInt("1b74b5a30a12937c53dfa9f06378ee548f655bd4333d477119cf7a23caed2abb")),
+		z: pallasElement(big.NewInt(1)),
+	}
+)
+
+// minaSecretBox is the JSON layout of a key file written by mina advanced
+// generate-keypair and read by mina accounts import
+type minaSecretBox struct {
+	BoxPrimitive string `json:"box_primitive"`
+	PwPrimitive  string `json:"pw_primitive"`
+	Nonce        string `json:"nonce"`
+	PwSalt       string `json:"pwsalt"`
+	PwDiff       [2]int `json:"pwdiff"`
+	Ciphertext   string `json:"ciphertext"`
+}
+
+func mustModulus(n *big.Int) *bigmod.Modulus {
+	m, err := bigmod.NewModulus(n.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// pallasElement returns n, which must be below pallasP, as a field element
+func pallasElement(n *big.Int) *bigmod.Nat {
+	x, err := bigmod.NewNat().SetBytes(n.FillBytes(make([]byte, 32)), pallasFp)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// pallasClone returns a copy of the field element x
+func pallasClone(x *bigmod.Nat) *bigmod.Nat {
+	return bigmod.NewNat().ExpandFor(pallasFp).Add(x, pallasFp)
+}
+
+// pallasAdd adds two points, or doubles a point, with the complete addition
+// formulas for a = 0 of Renes, Costello and Batina (Algorithm 7 of ePrint
+// 2015/1060), which take the same steps whatever the points
+func pallasAdd(p, q pallasPoint) pallasPoint {
+	m := pallasFp
+	t0 := pallasClone(p.x).Mul(q.x, m)
+	t1 := pallasClone(p.y).Mul(q.y, m)
+	t2 := pallasClone(p.z).Mul(q.z, m)
+	t3 := pallasClone(p.x).Add(p.y, m)
+	t4 := pallasClone(q.x).Add(q.y, m)
+	t3.Mul(t4, m)
+	t4 = pallasClone(t0).Add(t1, m)
+	t3.Sub(t4, m)
+	t4 = pallasClone(p.y).Add(p.z, m)
+	x3 := pallasClone(q.y).Add(q.z, m)
+	t4.Mul(x3, m)
+	x3 = pallasClone(t1).Add(t2, m)
+	t4.Sub(x3, m)
+	x3 = pallasClone(p.x).Add(p.z, m)
+	y3 := pallasClone(q.x).Add(q.z, m)
+	x3.Mul(y3, m)
+	y3 = pallasClone(t0).Add(t2, m)
+	y3 = pallasClone(x3).Sub(y3, m)
+	x3 = pallasClone(t0).Add(t0, m)
+	t0.Add(x3, m)
+	t2.Mul(pallasB3, m)
+	z3 := pallasClone(t1).Add(t2, m)
+	t1.Sub(t2, m)
+	y3.Mul(pallasB3, m)
+	x3 = pallasClone(t4).Mul(y3, m)
+	t2 = pallasClone(t3).Mul(t1, m)
+	x3 = t2.Sub(x3, m)
+	y3.Mul(t0, m)
+	t1.Mul(z3, m)
+	y3.Add(t1, m)
+	t0.Mul(t3, m)
+	z3.Mul(t4, m)
+	z3.Add(t0, m)
+	return pallasPoint{x: x3, y: y3, z: z3}
+}
+
+// pallasSwap swaps p and q when bit is 1, masking the limbs instead of
+// branching on the bit
+func pallasSwap(p, q *pallasPoint, bit uint) {
+	mask := -bit
+	for _, pair := range [][2]*bigmod.Nat{{p.x, q.x}, {p.y, q.y}, {p.z, q.z}} {
+		a, b := pair[0].Bits(), pair[1].Bits()
+		for i := range a {
+			t := (a[i] ^ b[i]) & mask
+			a[i] ^= t
+			b[i] ^= t
+		}
+	}
+}
+
+// pallasBaseMul computes the affine coordinates of k*G with a Montgomery
+// ladder over all 256 bits of k, so its timing does not depend on the key
+func pallasBaseMul(k *big.Int) (x, y *big.Int) {
+	m := pallasFp
+	scalar := k.FillBytes(make([]byte, 32))
+	r0 := pallasPoint{x: pallasElement(new(big.Int)), y: pallasElement(big.NewInt(1)), z: pallasElement(new(big.Int))}
+	r1 := pallasPoint{x: pallasClone(pallasG.x), y: pallasClone(pallasG.y), z: pallasClone(pallasG.z)}
+	for i := range 256 {
+		bit := uint(scalar[i/8]>>(7-i%8)) & 1
+		pallasSwap(&r0, &r1, bit)
+		r1 = pallasAdd(r0, r1)
+		r0 = pallasAdd(r0, r0)
+		pallasSwap(&r0, &r1, bit)
+	}
+	clear(scalar)
+
+	// 1/z = z^(p-2)
+	zInv := bigmod.NewNat().Exp(r0.z, new(big.Int).Sub(pallasP, big.NewInt(2)).Bytes(), m)
+	x = new(big.Int).SetBytes(pallasClone(r0.x).Mul(zInv, m).Bytes(m))
+	y = new(big.Int).SetBytes(pallasClone(r0.y).Mul(zInv, m).Bytes(m))
+	return x, y
+}
+
+// leBytes32 renders n as 32 little-endian bytes
+func leBytes32(n *big.Int) []byte {
+	b := n.FillBytes(make([]byte, 32))
+	slices.Reverse(b)
+	return b
+}
+
+// generateMinaKeyPair returns an EK... private key and its B62... public key
+func generateMinaKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	var secret *big.Int
+	for {
+		k, err := rand.Int(random, pallasQ)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if k.Sign() != 0 {
+			secret = k
+			break
+		}
+	}
+
+	privateKey, publicKey := minaKeyPair(secret)
+	return privateKey, publicKey, nil, nil
+}
+
+// minaKeyPair encodes the private key secret and its public key
+func minaKeyPair(secret *big.Int) (string, string) {
+	x, y := pallasBaseMul(secret)
+
+	// compressed point: bin_prot versions of the point and its compressed
+	// form, the x coordinate and the parity of y
+	pubKey := []byte{minaBinProtVersion, minaBinProtVersion}
+	pubKey = append(pubKey, leBytes32(x)...)
+	pubKey = append(pubKey, byte(y.Bit(0)))
+
+	privateKey := append([]byte{minaBinProtVersion}, leBytes32(secret)...)
+
+	return base58CheckEncode([]byte{minaPrivateKeyVersion}, privateKey), base58CheckEncode([]byte{minaPublicKeyVersion}, pubKey)
+}
+
+// minaKeyFiles writes each key as the password-protected <B62 key> secret box
+// and its <B62 key>.pub companion, the pair mina accounts import reads
+func minaKeyFiles(_ int, privateKey, publicKey string, _ map[string]string, password string) (map[string][]byte, error) {
+	decoded, err := base58.Decode(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	// strip the version byte and checksum, keeping the bin_prot private key
+	plaintext := decoded[1 : len(decoded)-4]
+
+	salt := make([]byte, 16)
+	var nonce [24]byte
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+
+	var key [32]byte
+	copy(key[:], argon2.Key([]byte(password), salt, minaArgon2Ops, minaArgon2Memory/1024, 1, 32))
+
+	box := minaSecretBox{
+		BoxPrimitive: "xsalsa20poly1305",
+		PwPrimitive:  "argon2i",
+		Nonce:        base58CheckEncode([]byte{minaSecretBoxVersion}, nonce[:]),
+		PwSalt:       base58CheckEncode([]byte{minaSecretBoxVersion}, salt),
+		PwDiff:       [2]int{minaArgon2Memory, minaArgon2Ops},
+		Ciphertext:   base58CheckEncode([]byte{minaSecretBoxVersion}, secretbox.Seal(nil, plaintext, &nonce, &key)),
+	}
+	data, err := json.Marshal(box)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding secret box: %w", err)
+	}
+
+	return map[string][]byte{
+		publicKey:          data,
+		publicKey + ".pub": []byte(publicKey + "\n"),
+	}, nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/mr-tron/base58"
+)
+
+// A keypair of the mina-signer test suite
+func TestMinaKeyPair(t *testing.T) {
+	decoded, err := base58.Decode("EKFKgDtU3rcuFTVSEpmpXSkukjmX4cKefYREi6Sdsk7E7wsT7KRw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the little-endian scalar between the version bytes and the checksum
+	scalar := slices.Clone(decoded[2:34])
+	slices.Reverse(scalar)
+
+	privateKey, publicKey := minaKeyPair(new(big.Int).SetBytes(scalar))
+	if privateKey != "EKFKgDtU3rcuFTVSEpmpXSkukjmX4cKefYREi6Sdsk7E7wsT7KRw" || publicKey != "B62qiy32p8kAKnny8ZFwoMhYpBppM1DWVCqAPBYNcXnsAHhnfAAuXgg" {
+		t.Errorf("minaKeyPair = %s, %s", privateKey, publicKey)
+	}
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	defaultMnemonicWords = 12
+	slip10Ed25519Key     = "ed25519 seed"
+)
+
+// derivationOptions carries the flags that change how a key type derives
+// and renders its keys
+type derivationOptions struct {
+	network    string
+	hrp        string
+	scheme     string
+	passphrase string
+	words      int
+}
+
+// mnemonicDeriver derives keypairs of one key type from a BIP39 seed
+type mnemonicDeriver struct {
+	// path returns the chain's standard wallet path of the index-th keypair
+	path func(index int, opts derivationOptions) ([]uint32, error)
+	// derive renders the keypair at path like the key type's generator does
+	derive func(seed []byte, path []uint32, opts derivationOptions) (string, string, keyExtras, error)
+	// curve returns the curve the keys are derived on: ed25519 keys derive
+	// with SLIP-0010, which only allows hardened path segments, and the
+	// others on the secp256k1 BIP32 tree
+	curve func(opts derivationOptions) string
+}
+
+// mnemonicDerivers lists the key types -mnemonic supports
+var mnemonicDerivers = map[string]mnemonicDeriver{
+	"evm":     {path: bip44Path(60, false), derive: deriveEVMKeyPair, curve: fixedCurve("secp256k1")},
+	"solana":  {path: solanaPath, derive: deriveSolanaKeyPair, curve: fixedCurve("ed25519")},
+	"sui":     {path: suiPath, derive: deriveSuiKeyPair, curve: func(opts derivationOptions) string { return opts.scheme }},
+	"bitcoin": {path: bitcoinPath, derive: deriveBitcoinKeyPair, curve: fixedCurve("secp256k1")},
+	"cosmos":  {path: bip44Path(118, false), derive: deriveCosmosKeyPair, curve: fixedCurve("secp256k1")},
+	"tron":    {path: bip44Path(195, false), derive: deriveTronKeyPair, curve: fixedCurve("secp256k1")},
+	"aptos":   {path: aptosPath, derive: deriveAptosKeyPair, curve: fixedCurve("ed25519")},
+}
+
+func fixedCurve(curve string) func(derivationOptions) string {
+	return func(derivationOptions) string { return curve }
+}
+
+// mnemonicWordCounts are the BIP39 mnemonic lengths, of 128 to 256 bits of
+// entropy
+var mnemonicWordCounts = []int{12, 15, 18, 21, 24}
+
+// mnemonicWordlists maps -wordlist values to the official BIP39 wordlists
+var mnemonicWordlists = map[string][]string{
+	"english":             wordlists.English,
+	"japanese":            wordlists.Japanese,
+	"korean":              wordlists.Korean,
+	"spanish":             wordlists.Spanish,
+	"chinese-simplified":  wordlists.ChineseSimplified,
+	"chinese-traditional": wordlists.ChineseTraditional,
+	"french":              wordlists.French,
+	"italian":             wordlists.Italian,
+	"czech":               wordlists.Czech,
+}
+
+// mnemonicSeparator joins the words of new mnemonics; Japanese phrases are
+// written with ideographic spaces
+var mnemonicSeparator = " "
+
+// setMnemonicWordlist selects the wordlist mnemonics are generated and
+// parsed with, NFKD normalized like the phrases the seed is derived from
+func setMnemonicWordlist(language string) error {
+	wordlist, ok := mnemonicWordlists[language]
+	if !ok {
+		return fmt.Errorf("unsupported wordlist: %s", language)
+	}
+	normalized := make([]string, len(wordlist))
+	for i, word := range wordlist {
+		normalized[i] = norm.NFKD.String(word)
+	}
+	bip39.SetWordList(normalized)
+	mnemonicSeparator = " "
+	if language == "japanese" {
+		mnemonicSeparator = "\u3000"
+	}
+	return nil
+}
+
+// mnemonicSeed derives the BIP39 seed of mnemonic under passphrase, both
+// NFKD normalized as BIP39 requires
+func mnemonicSeed(mnemonic, passphrase string) []byte {
+	return bip39.NewSeed(norm.NFKD.String(mnemonic), norm.NFKD.String(passphrase))
+}
+
+// newMnemonic returns a fresh BIP39 mnemonic of words words and its seed
+// under passphrase
+func newMnemonic(random io.Reader, words int, passphrase string) (string, []byte, error) {
+	// Every word encodes 11 bits, of which one in 33 is checksum
+	entropy := make([]byte, words*32/3/8)
+	if _, err := io.ReadFull(random, entropy); err != nil {
+		return "", nil, err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.ReplaceAll(mnemonic, " ", mnemonicSeparator), mnemonicSeed(mnemonic, passphrase), nil
+}
+
+// formatDerivationPath renders path as m/a'/b/..., marking hardened segments
+func formatDerivationPath(path []uint32) string {
+	segments := []string{"m"}
+	for _, index := range path {
+		if index >= hardenedOffset {
+			segments = append(segments, fmt.Sprintf("%d'", index-hardenedOffset))
+		} else {
+			segments = append(segments, fmt.Sprint(index))
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// slip10Ed25519 derives the ed25519 private key seed at path per SLIP-0010,
+// which only defines hardened children for ed25519
+func slip10Ed25519(seed []byte, path []uint32) ([]byte, error) {
+	mac := hmac.New(sha512.New, []byte(slip10Ed25519Key))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	for _, index := range path {
+		if index < hardenedOffset {
+			return nil, fmt.Errorf("ed25519 derivation requires hardened indexes, got %d in %s", index, formatDerivationPath(path))
+		}
+		data := append([]byte{0x00}, key...)
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+	return key, nil
+}
+
+// parsePathTemplate parses a -path template such as m/44'/60'/0'/0/i, where
+// i stands for the account index and ', h or H marks a hardened segment. It
+// returns a path function for it and whether the template contains i
+func parsePathTemplate(template string) (func(int, derivationOptions) ([]uint32, error), bool, error) {
+	segments := strings.Split(strings.TrimSpace(template), "/")
+	if segments[0] != "m" || len(segments) < 2 {
+		return nil, false, fmt.Errorf("derivation path must start with m/: %q", template)
+	}
+
+	type segment struct {
+		value    uint32
+		hardened bool
+		account  bool
+	}
+	parsed := make([]segment, 0, len(segments)-1)
+	indexed := false
+	for _, s := range segments[1:] {
+		var seg segment
+		if trimmed := strings.TrimRight(s, "'hH"); len(s)-len(trimmed) == 1 {
+			seg.hardened = true
+			s = trimmed
+		}
+		if s == "i" {
+			seg.account = true
+			indexed = true
+		} else {
+			value, err := strconv.ParseUint(s, 10, 31)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid derivation path segment %q in %q", s, template)
+			}
+			seg.value = uint32(value)
+		}
+		parsed = append(parsed, seg)
+	}
+
+	return func(index int, _ derivationOptions) ([]uint32, error) {
+		path := make([]uint32, len(parsed))
+		for i, seg := range parsed {
+			path[i] = seg.value
+			if seg.account {
+				path[i] = uint32(index)
+			}
+			if seg.hardened {
+				path[i] += hardenedOffset
+			}
+		}
+		return path, nil
+	}, indexed, nil
+}
+
+// bip44Purposes are the purposes of BIP44-style paths, whose purpose, coin
+// type and account segments wallets always harden
+var bip44Purposes = []uint32{44, 49, 84, 86}
+
+// unhardenedSegments returns the positions, counting from 1, of the segments
+// of path that are not hardened
+func unhardenedSegments(path []uint32) []int {
+	var positions []int
+	for i, index := range path {
+		if index < hardenedOffset {
+			positions = append(positions, i+1)
+		}
+	}
+	return positions
+}
+
+// isBIP44Unhardened reports whether path follows a BIP44-style purpose but
+// leaves its purpose, coin type or account segment unhardened
+func isBIP44Unhardened(path []uint32) bool {
+	if len(path) < 3 || !slices.Contains(bip44Purposes, path[0]&^hardenedOffset) {
+		return false
+	}
+	return path[0] < hardenedOffset || path[1] < hardenedOffset || path[2] < hardenedOffset
+}
+
+// hardenPath returns pathFunc with every segment of its paths hardened, as
+// ed25519 libraries that accept unhardened segments silently do
+func hardenPath(pathFunc func(int, derivationOptions) ([]uint32, error)) func(int, derivationOptions) ([]uint32, error) {
+	return func(index int, opts derivationOptions) ([]uint32, error) {
+		path, err := pathFunc(index, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range path {
+			path[i] |= hardenedOffset
+		}
+		return path, nil
+	}
+}
+
+// bip44Path returns the path function of m/44'/coin'/0'/0/i, the layout
+// MetaMask and most single-chain wallets use, or of the fully hardened
+// m/44'/coin'/i'/0'/0' that ed25519 chains use
+func bip44Path(coin uint32, hardened bool) func(int, derivationOptions) ([]uint32, error) {
+	return func(index int, _ derivationOptions) ([]uint32, error) {
+		if hardened {
+			return []uint32{44 + hardenedOffset, coin + hardenedOffset, uint32(index) + hardenedOffset, 0 + hardenedOffset, 0 + hardenedOffset}, nil
+		}
+		return []uint32{44 + hardenedOffset, coin + hardenedOffset, 0 + hardenedOffset, 0, uint32(index)}, nil
+	}
+}
+
+// solanaPath is m/44'/501'/i'/0', the path Phantom and Solflare use for each
+// account added to a seed phrase
+func solanaPath(index int, _ derivationOptions) ([]uint32, error) {
+	return []uint32{44 + hardenedOffset, 501 + hardenedOffset, uint32(index) + hardenedOffset, 0 + hardenedOffset}, nil
+}
+
+// suiPath is the Sui Wallet path of the scheme: m/44'/784'/i'/0'/0' for
+// ed25519, m/54'/784'/i'/0/0 for secp256k1 and m/74'/784'/i'/0/0 for
+// secp256r1
+func suiPath(index int, opts derivationOptions) ([]uint32, error) {
+	switch opts.scheme {
+	case "ed25519":
+		return bip44Path(784, true)(index, opts)
+	case "secp256k1":
+		return []uint32{54 + hardenedOffset, 784 + hardenedOffset, uint32(index) + hardenedOffset, 0, 0}, nil
+	case "secp256r1":
+		return []uint32{74 + hardenedOffset, 784 + hardenedOffset, uint32(index) + hardenedOffset, 0, 0}, nil
+	default:
+		return nil, fmt.Errorf("mnemonic derivation is not supported for %s keys", opts.scheme)
+	}
+}
+
+// bitcoinPath is the BIP84 native segwit receive path m/84'/coin'/0'/0/i,
+// with coin type 1 on every test network
+func bitcoinPath(index int, opts derivationOptions) ([]uint32, error) {
+	coin := uint32(0)
+	if opts.network != "mainnet" {
+		coin = 1
+	}
+	return []uint32{84 + hardenedOffset, coin + hardenedOffset, 0 + hardenedOffset, 0, uint32(index)}, nil
+}
+
+// aptosPath is m/44'/637'/i'/0'/0', the path Petra derives accounts at
+func aptosPath(index int, opts derivationOptions) ([]uint32, error) {
+	return bip44Path(637, true)(index, opts)
+}
+
+// bip32ExtendedKey derives the extended private key at path per BIP32
+func bip32ExtendedKey(seed []byte, path []uint32) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		if key, err = key.Derive(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// bip32Secp256k1 derives the secp256k1 private key at path per BIP32
+func bip32Secp256k1(seed []byte, path []uint32) (*btcec.PrivateKey, error) {
+	key, err := bip32ExtendedKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return key.ECPrivKey()
+}
+
+func deriveEVMKeyPair(seed []byte, path []uint32, _ derivationOptions) (string, string, keyExtras, error) {
+	key, err := bip32Secp256k1(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	privateKey, address := evmKeyPair(key.ToECDSA())
+	return privateKey, address, keyExtras{"derivationPath": publicExtra(formatDerivationPath(path))}, nil
+}
+
+func deriveSolanaKeyPair(seed []byte, path []uint32, _ derivationOptions) (string, string, keyExtras, error) {
+	key, err := slip10Ed25519(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	privateKey, publicKey, err := solanaKeyPair(key)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return privateKey, publicKey, keyExtras{"derivationPath": publicExtra(formatDerivationPath(path))}, nil
+}
+
+func deriveSuiKeyPair(seed []byte, path []uint32, opts derivationOptions) (string, string, keyExtras, error) {
+	var secret []byte
+	switch opts.scheme {
+	case "ed25519":
+		key, err := slip10Ed25519(seed, path)
+		if err != nil {
+			return "", "", nil, err
+		}
+		secret = key
+	case "secp256k1", "secp256r1":
+		// Sui derives secp256r1 keys on the secp256k1 BIP32 tree too, not
+		// with SLIP-0010's NIST P-256 curve
+		key, err := bip32Secp256k1(seed, path)
+		if err != nil {
+			return "", "", nil, err
+		}
+		secret = key.Serialize()
+	default:
+		return "", "", nil, fmt.Errorf("mnemonic derivation is not supported for %s keys", opts.scheme)
+	}
+
+	privateKey, address, err := suiKeyPair(opts.scheme, secret)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return privateKey, address, keyExtras{"derivationPath": publicExtra(formatDerivationPath(path))}, nil
+}
+
+func deriveBitcoinKeyPair(seed []byte, path []uint32, opts derivationOptions) (string, string, keyExtras, error) {
+	key, err := bip32Secp256k1(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return withDerivationPath(path)(bitcoinKeyPair(bitcoinNetworks[opts.network], key))
+}
+
+func deriveCosmosKeyPair(seed []byte, path []uint32, opts derivationOptions) (string, string, keyExtras, error) {
+	key, err := bip32Secp256k1(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return withDerivationPath(path)(cosmosKeyPair(opts.hrp, key))
+}
+
+func deriveTronKeyPair(seed []byte, path []uint32, _ derivationOptions) (string, string, keyExtras, error) {
+	key, err := bip32Secp256k1(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return withDerivationPath(path)(tronKeyPair(key.ToECDSA()))
+}
+
+func deriveAptosKeyPair(seed []byte, path []uint32, _ derivationOptions) (string, string, keyExtras, error) {
+	key, err := slip10Ed25519(seed, path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return withDerivationPath(path)(aptosKeyPair(key))
+}
+
+// withDerivationPath adds path to the extras of a generator's result
+func withDerivationPath(path []uint32) func(string, string, keyExtras, error) (string, string, keyExtras, error) {
+	return func(privateKey, publicKey string, extra keyExtras, err error) (string, string, keyExtras, error) {
+		if err != nil {
+			return "", "", nil, err
+		}
+		extra["derivationPath"] = publicExtra(formatDerivationPath(path))
+		return privateKey, publicKey, extra, nil
+	}
+}
+
+// deriveMnemonicKeyPair derives the index-th keypair from seed, or with
+// perKey from a new mnemonic of its own, listed in the extras, at the first
+// account of the path
+func deriveMnemonicKeyPair(random io.Reader, deriver mnemonicDeriver, seed []byte, index int, perKey bool, opts derivationOptions) (string, string, keyExtras, error) {
+	var mnemonic string
+	if perKey {
+		var err error
+		if mnemonic, seed, err = newMnemonic(random, opts.words, opts.passphrase); err != nil {
+			return "", "", nil, err
+		}
+		index = 0
+	}
+
+	path, err := deriver.path(index, opts)
+	if err != nil {
+		return "", "", nil, err
+	}
+	privateKey, publicKey, extra, err := deriver.derive(seed, path, opts)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if perKey {
+		extra["mnemonic"] = secretExtra(mnemonic)
+	}
+	return privateKey, publicKey, extra, nil
+}
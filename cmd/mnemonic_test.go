@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+)
+
+// abandonMnemonic is the all-zero entropy mnemonic of the BIP39 test
+// vectors, which wallets publish the addresses of
+const abandonMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMnemonicSeed(t *testing.T) {
+	for _, tt := range []struct {
+		passphrase, seed string
+	}{
+		{"", "5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4"},
+		// The BIP39 test vectors are all under the passphrase TREZOR
+		{"TREZOR", "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"},
+	} {
+		if seed := hex.EncodeToString(mnemonicSeed(abandonMnemonic, tt.passphrase)); seed != tt.seed {
+			t.Errorf("mnemonicSeed(%q) = %s, want %s", tt.passphrase, seed, tt.seed)
+		}
+	}
+}
+
+// The first test vector of BIP32
+func TestBIP32Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	master, err := bip32ExtendedKey(seed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi"; master.String() != want {
+		t.Errorf("m = %s, want %s", master, want)
+	}
+	child, err := bip32ExtendedKey(seed, []uint32{hardenedOffset})
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := child.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw"; public.String() != want {
+		t.Errorf("m/0' = %s, want %s", public, want)
+	}
+}
+
+// The first test vector of SLIP-0010 for ed25519
+func TestSLIP10Vector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	for _, tt := range []struct {
+		path []uint32
+		key  string
+	}{
+		{nil, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{[]uint32{hardenedOffset}, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+	} {
+		key, err := slip10Ed25519(seed, tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key) != tt.key {
+			t.Errorf("slip10Ed25519(%s) = %x, want %s", formatDerivationPath(tt.path), key, tt.key)
+		}
+	}
+}
+
+func TestDeriveMnemonicKeyPair(t *testing.T) {
+	seed := mnemonicSeed(abandonMnemonic, "")
+	opts := derivationOptions{network: "mainnet", hrp: "cosmos", words: defaultMnemonicWords}
+	for _, tt := range []struct {
+		keyType             string
+		index               int
+		privateKey, address string
+		path                string
+	}{
+		{"evm", 0, "1ab42cc412b618bdea3a599e3c9bae199ebf030895b039e9db1e30dafb12b727", "0x9858EfFD232B4033E47d90003D41EC34EcaEda94", "m/44'/60'/0'/0/0"},
+		{"evm", 1, "9a983cb3d832fbde5ab49d692b7a8bf5b5d232479c99333d0fc8e1d21f1b55b6", "0x6Fac4D18c912343BF86fa7049364Dd4E424Ab9C0", "m/44'/60'/0'/0/1"},
+		// The first receive address of the BIP84 test vectors
+		{"bitcoin", 0, "KyZpNDKnfs94vbrwhJneDi77V6jF64PWPF8x5cdJb8ifgg2DUc9d", "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", "m/84'/0'/0'/0/0"},
+		{"solana", 0, "27npWoNE4HfmLeQo1TyWcW7NEA28qnsnDK7kcttDQEWrCWnro83HMJ97rMmpvYYZRwDAvG4KRuB7hTBacvwD7bgi", "HAgk14JpMQLgt6rVgv7cBQFJWFto5Dqxi472uT3DKpqk", "m/44'/501'/0'/0'"},
+		{"cosmos", 0, "c4a48e2fce1481cd3294b4490f6678090ea98d3d0e5cd984558ab0968741b104", "cosmos19rl4cm2hmr8afy4kldpxz3fka4jguq0auqdal4", "m/44'/118'/0'/0/0"},
+		{"tron", 0, "b5a4cea271ff424d7c31dc12a3e43e401df7a40d7412a15750f3f0b6b5449a28", "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH", "m/44'/195'/0'/0/0"},
+	} {
+		privateKey, address, extra, err := deriveMnemonicKeyPair(rand.Reader, mnemonicDerivers[tt.keyType], seed, tt.index, false, opts)
+		if err != nil {
+			t.Fatalf("%s %d: %v", tt.keyType, tt.index, err)
+		}
+		if privateKey != tt.privateKey || address != tt.address || extra["derivationPath"].value != tt.path {
+			t.Errorf("%s %d = %s, %s at %s, want %s, %s at %s", tt.keyType, tt.index, privateKey, address, extra["derivationPath"].value, tt.privateKey, tt.address, tt.path)
+		}
+	}
+}
+
+func TestParsePathTemplate(t *testing.T) {
+	pathFunc, indexed, err := parsePathTemplate("m/44'/60'/i'/0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := pathFunc(7, derivationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !indexed || formatDerivationPath(path) != "m/44'/60'/7'/0/0" {
+		t.Errorf("path %s, indexed %v", formatDerivationPath(path), indexed)
+	}
+	if unhardened := unhardenedSegments(path); len(unhardened) != 2 || unhardened[0] != 4 || unhardened[1] != 5 {
+		t.Errorf("unhardenedSegments = %v, want [4 5]", unhardened)
+	}
+	if _, _, err := parsePathTemplate("44'/60'"); err == nil {
+		t.Error("a path without m parsed")
+	}
+}
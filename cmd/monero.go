@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"filippo.io/edwards25519"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	moneroBase58Alphabet       = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	moneroMnemonicPrefixLength = 3
+	moneroChecksumLength       = 4
+)
+
+// moneroNetworkBytes maps the -network flag to the standard and subaddress
+// address prefixes
+var moneroNetworkBytes = map[string]struct{ standard, subaddress byte }{
+	"mainnet":  {18, 42},
+	"testnet":  {53, 63},
+	"stagenet": {24, 36},
+}
+
+// moneroSubaddressRange selects which subaddresses to derive for each wallet
+type moneroSubaddressRange struct {
+	account uint32
+	count   uint32
+}
+
+// moneroKeccak is the original Keccak-256 used throughout CryptoNote
+func moneroKeccak(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// moneroScalar reduces a 32-byte little-endian value modulo the group order
+func moneroScalar(b []byte) (*edwards25519.Scalar, error) {
+	wide := make([]byte, 64)
+	copy(wide, b)
+	return edwards25519.NewScalar().SetUniformBytes(wide)
+}
+
+// moneroBase58 encodes data in Monero's block-wise base58, where each 8-byte
+// block maps to exactly 11 characters
+func moneroBase58(data []byte) string {
+	encodedBlockSizes := []int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+	var sb strings.Builder
+	for len(data) > 0 {
+		block := data[:min(8, len(data))]
+		data = data[len(block):]
+
+		var num uint64
+		for _, b := range block {
+			num = num<<8 | uint64(b)
+		}
+		encoded := make([]byte, encodedBlockSizes[len(block)])
+		for i := len(encoded) - 1; i >= 0; i-- {
+			encoded[i] = moneroBase58Alphabet[num%58]
+			num /= 58
+		}
+		sb.Write(encoded)
+	}
+	return sb.String()
+}
+
+// moneroAddress encodes network byte, public spend and view keys and checksum
+func moneroAddress(networkByte byte, spend, view []byte) string {
+	data := append([]byte{networkByte}, spend...)
+	data = append(data, view...)
+	data = append(data, moneroKeccak(data)[:moneroChecksumLength]...)
+	return moneroBase58(data)
+}
+
+// moneroMnemonic encodes a 32-byte spend key as 24 words plus a checksum word
+func moneroMnemonic(key []byte) string {
+	n := uint32(len(moneroEnglishWords))
+	words := make([]string, 0, 25)
+	for i := 0; i < len(key); i += 4 {
+		x := binary.LittleEndian.Uint32(key[i : i+4])
+		w1 := x % n
+		w2 := (x/n + w1) % n
+		w3 := (x/n/n + w2) % n
+		words = append(words, moneroEnglishWords[w1], moneroEnglishWords[w2], moneroEnglishWords[w3])
+	}
+
+	var prefixes strings.Builder
+	for _, w := range words {
+		prefixes.WriteString(w[:min(moneroMnemonicPrefixLength, len(w))])
+	}
+	checksum := crc32.ChecksumIEEE([]byte(prefixes.String())) % uint32(len(words))
+
+	return strings.Join(append(words, words[checksum]), " ")
+}
+
+// moneroSubaddress derives the (account, index) subaddress keys:
+// D = B + Hs("SubAddr\0" || a || account || index)*G and C = a*D
+func moneroSubaddress(viewKey *edwards25519.Scalar, spendPub *edwards25519.Point, account, index uint32) ([]byte, []byte, error) {
+	var indexes [8]byte
+	binary.LittleEndian.PutUint32(indexes[:4], account)
+	binary.LittleEndian.PutUint32(indexes[4:], index)
+
+	m, err := moneroScalar(moneroKeccak([]byte("SubAddr\x00"), viewKey.Bytes(), indexes[:]))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d := new(edwards25519.Point).Add(spendPub, new(edwards25519.Point).ScalarBaseMult(m))
+	c := new(edwards25519.Point).ScalarMult(viewKey, d)
+	return d.Bytes(), c.Bytes(), nil
+}
+
+// generateMoneroKeyPair returns the private spend key and standard address of a
+// new wallet; the view key, public keys, 25-word seed and any requested
+// subaddresses are returned as extras
+func generateMoneroKeyPair(random io.Reader, network string, subaddresses moneroSubaddressRange) (string, string, keyExtras, error) {
+	netBytes, ok := moneroNetworkBytes[network]
+	if !ok {
+		return "", "", nil, fmt.Errorf("unsupported network: %s", network)
+	}
+
+	entropy := make([]byte, 32)
+	if _, err := io.ReadFull(random, entropy); err != nil {
+		return "", "", nil, err
+	}
+	return moneroKeyPair(netBytes.standard, netBytes.subaddress, entropy, subaddresses)
+}
+
+// moneroKeyPair derives the wallet whose private spend key is entropy
+// reduced modulo the group order
+func moneroKeyPair(standard, subaddress byte, entropy []byte, subaddresses moneroSubaddressRange) (string, string, keyExtras, error) {
+	spendKey, err := moneroScalar(entropy)
+	if err != nil {
+		return "", "", nil, err
+	}
+	viewKey, err := moneroScalar(moneroKeccak(spendKey.Bytes()))
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	spendPub := new(edwards25519.Point).ScalarBaseMult(spendKey)
+	viewPub := new(edwards25519.Point).ScalarBaseMult(viewKey)
+
+	extra := keyExtras{
+		"mnemonic":       secretExtra(moneroMnemonic(spendKey.Bytes())),
+		"privateViewKey": secretExtra(hex.EncodeToString(viewKey.Bytes())),
+		"publicSpendKey": publicExtra(hex.EncodeToString(spendPub.Bytes())),
+		"publicViewKey":  publicExtra(hex.EncodeToString(viewPub.Bytes())),
+	}
+
+	for i := uint32(0); i < subaddresses.count; i++ {
+		index := i
+		// (0, 0) is the primary address, so account 0 starts at index 1
+		if subaddresses.account == 0 {
+			index++
+		}
+		d, c, err := moneroSubaddress(viewKey, spendPub, subaddresses.account, index)
+		if err != nil {
+			return "", "", nil, err
+		}
+		extra[fmt.Sprintf("subaddress_%d_%d", subaddresses.account, index)] = publicExtra(moneroAddress(subaddress, d, c))
+	}
+
+	address := moneroAddress(standard, spendPub.Bytes(), viewPub.Bytes())
+
+	return hex.EncodeToString(spendKey.Bytes()), address, extra, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The wallet of Monero's functional tests
+func TestMoneroKeyPair(t *testing.T) {
+	spendKey, _ := hex.DecodeString("148d78d2aba7dbca5cd8f6abcfb0b3c009ffbdbea1ff373d50ed94d78286640e")
+	privateKey, address, extra, err := moneroKeyPair(moneroNetworkBytes["mainnet"].standard, moneroNetworkBytes["mainnet"].subaddress, spendKey, moneroSubaddressRange{account: 0, count: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, tt := range map[string]struct{ got, want string }{
+		"private spend key": {privateKey, "148d78d2aba7dbca5cd8f6abcfb0b3c009ffbdbea1ff373d50ed94d78286640e"},
+		"private view key":  {extra["privateViewKey"].value, "49774391fa5e8d249fc2c5b45dadef13534bf2483dede880dac88f061e809100"},
+		"mnemonic":          {extra["mnemonic"].value, "velvet lymph giddy number token physics poetry unquoted nibs useful sabotage limits benches lifestyle eden nitrogen anvil fewest avoid batch vials washing fences goat unquoted"},
+		"address":           {address, "42ey1afDFnn4886T7196doS9GPMzexD9gXpsZJDwVjeRVdFCSoHnv7KPbBeGpzJBzHRCAs9UxqeoyFQMYbqSWYTfJJQAWDm"},
+		"subaddress (0, 1)": {extra["subaddress_0_1"].value, "84QRUYawRNrU3NN1VpFRndSukeyEb3Xpv8qZjjsoJZnTYpDYceuUTpog13D7qPxpviS7J29bSgSkR11hFFoXWk2yNdsR9WF"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", name, tt.got, tt.want)
+		}
+	}
+	if extra["privateViewKey"].public || extra["mnemonic"].public || !extra["subaddress_0_1"].public {
+		t.Error("the private view key and mnemonic are marked public or the subaddress secret")
+	}
+}
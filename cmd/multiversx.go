@@ -0,0 +1,147 @@
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	multiversxHRP             = "erd"
+	multiversxKeystoreVersion = 4
+	multiversxScryptN         = 4096
+	multiversxScryptR         = 8
+	multiversxScryptP         = 1
+	multiversxScryptKeyLength = 32
+)
+
+// multiversxKeystore is the version 4 secret key keystore written by mxpy and
+// the mx-sdk wallet tools
+type multiversxKeystore struct {
+	Version int    `json:"version"`
+	Kind    string `json:"kind"`
+	ID      string `json:"id"`
+	Address string `json:"address"`
+	Bech32  string `json:"bech32"`
+	Crypto  struct {
+		Ciphertext   string `json:"ciphertext"`
+		CipherParams struct {
+			IV string `json:"iv"`
+		} `json:"cipherparams"`
+		Cipher    string `json:"cipher"`
+		KDF       string `json:"kdf"`
+		KDFParams struct {
+			DKLen int    `json:"dklen"`
+			Salt  string `json:"salt"`
+			N     int    `json:"n"`
+			R     int    `json:"r"`
+			P     int    `json:"p"`
+		} `json:"kdfparams"`
+		MAC string `json:"mac"`
+	} `json:"crypto"`
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// generateMultiversXKeyPair returns a hex ed25519 secret key and its erd1
+// address, with the hex public key as an extra
+func generateMultiversXKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return "", "", nil, err
+	}
+
+	pubKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	address, err := encodeBech32(multiversxHRP, pubKey)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding address: %w", err)
+	}
+
+	extra := keyExtras{
+		"publicKey": publicExtra(hex.EncodeToString(pubKey)),
+	}
+
+	return hex.EncodeToString(seed), address, extra, nil
+}
+
+// multiversxKeyFiles encrypts each key as <erd1 address>.json; the secret is
+// encrypted with AES-128-CTR under an scrypt key and authenticated with
+// HMAC-SHA256, as mx-sdk-wallet does
+func multiversxKeyFiles(_ int, privateKey, publicKey string, extra map[string]string, password string) (map[string][]byte, error) {
+	seed, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	secret := ed25519.NewKeyFromSeed(seed)
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	derivedKey, err := scrypt.Key([]byte(password), salt, multiversxScryptN, multiversxScryptR, multiversxScryptP, multiversxScryptKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(secret))
+	cipher.NewCTR(block, iv).XORKeyStream(ciphertext, secret)
+
+	mac := hmac.New(sha256.New, derivedKey[16:])
+	mac.Write(ciphertext)
+
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	keystore := multiversxKeystore{
+		Version: multiversxKeystoreVersion,
+		Kind:    "secretKey",
+		ID:      id,
+		Address: extra["publicKey"],
+		Bech32:  publicKey,
+	}
+	keystore.Crypto.Ciphertext = hex.EncodeToString(ciphertext)
+	keystore.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	keystore.Crypto.Cipher = "aes-128-ctr"
+	keystore.Crypto.KDF = "scrypt"
+	keystore.Crypto.KDFParams.DKLen = multiversxScryptKeyLength
+	keystore.Crypto.KDFParams.Salt = hex.EncodeToString(salt)
+	keystore.Crypto.KDFParams.N = multiversxScryptN
+	keystore.Crypto.KDFParams.R = multiversxScryptR
+	keystore.Crypto.KDFParams.P = multiversxScryptP
+	keystore.Crypto.MAC = hex.EncodeToString(mac.Sum(nil))
+
+	data, err := json.MarshalIndent(keystore, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{publicKey + ".json": data}, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// keyRecord is one keypair, with the mnemonic it was derived from and its
+// chain-specific values: a line of the ndjson format, and an element of the
+// .Keys of templates
+type keyRecord struct {
+	Index      int               `json:"index"`
+	Type       string            `json:"type"`
+	Address    string            `json:"address"`
+	PrivateKey string            `json:"privateKey,omitempty"`
+	Mnemonic   string            `json:"mnemonic,omitempty"`
+	Extra      map[string]string `json:"extra,omitempty"`
+	// Insecure marks keys generated with -insecure-seed
+	Insecure bool `json:"insecure,omitempty"`
+}
+
+// ndjsonStream writes the keypairs as ndjson records, numbered from
+// startIndex, as they are generated
+type ndjsonStream struct {
+	enc        *json.Encoder
+	keyType    string
+	mnemonic   string
+	startIndex int
+	insecure   bool
+}
+
+// newNDJSONStream returns a stream of keyType records to w. mnemonic is the
+// phrase shared by every keypair, if any, and insecure marks every record as
+// generated with -insecure-seed
+func newNDJSONStream(w io.Writer, keyType, mnemonic string, startIndex int, insecure bool) *ndjsonStream {
+	return &ndjsonStream{enc: json.NewEncoder(w), keyType: keyType, mnemonic: mnemonic, startIndex: startIndex, insecure: insecure}
+}
+
+// write writes the i-th keypair of the batch as a line
+func (s *ndjsonStream) write(i int, privateKey, publicKey string, extra map[string]string) error {
+	return s.enc.Encode(keyRecord{
+		Index:      s.startIndex + i,
+		Type:       s.keyType,
+		Address:    publicKey,
+		PrivateKey: privateKey,
+		Mnemonic:   s.mnemonic,
+		Extra:      extra,
+		Insecure:   s.insecure,
+	})
+}
+
+// ndjsonOutput is where a run of the ndjson format streams its keypairs:
+// the results, compressed if asked, and with -public-only the private keys
+// of -private-out
+type ndjsonOutput struct {
+	// filename is the name of the results, or stdout
+	filename              string
+	publicOnly            bool
+	stream, privateStream *ndjsonStream
+	file, privateFile     *os.File
+	compressor            io.WriteCloser
+}
+
+// write writes the i-th keypair of the batch to the streams, without its
+// secrets to the results of -public-only
+func (s *ndjsonOutput) write(i int, privateKey, publicKey string, extra keyExtras) error {
+	streamKey, streamExtra := privateKey, extra.values()
+	if s.publicOnly {
+		streamKey, streamExtra = "", extra.publicValues()
+	}
+	if err := s.stream.write(i, streamKey, publicKey, streamExtra); err != nil {
+		return fmt.Errorf("writing results: %v", err)
+	}
+	if s.privateStream != nil {
+		if err := s.privateStream.write(i, privateKey, publicKey, extra.values()); err != nil {
+			return fmt.Errorf("writing private keys: %v", err)
+		}
+	}
+	return nil
+}
+
+// close flushes the compressor and closes the files of the streams
+func (s *ndjsonOutput) close() error {
+	if s.compressor != nil {
+		if err := s.compressor.Close(); err != nil {
+			return fmt.Errorf("writing results: %v", err)
+		}
+	}
+	if s.file != nil {
+		if err := closeOutputFile(s.file); err != nil {
+			return fmt.Errorf("writing results: %v", err)
+		}
+	}
+	if s.privateFile != nil {
+		if err := closeOutputFile(s.privateFile); err != nil {
+			return fmt.Errorf("writing private keys: %v", err)
+		}
+	}
+	return nil
+}
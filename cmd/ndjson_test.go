@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNDJSONOutputPublicOnly(t *testing.T) {
+	var public, private bytes.Buffer
+	s := &ndjsonOutput{
+		publicOnly:    true,
+		stream:        newNDJSONStream(&public, "evm", "", 5, false),
+		privateStream: newNDJSONStream(&private, "evm", abandonMnemonic, 5, false),
+	}
+	extra := keyExtras{"derivationPath": publicExtra("m/44'/60'/0'/0/5"), "mnemonic": secretExtra("own words")}
+	if err := s.write(1, "0x01", "0xabc", extra); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"index":6,"type":"evm","address":"0xabc","extra":{"derivationPath":"m/44'/60'/0'/0/5"}}` + "\n"; public.String() != want {
+		t.Errorf("public stream %s, want %s", public.String(), want)
+	}
+	if want := `{"index":6,"type":"evm","address":"0xabc","privateKey":"0x01","mnemonic":"` + abandonMnemonic + `","extra":{"derivationPath":"m/44'/60'/0'/0/5","mnemonic":"own words"}}` + "\n"; private.String() != want {
+		t.Errorf("private stream %s, want %s", private.String(), want)
+	}
+}
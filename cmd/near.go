@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+
+	"github.com/mr-tron/base58"
+)
+
+const nearKeyPrefix = "ed25519:"
+
+// generateNEARKeyPair returns the secret key in the ed25519:<base58> form NEAR
+// CLI expects and the implicit account ID, the hex encoding of the public key
+func generateNEARKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	pubKey, privKey, err := newEd25519Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	extra := keyExtras{
+		"publicKey": publicExtra(nearKeyPrefix + base58.Encode(pubKey)),
+	}
+
+	return nearKeyPrefix + base58.Encode(privKey), hex.EncodeToString(pubKey), extra, nil
+}
+
+// nearCredentials is the ~/.near-credentials/<network>/<account>.json layout
+type nearCredentials struct {
+	AccountID  string `json:"account_id"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key"`
+}
+
+// nearKeyFiles renders one account as a NEAR CLI credentials file
+func nearKeyFiles(_ int, privateKey, publicKey string, extra map[string]string, _ string) (map[string][]byte, error) {
+	data, err := json.Marshal(nearCredentials{
+		AccountID:  publicKey,
+		PublicKey:  extra["publicKey"],
+		PrivateKey: privateKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{publicKey + ".json": data}, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// generateNostrKeyPair returns NIP-19 nsec and npub keys, with the raw hex
+// secret and x-only public key used by relays and NIP-01 events as extras
+func generateNostrKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	secret := privateKey.Serialize()
+	pubKey := schnorr.SerializePubKey(privateKey.PubKey())
+
+	nsec, err := encodeBech32("nsec", secret)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding nsec: %w", err)
+	}
+	npub, err := encodeBech32("npub", pubKey)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding npub: %w", err)
+	}
+
+	extra := keyExtras{
+		"privateKeyHex": secretExtra(hex.EncodeToString(secret)),
+		"publicKeyHex":  publicExtra(hex.EncodeToString(pubKey)),
+	}
+
+	return nsec, npub, extra, nil
+}
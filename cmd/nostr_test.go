@@ -0,0 +1,25 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The npub and nsec examples of NIP-19
+func TestNostrBech32Keys(t *testing.T) {
+	for _, tt := range []struct {
+		hrp, key, encoded string
+	}{
+		{"npub", "3bf0c63fcb93463407af97a5e5ee64fa883d107ef9e558472c4eb9aaaefa459d", "npub180cvv07tjdrrgpa0j7j7tmnyl2yr6yr7l8j4s3evf6u64th6gkwsyjh6w6"},
+		{"nsec", "67dea2ed018072d675f5415ecfaed7d2597555e202d85b3d65ea4e58d2d92ffa", "nsec1vl029mgpspedva04g90vltkh6fvh240zqtv9k0t9af8935ke9laqsnlfe5"},
+	} {
+		key, _ := hex.DecodeString(tt.key)
+		encoded, err := encodeBech32(tt.hrp, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoded != tt.encoded {
+			t.Errorf("encodeBech32(%s, %s) = %s, want %s", tt.hrp, tt.key, encoded, tt.encoded)
+		}
+	}
+}
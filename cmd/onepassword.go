@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// opItem is a 1Password item, in the shape both the Connect API and the op
+// CLI's item templates take
+type opItem struct {
+	Vault    *opVault  `json:"vault,omitempty"`
+	Title    string    `json:"title"`
+	Category string    `json:"category"`
+	Tags     []string  `json:"tags,omitempty"`
+	Fields   []opField `json:"fields"`
+}
+
+type opVault struct {
+	ID string `json:"id"`
+}
+
+// opField is a field of an opItem; CONCEALED ones are hidden until revealed
+type opField struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// opTitleTemplate titles the items of -op-vault by default
+const opTitleTemplate = "{{.Type}} {{.Address}}"
+
+// parseOPTitle parses a -op-title template, executed with each keyRecord
+func parseOPTitle(title string) (*template.Template, error) {
+	return template.New("op-title").Funcs(templateFuncs).Option("missingkey=error").Parse(title)
+}
+
+// newOPItem returns the item of record: its address, chain and derivation
+// path in plain fields, and its private key and other chain-specific values,
+// some of which are secret, in concealed ones
+func newOPItem(record keyRecord, title string, tags []string) opItem {
+	item := opItem{
+		Title:    title,
+		Category: "SECURE_NOTE",
+		Tags:     tags,
+		Fields: []opField{
+			{ID: "address", Type: "STRING", Label: "address", Value: record.Address},
+			{ID: "chain", Type: "STRING", Label: "chain", Value: record.Type},
+			{ID: "private_key", Type: "CONCEALED", Label: "private key", Value: record.PrivateKey},
+		},
+	}
+	if path, ok := record.Extra["derivationPath"]; ok {
+		item.Fields = append(item.Fields, opField{ID: "derivation_path", Type: "STRING", Label: "derivation path", Value: path})
+	}
+	names := make([]string, 0, len(record.Extra))
+	for name := range record.Extra {
+		if name != "derivationPath" {
+			names = append(names, name)
+		}
+	}
+	// Fields keep a stable order from key to key
+	slices.Sort(names)
+	for _, name := range names {
+		item.Fields = append(item.Fields, opField{ID: name, Type: "CONCEALED", Label: name, Value: record.Extra[name]})
+	}
+	return item
+}
+
+// opConnect calls the API of a 1Password Connect server
+type opConnect struct {
+	host  string
+	token string
+	http  *http.Client
+}
+
+// request calls the API at path with body as JSON, decoding the response
+// into out unless it is nil
+func (c *opConnect) request(method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.host+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var opErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &opErr) == nil && opErr.Message != "" {
+			return fmt.Errorf("%s %s: %s", method, req.URL.Path, opErr.Message)
+		}
+		return fmt.Errorf("%s %s: %s", method, req.URL.Path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// vaultID returns the ID of the vault named vault, or vault itself if it is
+// one already
+func (c *opConnect) vaultID(vault string) (string, error) {
+	var vaults []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := c.request(http.MethodGet, "/v1/vaults", nil, &vaults); err != nil {
+		return "", err
+	}
+	for _, v := range vaults {
+		if v.ID == vault || v.Name == vault {
+			return v.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no vault %q is shared with the Connect token", vault)
+}
+
+// opCLICreate creates item in vault with the op CLI, which must be signed in
+// or have OP_SERVICE_ACCOUNT_TOKEN set. The item is piped to it, so the
+// private key never appears in its arguments
+func opCLICreate(vault string, item opItem) error {
+	args := []string{"item", "create", "--vault", vault, "--format", "json"}
+	if len(item.Tags) > 0 {
+		args = append(args, "--tags", strings.Join(item.Tags, ","))
+	}
+	item.Tags = nil
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("op", append(args, "-")...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return fmt.Errorf("op item create: %s", message)
+		}
+		return fmt.Errorf("op item create: %w", err)
+	}
+	return nil
+}
+
+// writeOnePassword creates a 1Password item in vault per keypair of result,
+// numbered from startIndex, titled by executing title with its keyRecord.
+// It uses the Connect server of OP_CONNECT_HOST and OP_CONNECT_TOKEN when
+// they are set, and the op CLI otherwise. The number of items created is
+// returned
+func writeOnePassword(vault string, result KeyGenResult, startIndex int, title *template.Template, tags []string) (int, error) {
+	var connect *opConnect
+	if host := os.Getenv("OP_CONNECT_HOST"); host != "" {
+		token := os.Getenv("OP_CONNECT_TOKEN")
+		if token == "" {
+			return 0, errors.New("OP_CONNECT_HOST requires OP_CONNECT_TOKEN")
+		}
+		connect = &opConnect{host: strings.TrimRight(host, "/"), token: token, http: &http.Client{Timeout: 30 * time.Second}}
+		id, err := connect.vaultID(vault)
+		if err != nil {
+			return 0, err
+		}
+		vault = id
+	} else if _, err := exec.LookPath("op"); err != nil {
+		return 0, errors.New("neither OP_CONNECT_HOST is set nor the op CLI installed")
+	}
+
+	for i, record := range keyRecords(result, startIndex) {
+		var itemTitle strings.Builder
+		if err := title.Execute(&itemTitle, record); err != nil {
+			return i, err
+		}
+		item := newOPItem(record, itemTitle.String(), tags)
+		if connect == nil {
+			if err := opCLICreate(vault, item); err != nil {
+				return i, err
+			}
+			continue
+		}
+		item.Vault = &opVault{ID: vault}
+		if err := connect.request(http.MethodPost, "/v1/vaults/"+url.PathEscape(vault)+"/items", item, nil); err != nil {
+			return i, err
+		}
+	}
+	return len(result.PublicKeys), nil
+}
@@ -0,0 +1,1115 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// options are the flags of a generation run
+type options struct {
+	keyType              string
+	count                int
+	network              string
+	altEncoding          string
+	hrp                  string
+	walletVersion        string
+	workchain            int
+	scheme               string
+	ss58Prefix           int
+	multisig             int
+	subaddressAccount    uint
+	subaddressCount      uint
+	withdrawalAddress    string
+	dvOperators          int
+	dvThreshold          int
+	qr                   bool
+	qrPrivate            bool
+	suiKeystore          string
+	kdf                  string
+	accountPreset        string
+	classHash            string
+	constructorCalldata  string
+	agentName            string
+	masterKeyFile        string
+	nodeKey              bool
+	enodeHost            string
+	enodePort            int
+	useMnemonic          bool
+	fromMnemonic         string
+	mnemonicFile         string
+	electrum             bool
+	fromSeed             string
+	slip39               string
+	slip39File           string
+	exportXpub           bool
+	words                int
+	wordlist             string
+	usePassphrase        bool
+	passphraseFile       string
+	passphraseEnv        string
+	pathTemplate         string
+	hardenAll            bool
+	preset               string
+	startIndex           int
+	endIndex             int
+	mnemonicPerKey       bool
+	keyDir               string
+	passwordFile         string
+	format               string
+	encryptAge           string
+	encryptPassword      bool
+	encryptAgePassphrase bool
+	encryptPGP           string
+	encryptKMS           string
+	sops                 bool
+	compression          string
+	output               string
+	sse                  string
+	sseKMSKey            string
+	files                *fileFlags
+	archive              string
+	splitFiles           bool
+	outputDir            string
+	splitName            string
+	envPrefixFlag        string
+	k8sNamespaceFlag     string
+	k8sNameFlag          string
+	k8sPerKeyFlag        bool
+	k8sSealCert          string
+	templateFile         string
+	vaultAddr            string
+	vaultPath            string
+	awsSecrets           bool
+	awsSecretName        string
+	awsTagsFlag          string
+	gcpProject           string
+	gcpSecretID          string
+	gcpLabelsFlag        string
+	azureVault           string
+	azureSecretName      string
+	azureTagsFlag        string
+	opVaultFlag          string
+	opTitle              string
+	opTags               string
+	store                string
+	postgresDSN          string
+	publicFile           bool
+	publicOnly           bool
+	privateOut           string
+	insecureSeed         string
+}
+
+// addFlags defines the flags of a generation run on fs
+func addFlags(fs *flag.FlagSet) *options {
+	o := new(options)
+	fs.StringVar(&o.keyType, "type", "", "Key type: "+quoteList(keyTypes))
+	fs.IntVar(&o.count, "count", 1, "Number of keypairs to generate")
+	fs.StringVar(&o.network, "network", "mainnet", "Network: 'mainnet', 'testnet', 'signet', or 'regtest' for bitcoin; 'mainnet' or 'testnet' for ton, cardano, zcash, kaspa, bch, chia, ckb, iota, shimmer and hyperliquid; 'mainnet', 'testnet', or 'stagenet' for monero; 'mainnet', 'sepolia', 'holesky', or 'hoodi' for eth-validator")
+	fs.StringVar(&o.altEncoding, "alt-encoding", "", "EVM only: also encode each address as 'harmony' (one1...) bech32")
+	fs.StringVar(&o.hrp, "hrp", "cosmos", "Cosmos, eth-cosmos and cometbft only: bech32 account prefix, e.g. 'cosmos', 'osmo', 'celestia', or 'evmos'")
+	fs.StringVar(&o.walletVersion, "wallet-version", "v4r2", "TON only: wallet contract version, "+quoteList(tonWalletVersions))
+	fs.IntVar(&o.workchain, "workchain", 0, "TON only: workchain ID of the wallet contract")
+	fs.StringVar(&o.scheme, "scheme", "", "Signature scheme: 'ed25519' (default), 'secp256k1', or 'secp256r1' for sui; 'sr25519' (default) or 'ed25519' for substrate; 'ed25519' (default) or 'secp256k1' for tezos, hedera and casper; 'secp256k1' (default) or 'ed25519' for icp")
+	fs.IntVar(&o.ss58Prefix, "ss58-prefix", 42, "Substrate only: SS58 network prefix, e.g. 0 for Polkadot, 2 for Kusama")
+	fs.IntVar(&o.multisig, "multisig", 0, "Bitcoin only: build an M-of-count multisig from the batch with this threshold M")
+	fs.UintVar(&o.subaddressAccount, "subaddress-account", 0, "Monero only: account whose subaddresses are derived")
+	fs.UintVar(&o.subaddressCount, "subaddress-count", 0, "Monero only: number of subaddresses to derive per wallet")
+	fs.StringVar(&o.withdrawalAddress, "withdrawal-address", "", "eth-validator only: execution address for 0x01 withdrawal credentials instead of a BLS withdrawal key")
+	fs.IntVar(&o.dvOperators, "dv-operators", 0, "eth-validator only: split each validator key into shares for this many distributed validator operators")
+	fs.IntVar(&o.dvThreshold, "dv-threshold", 0, "eth-validator only: shares needed to sign with -dv-operators (default ceil(2n/3))")
+	fs.BoolVar(&o.qr, "qr", false, "Print a QR code of each address and write them as PNG files to [type]_qr_[timestamp]")
+	fs.BoolVar(&o.qrPrivate, "qr-private", false, "With -qr, also render the private keys as QR codes")
+	fs.StringVar(&o.suiKeystore, "sui-keystore", "", "sui only: also add the keys to this sui.keystore file, created if missing, e.g. ~/.sui/sui_config/sui.keystore")
+	fs.StringVar(&o.kdf, "kdf", "scrypt", "eth-validator only: key derivation function of the -key-dir keystores, 'scrypt' or 'pbkdf2'")
+	fs.StringVar(&o.accountPreset, "account-preset", "", "Starknet only: also compute the account contract address of "+quoteList(slices.Sorted(maps.Keys(starknetAccountPresets))))
+	fs.StringVar(&o.classHash, "class-hash", "", "Starknet only: account class hash to compute addresses for, instead of a preset's")
+	fs.StringVar(&o.constructorCalldata, "constructor-calldata", "publicKey", "Starknet only: comma-separated constructor calldata of -class-hash, with publicKey standing for the key")
+	fs.StringVar(&o.agentName, "agent-name", "", "hyperliquid only: name of the approved agent wallets, numbered when count is above 1")
+	fs.StringVar(&o.masterKeyFile, "master-key-file", "", "hyperliquid only: file with the hex private key of the master account, to sign the approveAgent requests")
+	fs.BoolVar(&o.nodeKey, "node-key", false, "cometbft only: also generate a p2p node key per validator and print the node IDs")
+	fs.StringVar(&o.enodeHost, "enode-host", "127.0.0.1", "geth-nodekey only: host of the printed enode URLs")
+	fs.IntVar(&o.enodePort, "enode-port", 30303, "geth-nodekey only: TCP port of the printed enode URLs")
+	fs.BoolVar(&o.useMnemonic, "mnemonic", false, "Derive every keypair from one new BIP39 mnemonic at the chain's standard wallet path (evm, solana, sui, bitcoin, cosmos, tron, aptos)")
+	fs.StringVar(&o.fromMnemonic, "from-mnemonic", "", "Derive the keypairs from this existing BIP39 mnemonic instead of a new one, or '-' to prompt for it; implies -mnemonic")
+	fs.StringVar(&o.mnemonicFile, "mnemonic-file", "", "Read the existing BIP39 mnemonic to derive from from this file; implies -mnemonic")
+	fs.BoolVar(&o.electrum, "electrum", false, "bitcoin only: generate or import Electrum segwit seeds instead of BIP39 mnemonics, derived at m/0'/0/i")
+	fs.StringVar(&o.fromSeed, "from-seed", "", "Derive the keypairs from this hex BIP32 seed of 16 to 64 bytes instead of a mnemonic, or '-' to prompt for it; implies -mnemonic")
+	fs.StringVar(&o.slip39, "slip39", "", "Derive every keypair from a new master secret split into SLIP-39 share mnemonics, as T-of-N, e.g. 3-of-5")
+	fs.StringVar(&o.slip39File, "slip39-file", "", "Derive the keypairs from the master secret recovered from the SLIP-39 share mnemonics in this file, one per line")
+	fs.BoolVar(&o.exportXpub, "xpub", false, "With a mnemonic, also output the account-level extended keys of secp256k1 keys (xpub/xprv, zpub/zprv for bitcoin)")
+	fs.IntVar(&o.words, "words", defaultMnemonicWords, "Number of words of new mnemonics, or the matching master secret size with -slip39: 12, 15, 18, 21 or 24")
+	fs.StringVar(&o.wordlist, "wordlist", "english", "Language of new and imported mnemonics: english, japanese, korean, spanish, chinese-simplified, chinese-traditional, french, italian or czech")
+	fs.BoolVar(&o.usePassphrase, "passphrase", false, "With a mnemonic, prompt for a BIP39 passphrase (25th word) to derive the seed under")
+	fs.StringVar(&o.passphraseFile, "passphrase-file", "", "With a mnemonic, read the BIP39 passphrase from this file")
+	fs.StringVar(&o.passphraseEnv, "passphrase-env", "", "With a mnemonic, read the BIP39 passphrase from this environment variable")
+	fs.StringVar(&o.pathTemplate, "path", "", "With a mnemonic, derive at this path instead of the chain's standard one; i stands for the account index, e.g. m/44'/60'/i'/0/0")
+	fs.BoolVar(&o.hardenAll, "harden-all", false, "Harden every segment of -path, as ed25519 libraries that accept unhardened segments do")
+	fs.StringVar(&o.preset, "preset", "", "With a mnemonic, derive at the path of a wallet: 'ledger-live', 'metamask', 'trust', 'phantom', or 'suiwallet'")
+	fs.IntVar(&o.startIndex, "start-index", 0, "With a mnemonic, index of the first account to derive")
+	fs.IntVar(&o.endIndex, "end-index", -1, "With a mnemonic, index of the last account to derive; sets -count to end-index - start-index + 1")
+	fs.BoolVar(&o.mnemonicPerKey, "mnemonic-per-key", false, "Give every keypair its own new BIP39 mnemonic, derived at the first account of the standard wallet path")
+	fs.StringVar(&o.keyDir, "key-dir", "", "Also write per-key files in the chain's native format to this directory (evm, solana, cosmos, sei, injective, eth-cosmos, cardano, near, icp, multiversx, mina, casper, lightning, cometbft, geth-nodekey, eth-validator)")
+	fs.StringVar(&o.passwordFile, "password-file", "", "Read the password of encrypted key files from this file instead of prompting")
+	fs.StringVar(&o.format, "format", "json", "Format of the output file: "+quoteList(outputFormats)+"; 'keystore' writes encrypted evm key files and 'paper' printable PDF wallets instead")
+	fs.StringVar(&o.encryptAge, "encrypt-age", "", "Encrypt the results with age to these comma-separated age1... recipients")
+	fs.BoolVar(&o.encryptPassword, "encrypt", false, "Encrypt the results with AES-256-GCM under a password, read from -password-file or prompted for, stretched with argon2id")
+	fs.BoolVar(&o.encryptAgePassphrase, "encrypt-age-passphrase", false, "Encrypt the results with age to a passphrase, read from -password-file or prompted for")
+	fs.StringVar(&o.encryptPGP, "encrypt-pgp", "", "Encrypt the results with OpenPGP to the public keys in these comma-separated key files")
+	fs.StringVar(&o.encryptKMS, "encrypt-kms", "", "Encrypt the results with a data key of this AWS KMS key ID, ARN or alias; with -aws-secrets, the KMS key of the secrets")
+	fs.BoolVar(&o.sops, "sops", false, "With -format=json or yaml, write a SOPS file, each value encrypted to the -encrypt-age recipients and -encrypt-kms key, or to those of the matching .sops.yaml creation rule")
+	fs.StringVar(&o.compression, "compress", "", "Compress the results file with "+quoteList(compressions)+", appending .gz or .zst to its name")
+	fs.StringVar(&o.output, "output", "", "Write the results to this file instead of [type]_keys_[timestamp].[format], '-' for stdout, 'sqlite:<path>' to insert them into a SQLite database, or an s3://bucket/key or gs://bucket/key object to upload them to; a bucket or a prefix ending in / gets the default name")
+	fs.StringVar(&o.sse, "sse", "", "With -output s3://, server-side encryption of the object: "+quoteList(s3SSEAlgorithms)+" (default: the bucket's)")
+	fs.StringVar(&o.sseKMSKey, "sse-kms-key", "", "With -output s3://, the AWS KMS key of aws:kms encryption; with gs://, the Cloud KMS key of the object")
+	o.files = addFileFlags(fs)
+	fs.StringVar(&o.archive, "archive", "", "Bundle the files written by the run into this password-protected archive, an AES-256 .zip or an age-encrypted .tar.age, and remove them")
+	fs.BoolVar(&o.splitFiles, "split-files", false, "Write each keypair to its own results file in -output-dir, e.g. to mount a single key per container")
+	fs.StringVar(&o.outputDir, "output-dir", "", "With -split-files, directory of the results files (default [type]_keys_[timestamp])")
+	fs.StringVar(&o.splitName, "split-name", "index", "With -split-files, name the files after the keypair's "+quoteList(splitFileNames))
+	fs.StringVar(&o.envPrefixFlag, "env-prefix", "", "With -format=env or k8s-secret, prefix of the variable names, e.g. BOT_ for BOT_PRIVATE_KEY_0")
+	fs.StringVar(&o.k8sNamespaceFlag, "k8s-namespace", "", "With -format=k8s-secret, namespace of the Secrets")
+	fs.StringVar(&o.k8sNameFlag, "k8s-name", "", "With -format=k8s-secret, Go template of the Secret names (default "+k8sBatchNameTemplate+", or "+k8sKeyNameTemplate+" with -k8s-per-key)")
+	fs.BoolVar(&o.k8sPerKeyFlag, "k8s-per-key", false, "With -format=k8s-secret, write a Secret per keypair instead of one for the batch")
+	fs.StringVar(&o.k8sSealCert, "k8s-seal-cert", "", "With -format=k8s-secret, write SealedSecrets encrypted to this sealed-secrets controller certificate, from kubeseal --fetch-cert")
+	fs.StringVar(&o.templateFile, "template", "", "Render the results through this Go text/template file instead of -format, e.g. to emit SQL inserts or shell exports")
+	fs.StringVar(&o.vaultAddr, "vault-addr", "", "With -vault-path, address of the Vault server (default $VAULT_ADDR)")
+	fs.StringVar(&o.vaultPath, "vault-path", "", "Write each keypair to Vault KV v2 as the secret <mount>/<path>/<address> instead of writing a file, e.g. secret/wallets")
+	fs.BoolVar(&o.awsSecrets, "aws-secrets", false, "Create an AWS Secrets Manager secret per keypair instead of writing a file, with the credentials and region of the AWS CLI")
+	fs.StringVar(&o.awsSecretName, "aws-secret-name", awsSecretNameTemplate, "With -aws-secrets, Go template of the secret names over each keypair's .Index, .Type, .Address and .Extra")
+	fs.StringVar(&o.awsTagsFlag, "aws-tags", "", "With -aws-secrets, comma-separated key=value tags of the secrets")
+	fs.StringVar(&o.gcpProject, "gcp-project", "", "Create a GCP Secret Manager secret per keypair in this project instead of writing a file, with Application Default Credentials")
+	fs.StringVar(&o.gcpSecretID, "gcp-secret-id", gcpSecretIDTemplate, "With -gcp-project, Go template of the secret IDs over each keypair's .Index, .Type, .Address and .Extra")
+	fs.StringVar(&o.gcpLabelsFlag, "gcp-labels", "", "With -gcp-project, comma-separated key=value labels of the secrets, besides chain and batch")
+	fs.StringVar(&o.azureVault, "azure-vault", "", "Set an Azure Key Vault secret per keypair in this vault, a name or URL, instead of writing a file, with a managed identity or AZURE_* credentials")
+	fs.StringVar(&o.azureSecretName, "azure-secret-name", azureSecretNameTemplate, "With -azure-vault, Go template of the secret names over each keypair's .Index, .Type, .Address and .Extra")
+	fs.StringVar(&o.azureTagsFlag, "azure-tags", "", "With -azure-vault, comma-separated key=value tags of the secrets, besides keyType and index")
+	fs.StringVar(&o.opVaultFlag, "op-vault", "", "Create a 1Password item per keypair in this vault instead of writing a file, through 1Password Connect or the op CLI")
+	fs.StringVar(&o.opTitle, "op-title", opTitleTemplate, "With -op-vault, Go template of the item titles over each keypair's .Index, .Type, .Address and .Extra")
+	fs.StringVar(&o.opTags, "op-tags", "", "With -op-vault, comma-separated tags of the items")
+	fs.StringVar(&o.store, "store", "", "Store the private keys in 'keyring', the platform keychain, under their addresses instead of writing a file; read them back with the export subcommand")
+	fs.StringVar(&o.postgresDSN, "postgres", "", "Insert the results into the Postgres database at this postgres://user@host/db URL instead of writing a file; the password may come from PGPASSWORD")
+	fs.BoolVar(&o.publicFile, "public-file", false, "Also write the addresses and public keys, without any secret, to a companion [results].public.[format] file to share with auditors or funding scripts")
+	fs.BoolVar(&o.publicOnly, "public-only", false, "Write only the addresses and public keys, without any secret, as the results, and discard the private keys unless -private-out is set")
+	fs.StringVar(&o.privateOut, "private-out", "", "With -public-only, also write the results with their private keys to this file, or '-' for stdout")
+	fs.StringVar(&o.insecureSeed, "insecure-seed", "", "UNSAFE, for tests only: generate keys deterministically from this seed string, so the same seed always gives the same keys")
+	return o
+}
+
+// importsMnemonic reports whether the keys are derived from an existing
+// mnemonic
+func (o *options) importsMnemonic() bool {
+	return o.fromMnemonic != "" || o.mnemonicFile != ""
+}
+
+// alwaysDerived reports whether the key type is derived from a mnemonic even
+// without -mnemonic, as its own wallets do
+func (o *options) alwaysDerived() bool {
+	return o.keyType == "eth-validator" || o.keyType == "chia" || o.keyType == "iota" || o.keyType == "shimmer"
+}
+
+// validate checks that the flags can be combined, and fills in the values
+// that follow from others: the key directory of -format=keystore, the count
+// of -end-index, the DV threshold, the default scheme, the -mnemonic implied
+// by the import flags and the path of -preset. It reads no secret and sets
+// no global, so it fails before anything is prompted for
+func (o *options) validate() error {
+	if err := o.validateOutput(); err != nil {
+		return err
+	}
+	if err := o.validateKeyType(); err != nil {
+		return err
+	}
+	return o.validateMnemonic()
+}
+
+// validateOutput checks the flags of where and how the results are written
+func (o *options) validateOutput() error {
+	if !slices.Contains(keyTypes, o.keyType) {
+		return usageError{fmt.Errorf("Key type must be %s", quoteList(keyTypes))}
+	}
+
+	if !slices.Contains(outputFormats, o.format) && !slices.Contains(keyFileFormats, o.format) {
+		return fmt.Errorf("Format must be %s", quoteList(append(slices.Clone(outputFormats), keyFileFormats...)))
+	}
+
+	if slices.Contains(keyFileFormats, o.format) && (o.output != "" || o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "") {
+		return fmt.Errorf("Format %s cannot be combined with -output, -encrypt or -encrypt-*", o.format)
+	}
+
+	// The keystore format writes Web3 Secret Storage files in place of the
+	// results, so nothing is left unencrypted on disk
+	if o.format == "keystore" {
+		if o.keyType != "evm" {
+			return errors.New("Format keystore requires -type=evm")
+		}
+		if o.keyDir == "" {
+			o.keyDir = "keystore"
+		}
+	}
+
+	if o.envPrefixFlag != "" {
+		if o.format != "env" && o.format != "k8s-secret" {
+			return errors.New("-env-prefix requires -format=env or k8s-secret")
+		}
+		if !validEnvName(o.envPrefixFlag) {
+			return errors.New("-env-prefix must be letters, digits and underscores, not starting with a digit")
+		}
+	}
+
+	if o.format == "k8s-secret" {
+		// Sealed values are bound to the namespace and name
+		if o.k8sSealCert != "" && o.k8sNamespaceFlag == "" {
+			return errors.New("-k8s-seal-cert requires -k8s-namespace")
+		}
+		// Neither form has variables for the shares of the batch
+		if o.slip39 != "" {
+			return errors.New("Format k8s-secret does not keep new SLIP-39 shares")
+		}
+		// Secrets of single keys hold no batch-wide secrets
+		if o.k8sPerKeyFlag && o.useMnemonic {
+			return errors.New("-k8s-per-key does not keep a new shared mnemonic; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.k8sNamespaceFlag != "" || o.k8sNameFlag != "" || o.k8sPerKeyFlag || o.k8sSealCert != "" {
+		return errors.New("-k8s-namespace, -k8s-name, -k8s-per-key and -k8s-seal-cert require -format=k8s-secret")
+	}
+
+	if o.format == "solana-json" && o.keyType != "solana" {
+		return errors.New("Format solana-json requires -type=solana")
+	}
+
+	// Fail before a long run rather than once the keys are generated
+	for _, path := range []string{o.output, o.privateOut} {
+		if path == "" || path == "-" || isBucketOutput(path) || strings.HasPrefix(path, sqliteOutputPrefix) || *o.files.force {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", path)
+		}
+	}
+
+	if o.archive != "" {
+		if !validArchivePath(o.archive) {
+			return fmt.Errorf("-archive must end in %s", quoteList(archiveFormats))
+		}
+		if o.output == "-" || isBucketOutput(o.output) || strings.HasPrefix(o.output, sqliteOutputPrefix) || o.postgresDSN != "" {
+			return errors.New("-archive cannot be combined with -output -, sqlite:, s3://, gs:// or -postgres")
+		}
+		// -archive removes what it bundles, so it only bundles files the run
+		// creates itself
+		if *o.files.force {
+			return errors.New("-archive cannot be combined with -force")
+		}
+		for _, path := range []string{o.output, o.privateOut} {
+			if info, err := os.Stat(path); path != "" && path != "-" && err == nil && !info.Mode().IsRegular() {
+				return fmt.Errorf("-archive cannot be combined with %s, which is not a regular file", path)
+			}
+		}
+	}
+
+	if isBucketOutput(o.output) {
+		if o.sse != "" && !strings.HasPrefix(o.output, s3OutputPrefix) {
+			return errors.New("-sse requires -output s3://")
+		}
+		if o.sse != "" && !slices.Contains(s3SSEAlgorithms, o.sse) {
+			return fmt.Errorf("-sse must be %s", quoteList(s3SSEAlgorithms))
+		}
+		// S3 only takes a KMS key for KMS encryption
+		if o.sse == "AES256" && o.sseKMSKey != "" {
+			return errors.New("-sse-kms-key requires -sse=aws:kms or aws:kms:dsse")
+		}
+	} else if o.sse != "" || o.sseKMSKey != "" {
+		return errors.New("-sse and -sse-kms-key require -output s3:// or gs://")
+	}
+
+	if o.splitFiles {
+		if o.output != "" || o.postgresDSN != "" || o.vaultPath != "" || o.format == "ndjson" || slices.Contains(keyFileFormats, o.format) {
+			return errors.New("-split-files cannot be combined with -output, -postgres, -vault-path or -format=" + o.format)
+		}
+		if !slices.Contains(splitFileNames, o.splitName) {
+			return fmt.Errorf("-split-name must be %s", quoteList(splitFileNames))
+		}
+		// Files of single keys hold no batch-wide secrets
+		if o.useMnemonic || o.slip39 != "" {
+			return errors.New("-split-files does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.outputDir != "" {
+		return errors.New("-output-dir requires -split-files")
+	}
+
+	// These formats hold only the keys
+	if (slices.Contains(keyFileFormats, o.format) || o.format == "solana-json") && (o.useMnemonic || o.mnemonicPerKey || o.slip39 != "") {
+		return fmt.Errorf("Format %s does not keep new mnemonics or SLIP-39 shares", o.format)
+	}
+
+	// The ndjson format is written line by line, before the whole batch
+	// could be encrypted
+	if o.format == "ndjson" {
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return errors.New("Format ndjson cannot be combined with -encrypt or -encrypt-*")
+		}
+		if o.slip39 != "" {
+			return errors.New("Format ndjson does not keep new SLIP-39 shares")
+		}
+	}
+
+	// Neither a csv row nor a variable has a place for the shares of the batch
+	if (o.format == "csv" || o.format == "env") && o.slip39 != "" {
+		return fmt.Errorf("Format %s does not keep new SLIP-39 shares", o.format)
+	}
+
+	if o.templateFile != "" && (o.format != "json" || o.postgresDSN != "" || o.vaultPath != "" || strings.HasPrefix(o.output, sqliteOutputPrefix)) {
+		return errors.New("-template cannot be combined with -format, -postgres, -vault-path or -output sqlite:")
+	}
+
+	// A database holds the results in its own tables
+	if o.postgresDSN != "" {
+		if o.output != "" || o.format != "json" {
+			return errors.New("-postgres cannot be combined with -output or -format")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return errors.New("-postgres cannot be combined with -encrypt or -encrypt-*")
+		}
+	}
+
+	if o.vaultPath != "" {
+		if o.output != "" || o.format != "json" || o.postgresDSN != "" {
+			return errors.New("-vault-path cannot be combined with -output, -format or -postgres")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return errors.New("-vault-path cannot be combined with -encrypt or -encrypt-*")
+		}
+		// Secrets of single keys hold no batch-wide secrets
+		if o.useMnemonic || o.slip39 != "" {
+			return errors.New("-vault-path does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.vaultAddr != "" {
+		return errors.New("-vault-addr requires -vault-path")
+	}
+
+	if o.awsSecrets {
+		if o.output != "" || o.format != "json" || o.postgresDSN != "" || o.vaultPath != "" || o.splitFiles || o.templateFile != "" {
+			return errors.New("-aws-secrets cannot be combined with -output, -format, -postgres, -vault-path, -split-files or -template")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return errors.New("-aws-secrets cannot be combined with -encrypt, -encrypt-age* or -encrypt-pgp")
+		}
+		if o.useMnemonic || o.slip39 != "" {
+			return errors.New("-aws-secrets does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.awsTagsFlag != "" || o.awsSecretName != awsSecretNameTemplate {
+		return errors.New("-aws-tags and -aws-secret-name require -aws-secrets")
+	}
+
+	if o.gcpProject != "" {
+		if o.output != "" || o.format != "json" || o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.splitFiles || o.templateFile != "" {
+			return errors.New("-gcp-project cannot be combined with -output, -format, -postgres, -vault-path, -aws-secrets, -split-files or -template")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" || o.encryptKMS != "" {
+			return errors.New("-gcp-project cannot be combined with -encrypt or -encrypt-*")
+		}
+		if o.useMnemonic || o.slip39 != "" {
+			return errors.New("-gcp-project does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.gcpLabelsFlag != "" || o.gcpSecretID != gcpSecretIDTemplate {
+		return errors.New("-gcp-labels and -gcp-secret-id require -gcp-project")
+	}
+
+	if o.azureVault != "" {
+		if o.output != "" || o.format != "json" || o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.gcpProject != "" || o.splitFiles || o.templateFile != "" {
+			return errors.New("-azure-vault cannot be combined with -output, -format, -postgres, -vault-path, -aws-secrets, -gcp-project, -split-files or -template")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" || o.encryptKMS != "" {
+			return errors.New("-azure-vault cannot be combined with -encrypt or -encrypt-*")
+		}
+		if o.useMnemonic || o.slip39 != "" {
+			return errors.New("-azure-vault does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.azureTagsFlag != "" || o.azureSecretName != azureSecretNameTemplate {
+		return errors.New("-azure-tags and -azure-secret-name require -azure-vault")
+	}
+
+	if o.opVaultFlag != "" {
+		if o.output != "" || o.format != "json" || o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.gcpProject != "" || o.azureVault != "" || o.splitFiles || o.templateFile != "" {
+			return errors.New("-op-vault cannot be combined with -output, -format, -postgres, -vault-path, -aws-secrets, -gcp-project, -azure-vault, -split-files or -template")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" || o.encryptKMS != "" {
+			return errors.New("-op-vault cannot be combined with -encrypt or -encrypt-*")
+		}
+		if o.useMnemonic || o.slip39 != "" {
+			return errors.New("-op-vault does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic")
+		}
+	} else if o.opTags != "" || o.opTitle != opTitleTemplate {
+		return errors.New("-op-tags and -op-title require -op-vault")
+	}
+
+	if o.store != "" {
+		if !slices.Contains(storeBackends, o.store) {
+			return fmt.Errorf("-store must be %s", quoteList(storeBackends))
+		}
+		if o.output != "" || o.format != "json" || o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.gcpProject != "" || o.azureVault != "" || o.opVaultFlag != "" || o.splitFiles || o.templateFile != "" {
+			return errors.New("-store cannot be combined with -output, -format, -postgres, -vault-path, -aws-secrets, -gcp-project, -azure-vault, -op-vault, -split-files or -template")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" || o.encryptKMS != "" {
+			return errors.New("-store cannot be combined with -encrypt or -encrypt-*")
+		}
+		// The keychain only holds the private keys
+		if o.useMnemonic || o.mnemonicPerKey || o.slip39 != "" {
+			return errors.New("-store does not keep new mnemonics or SLIP-39 shares; use -from-mnemonic")
+		}
+	}
+
+	// SOPS encrypts the values of the results file to age and KMS keys at
+	// once, instead of the whole file
+	if o.sops {
+		if !slices.Contains(sopsFormats, o.format) || o.templateFile != "" {
+			return fmt.Errorf("-sops requires -format=%s", strings.Join(sopsFormats, " or "))
+		}
+		if o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.gcpProject != "" || o.azureVault != "" || o.opVaultFlag != "" || o.store != "" || strings.HasPrefix(o.output, sqliteOutputPrefix) {
+			return errors.New("-sops cannot be combined with -postgres, -vault-path, -aws-secrets, -gcp-project, -azure-vault, -op-vault, -store or -output sqlite:")
+		}
+		if o.encryptPassword || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return errors.New("-sops encrypts to -encrypt-age recipients and -encrypt-kms keys only")
+		}
+	}
+
+	// Compression applies to the results file, before any encryption, as
+	// ciphertext does not compress
+	if o.compression != "" {
+		if !slices.Contains(compressions, o.compression) {
+			return fmt.Errorf("-compress must be %s", quoteList(compressions))
+		}
+		if slices.Contains(keyFileFormats, o.format) || o.sops {
+			return fmt.Errorf("-compress cannot be combined with -sops or -format=%s", o.format)
+		}
+		if o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.gcpProject != "" || o.azureVault != "" || o.opVaultFlag != "" || o.store != "" || strings.HasPrefix(o.output, sqliteOutputPrefix) {
+			return errors.New("-compress cannot be combined with -postgres, -vault-path, -aws-secrets, -gcp-project, -azure-vault, -op-vault, -store or -output sqlite:")
+		}
+	}
+
+	// KMS envelope encryption seals the whole results file
+	if o.encryptKMS != "" && !o.awsSecrets && !o.sops {
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return errors.New("-encrypt-kms cannot be combined with password, age or OpenPGP encryption")
+		}
+		if o.postgresDSN != "" || o.vaultPath != "" || strings.HasPrefix(o.output, sqliteOutputPrefix) || o.format == "ndjson" || slices.Contains(keyFileFormats, o.format) {
+			return errors.New("-encrypt-kms cannot be combined with -postgres, -vault-path, -output sqlite: or the ndjson, keystore and paper formats")
+		}
+	}
+
+	if path, ok := strings.CutPrefix(o.output, sqliteOutputPrefix); ok {
+		if o.format != "json" {
+			return fmt.Errorf("-output %s cannot be combined with -format=%s", o.output, o.format)
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" {
+			return fmt.Errorf("-output %s cannot be combined with -encrypt or -encrypt-*", o.output)
+		}
+		if path == "" {
+			return errors.New("-output sqlite: requires a database path, e.g. sqlite:keys.db")
+		}
+	}
+
+	if o.insecureSeed != "" {
+		// snarkos draws its own randomness, which the seed cannot replace
+		if o.keyType == "aleo" {
+			return errors.New("-insecure-seed cannot be combined with -type=aleo, whose accounts snarkos creates")
+		}
+		// The keys of these formats could not be told from real ones
+		if o.format == "solana-json" || slices.Contains(keyFileFormats, o.format) {
+			return fmt.Errorf("-insecure-seed cannot be combined with -format=%s, which has no place to mark the keys insecure", o.format)
+		}
+	}
+
+	if o.encryptAge != "" && o.encryptAgePassphrase {
+		return errors.New("age files are encrypted either to recipients or to a passphrase")
+	}
+	if o.encryptPassword && (o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "") {
+		return errors.New("-encrypt cannot be combined with age or OpenPGP encryption")
+	}
+	if o.encryptPGP != "" && (o.encryptAge != "" || o.encryptAgePassphrase) {
+		return errors.New("-encrypt-pgp cannot be combined with age encryption")
+	}
+
+	return nil
+}
+
+// validateKeyType checks the flags of the key type and its chain
+func (o *options) validateKeyType() error {
+	if o.endIndex >= 0 {
+		if o.endIndex < o.startIndex {
+			return errors.New("End index must not be below the start index")
+		}
+		o.count = o.endIndex - o.startIndex + 1
+	}
+
+	if o.count <= 0 {
+		return usageError{errors.New("Count must be greater than 0")}
+	}
+
+	if _, ok := bitcoinNetworks[o.network]; o.keyType == "bitcoin" && !ok {
+		return usageError{errors.New("Network must be 'mainnet', 'testnet', 'signet', or 'regtest'")}
+	}
+
+	_, isUTXO := utxoNetworks[o.keyType]
+	if (o.keyType == "ton" || o.keyType == "hyperliquid" || o.keyType == "cardano" || o.keyType == "filecoin" || o.keyType == "zcash" || o.keyType == "kaspa" || o.keyType == "bch" || o.keyType == "chia" || o.keyType == "ckb" || o.keyType == "iota" || o.keyType == "shimmer" || isUTXO) && o.network != "mainnet" && o.network != "testnet" {
+		return usageError{fmt.Errorf("Network must be 'mainnet' or 'testnet' for %s", o.keyType)}
+	}
+
+	if _, ok := moneroNetworkBytes[o.network]; o.keyType == "monero" && !ok {
+		return usageError{errors.New("Network must be 'mainnet', 'testnet', or 'stagenet' for monero")}
+	}
+
+	if _, ok := ethValidatorForkVersions[o.network]; o.keyType == "eth-validator" && !ok {
+		return usageError{errors.New("Network must be 'mainnet', 'sepolia', 'holesky', or 'hoodi' for eth-validator")}
+	}
+
+	if o.withdrawalAddress != "" && (o.keyType != "eth-validator" || !common.IsHexAddress(o.withdrawalAddress)) {
+		return usageError{errors.New("Withdrawal address requires -type=eth-validator and a 0x execution address")}
+	}
+
+	if o.dvOperators != 0 || o.dvThreshold != 0 {
+		if o.keyType != "eth-validator" || o.dvOperators < 2 {
+			return usageError{errors.New("Distributed validators require -type=eth-validator and at least 2 operators")}
+		}
+		if o.dvThreshold == 0 {
+			o.dvThreshold = defaultDVThreshold(o.dvOperators)
+		}
+		if o.dvThreshold < 1 || o.dvThreshold > o.dvOperators {
+			return usageError{errors.New("DV threshold must be between 1 and the number of operators")}
+		}
+	}
+
+	if o.qrPrivate && !o.qr {
+		return errors.New("-qr-private requires -qr")
+	}
+
+	// The results of -public-only are meant to be shared, so nothing that
+	// writes or stores secrets can be combined with it
+	if o.publicOnly {
+		if !slices.Contains(publicFormats, o.format) && o.format != "ndjson" {
+			return fmt.Errorf("-public-only requires -format=%s or ndjson", strings.Join(publicFormats, ", "))
+		}
+		if o.postgresDSN != "" || o.vaultPath != "" || o.awsSecrets || o.gcpProject != "" || o.azureVault != "" || o.opVaultFlag != "" || o.store != "" || strings.HasPrefix(o.output, sqliteOutputPrefix) {
+			return errors.New("-public-only cannot be combined with -postgres, -vault-path, -aws-secrets, -gcp-project, -azure-vault, -op-vault, -store or -output sqlite:")
+		}
+		if o.keyDir != "" || o.suiKeystore != "" || o.qrPrivate || o.splitFiles || o.templateFile != "" || o.publicFile {
+			return errors.New("-public-only cannot be combined with -key-dir, -sui-keystore, -qr-private, -split-files, -template or -public-file")
+		}
+		if o.encryptPassword || o.encryptAge != "" || o.encryptAgePassphrase || o.encryptPGP != "" || o.encryptKMS != "" || o.sops {
+			return errors.New("-public-only writes no secret to encrypt; pipe -private-out=- into an encryption tool instead")
+		}
+		if o.privateOut != "" && (o.privateOut == o.output || isBucketOutput(o.privateOut) || strings.HasPrefix(o.privateOut, sqliteOutputPrefix)) {
+			return errors.New("-private-out must be a file other than -output, or '-' for stdout")
+		}
+	} else if o.privateOut != "" {
+		return errors.New("-private-out requires -public-only")
+	}
+
+	if o.suiKeystore != "" && o.keyType != "sui" {
+		return usageError{errors.New("Sui keystore requires -type=sui")}
+	}
+
+	if o.kdf != "scrypt" && (o.keyType != "eth-validator" || !slices.Contains(eip2335KDFs, o.kdf)) {
+		return usageError{fmt.Errorf("KDF must be %s, and requires -type=eth-validator", quoteList(eip2335KDFs))}
+	}
+
+	if (o.subaddressAccount != 0 || o.subaddressCount != 0) && o.keyType != "monero" {
+		return usageError{errors.New("Subaddresses require -type=monero")}
+	}
+	if o.subaddressAccount > math.MaxUint32 || o.subaddressCount > math.MaxUint32 {
+		return usageError{errors.New("Subaddress account and count must fit in 32 bits")}
+	}
+
+	if o.keyType == "ton" {
+		if !slices.Contains(tonWalletVersions, o.walletVersion) {
+			return usageError{fmt.Errorf("Wallet version must be %s", quoteList(tonWalletVersions))}
+		}
+		if o.workchain < -128 || o.workchain > 127 {
+			return usageError{errors.New("Workchain must fit in a signed byte")}
+		}
+	}
+
+	if schemes, ok := keySchemes[o.keyType]; ok {
+		if o.scheme == "" {
+			o.scheme = schemes[0]
+		}
+		if !slices.Contains(schemes, o.scheme) {
+			return usageError{fmt.Errorf("Scheme must be %s for %s", quoteList(schemes), o.keyType)}
+		}
+	} else if o.scheme != "" {
+		return usageError{fmt.Errorf("Scheme is not supported for %s", o.keyType)}
+	}
+
+	if o.keyType == "substrate" {
+		if o.ss58Prefix < 0 || o.ss58Prefix > maxSS58Prefix {
+			return usageError{fmt.Errorf("SS58 prefix must be between 0 and %d", maxSS58Prefix)}
+		}
+	}
+
+	if o.altEncoding != "" {
+		if o.keyType != "evm" {
+			return usageError{errors.New("Alt encoding requires -type=evm")}
+		}
+		if altEncodings := slices.Sorted(maps.Keys(evmAltEncodings)); !slices.Contains(altEncodings, o.altEncoding) {
+			return usageError{fmt.Errorf("Alt encoding must be %s", quoteList(altEncodings))}
+		}
+	}
+
+	if (o.keyType == "cosmos" || o.keyType == "eth-cosmos" || o.keyType == "cometbft") && o.hrp == "" {
+		return usageError{errors.New("HRP must not be empty")}
+	}
+
+	if o.accountPreset != "" || o.classHash != "" {
+		if o.keyType != "starknet" || (o.accountPreset != "" && o.classHash != "") {
+			return usageError{errors.New("Account addresses require -type=starknet and either -account-preset or -class-hash")}
+		}
+		if _, ok := starknetAccountPresets[o.accountPreset]; o.accountPreset != "" && !ok {
+			return usageError{fmt.Errorf("Account preset must be %s", quoteList(slices.Sorted(maps.Keys(starknetAccountPresets))))}
+		}
+	}
+
+	if (o.agentName != "" || o.masterKeyFile != "") && o.keyType != "hyperliquid" {
+		return usageError{errors.New("Agent name and master key require -type=hyperliquid")}
+	}
+
+	if o.nodeKey && o.keyType != "cometbft" {
+		return usageError{errors.New("Node keys require -type=cometbft")}
+	}
+
+	if o.keyType == "geth-nodekey" && (o.enodeHost == "" || o.enodePort <= 0 || o.enodePort > math.MaxUint16) {
+		return usageError{errors.New("Enode host must not be empty and port must be between 1 and 65535")}
+	}
+
+	if o.multisig != 0 && (o.keyType != "bitcoin" || o.multisig < 0 || o.multisig > o.count) {
+		return usageError{errors.New("Multisig requires -type=bitcoin and a threshold between 1 and count")}
+	}
+
+	if _, ok := keyFileWriters[o.keyType]; o.keyDir != "" && !ok {
+		return usageError{fmt.Errorf("Key files are not supported for %s", o.keyType)}
+	}
+
+	if o.passwordFile != "" && !o.encryptPassword && !o.encryptAgePassphrase && o.archive == "" && (o.keyDir == "" || !slices.Contains(encryptedKeyFiles, o.keyType)) {
+		return usageError{fmt.Errorf("Password file requires -encrypt, -encrypt-age-passphrase, -archive or -key-dir with %s", quoteList(encryptedKeyFiles))}
+	}
+
+	return nil
+}
+
+// validateMnemonic checks the flags of the mnemonic, seed or SLIP-39 shares
+// the keys are derived from, and of their derivation path
+func (o *options) validateMnemonic() error {
+	if o.fromMnemonic != "" && o.mnemonicFile != "" {
+		return errors.New("-from-mnemonic and -mnemonic-file are mutually exclusive")
+	}
+	if o.importsMnemonic() {
+		o.useMnemonic = true
+	}
+
+	if o.slip39 != "" || o.slip39File != "" {
+		if o.slip39 != "" && o.slip39File != "" {
+			return errors.New("-slip39 and -slip39-file are mutually exclusive")
+		}
+		if o.importsMnemonic() || o.mnemonicPerKey || o.wordlist != "english" {
+			return errors.New("SLIP-39 shares cannot be combined with BIP39 mnemonic flags")
+		}
+		if _, ok := mnemonicDerivers[o.keyType]; !ok {
+			return fmt.Errorf("SLIP-39 derivation is not supported for %s", o.keyType)
+		}
+		o.useMnemonic = true
+	}
+
+	if o.fromSeed != "" {
+		if o.importsMnemonic() || o.slip39 != "" || o.slip39File != "" || o.mnemonicPerKey {
+			return errors.New("-from-seed cannot be combined with mnemonic or SLIP-39 flags")
+		}
+		if o.wordlist != "english" || o.usePassphrase || o.passphraseFile != "" || o.passphraseEnv != "" {
+			return errors.New("A raw seed has no mnemonic wordlist or passphrase")
+		}
+		o.useMnemonic = true
+	}
+
+	if o.electrum {
+		if o.keyType != "bitcoin" {
+			return errors.New("Electrum seeds are only supported for bitcoin")
+		}
+		if o.slip39 != "" || o.slip39File != "" || o.fromSeed != "" || o.mnemonicPerKey || o.exportXpub || o.wordlist != "english" || o.words != defaultMnemonicWords {
+			return errors.New("-electrum cannot be combined with SLIP-39, -from-seed, -mnemonic-per-key, -xpub, -wordlist or -words")
+		}
+		o.useMnemonic = true
+	}
+
+	if _, ok := mnemonicDerivers[o.keyType]; o.useMnemonic && !ok && !o.alwaysDerived() {
+		return usageError{fmt.Errorf("Mnemonic derivation is not supported for %s", o.keyType)}
+	}
+
+	if o.startIndex != 0 || o.endIndex >= 0 {
+		if !o.useMnemonic && !o.alwaysDerived() {
+			return errors.New("-start-index and -end-index require -mnemonic, -from-mnemonic or -mnemonic-file")
+		}
+		// Hardened path segments leave 31 bits for the index
+		if o.startIndex < 0 || int64(o.startIndex)+int64(o.count) > hardenedOffset {
+			return errors.New("Account indices must be between 0 and 2^31-1")
+		}
+	}
+
+	if !slices.Contains(mnemonicWordCounts, o.words) {
+		return errors.New("Words must be 12, 15, 18, 21 or 24")
+	}
+	if o.words != defaultMnemonicWords && (o.importsMnemonic() || o.slip39File != "" || o.fromSeed != "" || !o.useMnemonic && !o.mnemonicPerKey && !o.alwaysDerived()) {
+		return errors.New("-words only applies to new mnemonics of -mnemonic, -mnemonic-per-key or the always derived key types")
+	}
+
+	if o.wordlist != "english" {
+		if !o.useMnemonic && !o.mnemonicPerKey && !o.alwaysDerived() {
+			return errors.New("-wordlist requires -mnemonic, -from-mnemonic, -mnemonic-file or -mnemonic-per-key")
+		}
+		if o.keyType == "chia" || o.keyType == "iota" || o.keyType == "shimmer" {
+			return fmt.Errorf("%s wallets only accept English mnemonics", o.keyType)
+		}
+	}
+
+	if o.usePassphrase || o.passphraseFile != "" || o.passphraseEnv != "" {
+		if !o.useMnemonic && !o.mnemonicPerKey && !o.alwaysDerived() {
+			return errors.New("A BIP39 passphrase requires -mnemonic, -from-mnemonic, -mnemonic-file or -mnemonic-per-key")
+		}
+		// Their wallets always derive the seed without a passphrase
+		if o.keyType == "chia" || o.keyType == "iota" || o.keyType == "shimmer" {
+			return fmt.Errorf("%s wallets do not support BIP39 passphrases", o.keyType)
+		}
+	}
+
+	_, derived := mnemonicDerivers[o.keyType]
+	if o.mnemonicPerKey {
+		if o.useMnemonic {
+			return errors.New("-mnemonic-per-key cannot be combined with -mnemonic, -from-mnemonic or -mnemonic-file")
+		}
+		if !derived {
+			return fmt.Errorf("Mnemonic derivation is not supported for %s", o.keyType)
+		}
+	}
+	if o.preset != "" {
+		presetPaths, ok := walletPresets[o.preset]
+		if !ok {
+			return fmt.Errorf("Preset must be %s", quoteList(slices.Sorted(maps.Keys(walletPresets))))
+		}
+		if o.pathTemplate != "" || o.electrum {
+			return errors.New("-preset cannot be combined with -path or -electrum")
+		}
+		if !o.useMnemonic && !o.mnemonicPerKey {
+			return errors.New("-preset requires a mnemonic, SLIP-39 shares or -from-seed")
+		}
+		if o.pathTemplate = presetPaths[o.keyType]; o.pathTemplate == "" {
+			return fmt.Errorf("The %s preset has no %s accounts", o.preset, o.keyType)
+		}
+		// Sui wallets derive the other schemes at their own purposes, and
+		// bitcoin wallets use coin type 1 off mainnet
+		if o.keyType == "sui" && o.scheme != "ed25519" {
+			o.pathTemplate = ""
+		}
+		if o.keyType == "bitcoin" && o.network != "mainnet" {
+			o.pathTemplate = strings.Replace(o.pathTemplate, "m/84'/0'", "m/84'/1'", 1)
+		}
+	}
+	if o.hardenAll && o.pathTemplate == "" {
+		return errors.New("-harden-all requires -path or -preset")
+	}
+	if o.pathTemplate != "" {
+		if !derived || !(o.useMnemonic || o.mnemonicPerKey) {
+			return errors.New("-path requires -mnemonic, -from-mnemonic, -mnemonic-file or -mnemonic-per-key with a key type they support")
+		}
+	}
+
+	return nil
+}
+
+// runConfig is a validated run: its options, and what prepare parsed, read
+// and prompted for from them
+type runConfig struct {
+	*options
+	// resultOut is where -output - and -private-out - write
+	resultOut io.Writer
+	// random is what the keys, mnemonics and shares are generated from:
+	// crypto/rand, or the keystream of -insecure-seed
+	random io.Reader
+
+	tmpl             *template.Template
+	awsSecretNames   *template.Template
+	awsTags          []smtypes.Tag
+	gcpSecretIDs     *template.Template
+	gcpLabels        map[string]string
+	azureSecretNames *template.Template
+	azureTags        map[string]string
+	opTitles         *template.Template
+	sopsKeys         sopsRecipients
+	sqlitePath       string
+	sqliteOutput     bool
+
+	btcParams  *chaincfg.Params
+	utxoParams map[string]*chaincfg.Params
+	isUTXO     bool
+	masterKey  *ecdsa.PrivateKey
+
+	ageRecipients      []age.Recipient
+	agePassphrase      string
+	pgpRecipients      openpgp.EntityList
+	encryptionPassword string
+	// password encrypts the -key-dir files, and archivePassword the -archive
+	password        string
+	archivePassword string
+	// kmsKey is the data key of -encrypt-kms, generated with the first file
+	kmsKey kmsDataKey
+
+	slip39Threshold, slip39Count int
+	passphrase                   string
+	derivation                   derivationOptions
+	deriver                      mnemonicDeriver
+	derived                      bool
+}
+
+// prepare sets the globals of the flags, parses their templates, recipients
+// and paths, and reads the passwords and passphrase of a validated run
+func (o *options) prepare() (*runConfig, error) {
+	c := &runConfig{options: o}
+
+	if err := o.files.apply(); err != nil {
+		return nil, err
+	}
+	if o.envPrefixFlag != "" {
+		envPrefix = o.envPrefixFlag
+	}
+	if o.format == "k8s-secret" {
+		k8sNamespace = o.k8sNamespaceFlag
+		k8sPerKey = o.k8sPerKeyFlag
+		name := o.k8sNameFlag
+		if name == "" {
+			name = k8sBatchNameTemplate
+			if k8sPerKey {
+				name = k8sKeyNameTemplate
+			}
+		}
+		var err error
+		if k8sName, err = template.New("k8s-name").Funcs(templateFuncs).Option("missingkey=error").Parse(name); err != nil {
+			return nil, fmt.Errorf("-k8s-name: %v", err)
+		}
+		if o.k8sSealCert != "" {
+			if k8sSealKey, err = readSealCert(o.k8sSealCert); err != nil {
+				return nil, fmt.Errorf("reading seal certificate: %v", err)
+			}
+		}
+	}
+	if isBucketOutput(o.output) {
+		uploadSSE = o.sse
+		uploadKMSKey = o.sseKMSKey
+	}
+
+	var err error
+	if o.templateFile != "" {
+		if c.tmpl, err = parseTemplate(o.templateFile); err != nil {
+			return nil, fmt.Errorf("reading template: %v", err)
+		}
+	}
+	if o.awsSecrets {
+		if c.awsSecretNames, err = parseAWSSecretName(o.awsSecretName); err != nil {
+			return nil, fmt.Errorf("-aws-secret-name: %v", err)
+		}
+		if c.awsTags, err = parseAWSTags(o.awsTagsFlag); err != nil {
+			return nil, fmt.Errorf("-aws-tags: %v", err)
+		}
+	}
+	if o.gcpProject != "" {
+		if c.gcpSecretIDs, err = parseGCPSecretID(o.gcpSecretID); err != nil {
+			return nil, fmt.Errorf("-gcp-secret-id: %v", err)
+		}
+		if c.gcpLabels, err = parseGCPLabels(o.gcpLabelsFlag); err != nil {
+			return nil, fmt.Errorf("-gcp-labels: %v", err)
+		}
+	}
+	if o.azureVault != "" {
+		if c.azureSecretNames, err = parseAzureSecretName(o.azureSecretName); err != nil {
+			return nil, fmt.Errorf("-azure-secret-name: %v", err)
+		}
+		if c.azureTags, err = parseAzureTags(o.azureTagsFlag); err != nil {
+			return nil, fmt.Errorf("-azure-tags: %v", err)
+		}
+	}
+	if o.opVaultFlag != "" {
+		if c.opTitles, err = parseOPTitle(o.opTitle); err != nil {
+			return nil, fmt.Errorf("-op-title: %v", err)
+		}
+	}
+
+	if o.sops {
+		if o.encryptAge != "" || o.encryptKMS != "" {
+			if o.encryptAge != "" {
+				c.sopsKeys.age = strings.Split(o.encryptAge, ",")
+			}
+			if o.encryptKMS != "" {
+				c.sopsKeys.kms = []string{o.encryptKMS}
+			}
+		} else {
+			// As the sops CLI does, the rule is picked by the file name
+			name := o.output
+			if name == "" || name == "-" {
+				name = resultsFilename(o.keyType, formatExtension(o.format), o.insecureSeed != "")
+			}
+			if c.sopsKeys, err = sopsRulesRecipients(name); err != nil {
+				return nil, fmt.Errorf("-sops: %v", err)
+			}
+			for _, recipient := range c.sopsKeys.age {
+				if _, err := parseAgeRecipient(recipient); err != nil {
+					return nil, fmt.Errorf("-sops: %v", err)
+				}
+			}
+		}
+	}
+
+	c.sqlitePath, c.sqliteOutput = strings.CutPrefix(o.output, sqliteOutputPrefix)
+	c.random = rand.Reader
+	if o.insecureSeed != "" {
+		if c.random, err = newInsecureReader(o.insecureSeed); err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(os.Stderr, insecureSeedWarning)
+	}
+	c.btcParams = bitcoinNetworks[o.network]
+	c.utxoParams, c.isUTXO = utxoNetworks[o.keyType]
+	eip2335KDF = o.kdf
+
+	if o.accountPreset != "" || o.classHash != "" {
+		classHash, calldata := o.classHash, strings.Split(o.constructorCalldata, ",")
+		if o.accountPreset != "" {
+			preset := starknetAccountPresets[o.accountPreset]
+			classHash, calldata = preset.classHash, preset.calldata
+		}
+		if starknetAddressHook, err = starknetAccountAddressFunc(classHash, calldata); err != nil {
+			return nil, usageError{fmt.Errorf("Invalid account class: %v", err)}
+		}
+	}
+
+	if o.masterKeyFile != "" {
+		if c.masterKey, err = readHyperliquidMasterKey(o.masterKeyFile); err != nil {
+			return nil, fmt.Errorf("reading master key: %v", err)
+		}
+	}
+
+	if o.encryptAge != "" {
+		for _, recipient := range strings.Split(o.encryptAge, ",") {
+			key, err := parseAgeRecipient(recipient)
+			if err != nil {
+				return nil, err
+			}
+			c.ageRecipients = append(c.ageRecipients, key)
+		}
+	}
+	if o.encryptPGP != "" {
+		if c.pgpRecipients, err = readPGPRecipients(strings.Split(o.encryptPGP, ",")); err != nil {
+			return nil, err
+		}
+	}
+	if o.encryptPassword {
+		if c.encryptionPassword, err = readPassword(o.passwordFile); err != nil {
+			return nil, fmt.Errorf("reading password: %v", err)
+		}
+	}
+	if o.encryptAgePassphrase {
+		if c.agePassphrase, err = readPassword(o.passwordFile); err != nil {
+			return nil, fmt.Errorf("reading password: %v", err)
+		}
+	}
+	if o.keyDir != "" && slices.Contains(encryptedKeyFiles, o.keyType) {
+		if c.password, err = readPassword(o.passwordFile); err != nil {
+			return nil, fmt.Errorf("reading password: %v", err)
+		}
+	}
+	if o.archive != "" {
+		if c.archivePassword, err = readPassword(o.passwordFile); err != nil {
+			return nil, fmt.Errorf("reading password: %v", err)
+		}
+	}
+
+	if o.slip39 != "" {
+		if c.slip39Threshold, c.slip39Count, err = parseSlip39Scheme(o.slip39); err != nil {
+			return nil, err
+		}
+	}
+	if o.wordlist != "english" {
+		if err := setMnemonicWordlist(o.wordlist); err != nil {
+			return nil, err
+		}
+	}
+	if o.usePassphrase || o.passphraseFile != "" || o.passphraseEnv != "" {
+		if c.passphrase, err = readPassphrase(o.passphraseFile, o.passphraseEnv, o.usePassphrase); err != nil {
+			return nil, fmt.Errorf("reading passphrase: %v", err)
+		}
+	}
+
+	if err := c.resolveDerivation(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// resolveDerivation sets the path keys are derived at, hardened with
+// -harden-all, and warns of unhardened segments other wallets would not
+// derive at
+func (c *runConfig) resolveDerivation() error {
+	c.derivation = derivationOptions{network: c.network, hrp: c.hrp, scheme: c.scheme, passphrase: c.passphrase, words: c.words}
+	c.deriver, c.derived = mnemonicDerivers[c.keyType]
+	if c.electrum {
+		c.deriver.path = electrumPath
+	}
+	if c.pathTemplate != "" {
+		pathFunc, indexed, err := parsePathTemplate(c.pathTemplate)
+		if err != nil {
+			return err
+		}
+		if !indexed && c.count > 1 && !c.mnemonicPerKey {
+			return errors.New("-path must contain i to derive more than one keypair")
+		}
+		c.deriver.path = pathFunc
+	}
+	if c.derived && (c.useMnemonic || c.mnemonicPerKey) {
+		path, err := c.deriver.path(c.startIndex, c.derivation)
+		if err != nil {
+			return err
+		}
+		unhardened := unhardenedSegments(path)
+		switch {
+		case len(unhardened) > 0 && c.hardenAll:
+			c.deriver.path = hardenPath(c.deriver.path)
+			hardened, _ := c.deriver.path(c.startIndex, c.derivation)
+			fmt.Fprintf(os.Stderr, "Warning: Hardening segments %s of %s to %s\n", joinInts(unhardened), formatDerivationPath(path), formatDerivationPath(hardened))
+			path = hardened
+		case len(unhardened) > 0 && c.deriver.curve(c.derivation) == "ed25519":
+			return fmt.Errorf("%s keys are derived with SLIP-0010 ed25519, which requires every path segment to be hardened, but segments %s of %s are not; pass -harden-all to harden them like libraries that accept such paths do", c.keyType, joinInts(unhardened), formatDerivationPath(path))
+		case isBIP44Unhardened(path):
+			fmt.Fprintf(os.Stderr, "Warning: BIP44 wallets harden the purpose, coin type and account of their paths, but segments %s of %s are not hardened, so these keys will not match theirs\n", joinInts(slices.DeleteFunc(slices.Clone(unhardened), func(position int) bool { return position > 3 })), formatDerivationPath(path))
+		}
+		if c.pathTemplate != "" {
+			if unhardened := unhardenedSegments(path); len(unhardened) > 0 {
+				fmt.Printf("Deriving at %s: segments %s not hardened\n", formatDerivationPath(path), joinInts(unhardened))
+			} else {
+				fmt.Printf("Deriving at %s: every segment hardened\n", formatDerivationPath(path))
+			}
+		}
+	}
+	if c.exportXpub && (!c.derived || !c.useMnemonic || c.deriver.curve(c.derivation) != "secp256k1") {
+		return errors.New("-xpub requires -mnemonic, -from-mnemonic, -mnemonic-file or SLIP-39 shares with secp256k1 keys (evm, bitcoin, cosmos, tron, sui with secp256k1)")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// parseOptions parses args as the flags of a generation run
+func parseOptions(t *testing.T, args ...string) *options {
+	t.Helper()
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	o := addFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestValidateRejectsConflictingFlags(t *testing.T) {
+	for _, tt := range []struct {
+		args  []string
+		err   string
+		usage bool
+	}{
+		{[]string{"-type=nope"}, "Key type must be 'evm', 'solana', 'sui', 'bitcoin', 'cosmos', 'aptos', 'ton', 'tron', 'substrate', 'cardano', 'near', 'starknet', 'algorand', 'tezos', 'filecoin', 'litecoin', 'dogecoin', 'monero', 'zcash', 'kaspa', 'hedera', 'icp', 'sei', 'injective', 'eth-cosmos', 'bch', 'nostr', 'eth-validator', 'multiversx', 'chia', 'ckb', 'mina', 'aleo', 'iota', 'shimmer', 'casper', 'eos', 'lightning', 'cometbft', 'geth-nodekey', 'hyperliquid', or 'passkey'", true},
+		{[]string{"-type=solana", "-format=keystore"}, "Format keystore requires -type=evm", false},
+		{[]string{"-type=evm", "-count=0"}, "Count must be greater than 0", true},
+		{[]string{"-type=evm", "-end-index=2", "-start-index=5", "-mnemonic"}, "End index must not be below the start index", false},
+		{[]string{"-type=evm", "-start-index=1"}, "-start-index and -end-index require -mnemonic, -from-mnemonic or -mnemonic-file", false},
+		{[]string{"-type=bitcoin", "-network=sepolia"}, "Network must be 'mainnet', 'testnet', 'signet', or 'regtest'", true},
+		{[]string{"-type=evm", "-format=ndjson", "-encrypt"}, "Format ndjson cannot be combined with -encrypt or -encrypt-*", false},
+		{[]string{"-type=evm", "-archive=keys.zip", "-force"}, "-archive cannot be combined with -force", false},
+		{[]string{"-type=evm", "-archive=keys.rar"}, "-archive must end in '.zip', or '.tar.age'", false},
+		{[]string{"-type=evm", "-sse=AES256"}, "-sse and -sse-kms-key require -output s3:// or gs://", false},
+		{[]string{"-type=evm", "-mnemonic", "-split-files"}, "-split-files does not keep a new shared mnemonic or SLIP-39 shares; use -mnemonic-per-key or -from-mnemonic", false},
+		{[]string{"-type=evm", "-public-only", "-encrypt"}, "-public-only writes no secret to encrypt; pipe -private-out=- into an encryption tool instead", false},
+		{[]string{"-type=evm", "-private-out=-"}, "-private-out requires -public-only", false},
+		{[]string{"-type=evm", "-from-mnemonic=a", "-mnemonic-file=b"}, "-from-mnemonic and -mnemonic-file are mutually exclusive", false},
+		{[]string{"-type=evm", "-from-seed=00", "-passphrase"}, "A raw seed has no mnemonic wordlist or passphrase", false},
+		{[]string{"-type=evm", "-mnemonic", "-words=13"}, "Words must be 12, 15, 18, 21 or 24", false},
+		{[]string{"-type=evm", "-words=24"}, "-words only applies to new mnemonics of -mnemonic, -mnemonic-per-key or the always derived key types", false},
+		{[]string{"-type=solana", "-mnemonic", "-preset=metamask"}, "The metamask preset has no solana accounts", false},
+		{[]string{"-type=evm", "-harden-all"}, "-harden-all requires -path or -preset", false},
+		{[]string{"-type=near", "-path=m/44'/397'/i'"}, "-path requires -mnemonic, -from-mnemonic, -mnemonic-file or -mnemonic-per-key with a key type they support", false},
+		{[]string{"-type=aleo", "-insecure-seed=x"}, "-insecure-seed cannot be combined with -type=aleo, whose accounts snarkos creates", false},
+	} {
+		err := parseOptions(t, tt.args...).validate()
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%v: validate = %v, want %s", tt.args, err, tt.err)
+			continue
+		}
+		if usage := errors.As(err, new(usageError)); usage != tt.usage {
+			t.Errorf("%v: usage error %v, want %v", tt.args, usage, tt.usage)
+		}
+	}
+}
+
+func TestValidateFillsInImpliedValues(t *testing.T) {
+	o := parseOptions(t, "-type=evm", "-format=keystore")
+	if err := o.validate(); err != nil || o.keyDir != "keystore" {
+		t.Errorf("keystore: %v, key dir %q", err, o.keyDir)
+	}
+
+	o = parseOptions(t, "-type=evm", "-from-mnemonic="+abandonMnemonic, "-start-index=3", "-end-index=7")
+	if err := o.validate(); err != nil || o.count != 5 || !o.useMnemonic {
+		t.Errorf("end index: %v, count %d, mnemonic %v", err, o.count, o.useMnemonic)
+	}
+
+	o = parseOptions(t, "-type=eth-validator", "-dv-operators=4")
+	if err := o.validate(); err != nil || o.dvThreshold != 3 {
+		t.Errorf("dv: %v, threshold %d", err, o.dvThreshold)
+	}
+
+	o = parseOptions(t, "-type=substrate")
+	if err := o.validate(); err != nil || o.scheme != "sr25519" {
+		t.Errorf("substrate: %v, scheme %q", err, o.scheme)
+	}
+
+	o = parseOptions(t, "-type=bitcoin", "-network=testnet", "-mnemonic", "-preset=ledger-live")
+	if err := o.validate(); err != nil || o.pathTemplate != "m/84'/1'/i'/0/0" {
+		t.Errorf("preset: %v, path %q", err, o.pathTemplate)
+	}
+}
+
+func TestValidateRefusesExistingOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := parseOptions(t, "-type=evm", "-output="+path).validate()
+	if err == nil || err.Error() != path+" already exists, use -force to overwrite it" {
+		t.Errorf("validate = %v, want an already exists error", err)
+	}
+	if err := parseOptions(t, "-type=evm", "-output="+path, "-force").validate(); err != nil {
+		t.Errorf("validate with -force = %v", err)
+	}
+	// Devices are written through, so they are no reason to stop
+	if err := parseOptions(t, "-type=evm", "-output="+os.DevNull).validate(); err != nil {
+		t.Errorf("validate of %s = %v", os.DevNull, err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Paper wallets are single-page A4 PDFs drawn with the standard PDF fonts,
+// which every viewer has, so no font or image is embedded
+const (
+	paperWidth  = 595
+	paperHeight = 842
+	// paperQRSide is the side of the QR codes, in points
+	paperQRSide = 160
+	// paperTextColumns is how many Courier characters fit next to a QR code
+	paperTextColumns = 48
+)
+
+// pdfString escapes s as a PDF literal string
+func pdfString(s string) string {
+	return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s) + ")"
+}
+
+// paperPage accumulates the content stream of a page
+type paperPage struct {
+	bytes.Buffer
+}
+
+// text draws s with font, one of the F1 (Helvetica), F2 (Helvetica-Bold)
+// and F3 (Courier) resources, with its baseline starting at x, y
+func (p *paperPage) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(p, "BT /%s %g Tf %g %g Td %s Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// wrapped draws s in Courier, broken into lines of paperTextColumns
+// characters, and returns the y below the last line
+func (p *paperPage) wrapped(size, x, y float64, s string) float64 {
+	for len(s) > 0 {
+		line := s[:min(len(s), paperTextColumns)]
+		s = s[len(line):]
+		p.text("F3", size, x, y, line)
+		y -= size * 1.3
+	}
+	return y
+}
+
+// qr draws value as a QR code with its bottom left corner at x, y
+func (p *paperPage) qr(x, y float64, value string) error {
+	q, err := qrEncode(value)
+	if err != nil {
+		return err
+	}
+	q.DisableBorder = true
+	modules := q.Bitmap()
+	size := len(modules)
+	module := float64(paperQRSide) / float64(size)
+	for row := 0; row < size; row++ {
+		for col := 0; col < size; col++ {
+			if modules[row][col] {
+				fmt.Fprintf(p, "%.3f %.3f %.3f %.3f re\n", x+float64(col)*module, y+float64(size-1-row)*module, module, module)
+			}
+		}
+	}
+	p.WriteString("f\n")
+	return nil
+}
+
+// dashedLine draws a dashed guide line
+func (p *paperPage) dashedLine(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(p, "q 0.5 G 0.75 w [6 4] 0 d %g %g m %g %g l S Q\n", x1, y1, x2, y2)
+}
+
+// paperPDF wraps a content stream in a single-page A4 document
+func paperPDF(content []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R /F2 5 0 R /F3 6 0 R >> >> /Contents 7 0 R >>", paperWidth, paperHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	// Every cross-reference entry is exactly 20 bytes
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// paperWalletFiles returns the writer of keyType paper wallets: a printable
+// page per key, its address and QR code on the top half and its private key
+// on the bottom half, to be cut out and folded so the private key is inside
+func paperWalletFiles(keyType string) keyFileWriter {
+	return func(index int, privateKey, publicKey string, extra map[string]string, _ string) (map[string][]byte, error) {
+		var p paperPage
+		const left, right, bottom, top, fold = 40, paperWidth - 40, 60, 760, 410
+
+		p.text("F2", 18, left, 800, fmt.Sprintf("%s paper wallet", keyType))
+		p.text("F1", 9, left, 784, fmt.Sprintf("Key %d, generated %s. Cut along the dashed border and fold along the middle line.", index, time.Now().Format("2006-01-02")))
+
+		// Cut guides: a dashed border with crop marks at its corners
+		p.dashedLine(left, bottom, right, bottom)
+		p.dashedLine(right, bottom, right, top)
+		p.dashedLine(right, top, left, top)
+		p.dashedLine(left, top, left, bottom)
+		for _, corner := range [][2]float64{{left, bottom}, {right, bottom}, {right, top}, {left, top}} {
+			fmt.Fprintf(&p, "q 0.75 w %g %g m %g %g l S %g %g m %g %g l S Q\n",
+				corner[0]-12, corner[1], corner[0]-4, corner[1], corner[0], corner[1]-12, corner[0], corner[1]-4)
+			fmt.Fprintf(&p, "q 0.75 w %g %g m %g %g l S %g %g m %g %g l S Q\n",
+				corner[0]+4, corner[1], corner[0]+12, corner[1], corner[0], corner[1]+4, corner[0], corner[1]+12)
+		}
+		// Fold guide across the whole page
+		p.dashedLine(0, fold, paperWidth, fold)
+		p.text("F1", 8, right-50, fold+4, "fold here")
+
+		// Public half
+		p.text("F2", 12, left+20, top-25, "PUBLIC ADDRESS")
+		p.text("F1", 9, left+20, top-40, "Share this to receive funds.")
+		fmt.Fprintf(&p, "q 0.6 G 1 w [3 3] 0 d %d %d 80 60 re S Q\n", right-100, top-80)
+		p.text("F1", 8, right-92, top-45, strings.ToUpper(keyType))
+		p.text("F1", 8, right-92, top-57, "logo")
+		if err := p.qr(left+20, fold+40, publicKey); err != nil {
+			return nil, err
+		}
+		p.wrapped(10, left+200, fold+190, publicKey)
+
+		// Private half
+		p.text("F2", 12, left+20, fold-30, "PRIVATE KEY - KEEP SECRET")
+		p.text("F1", 9, left+20, fold-45, "Anyone who sees this half can spend the funds. Fold it inward and store it offline.")
+		if err := p.qr(left+20, fold-225, privateKey); err != nil {
+			return nil, err
+		}
+		y := p.wrapped(10, left+200, fold-75, privateKey)
+		if path := extra["derivationPath"]; path != "" {
+			p.text("F1", 9, left+200, y-10, "Derivation path: "+path)
+		}
+
+		return map[string][]byte{fmt.Sprintf("wallet_%d.pdf", index): paperPDF(p.Bytes())}, nil
+	}
+}
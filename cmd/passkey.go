@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+)
+
+const passkeyCredentialIDSize = 16
+
+// passkeyCOSEKey returns the COSE_Key of a P-256 public key as an
+// authenticator reports it in attested credential data:
+// {1: 2 (EC2), 3: -7 (ES256), -1: 1 (P-256), -2: x, -3: y}
+func passkeyCOSEKey(x, y []byte) []byte {
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01}
+	key = append(append(key, 0x21, 0x58, 0x20), x...)
+	key = append(append(key, 0x22, 0x58, 0x20), y...)
+	return key
+}
+
+// generatePasskeyKeyPair returns a hex P-256 private key and the 0x x||y
+// public key that passkey smart accounts store as owner, with the
+// coordinates, COSE key, a random credential ID and the PKCS#8 key virtual
+// authenticators import as extras
+func generatePasskeyKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	privateKey, err := newP256Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	credentialID := make([]byte, passkeyCredentialIDSize)
+	if _, err := io.ReadFull(random, credentialID); err != nil {
+		return "", "", nil, err
+	}
+
+	// Split the 0x04||X||Y encoding into its coordinates
+	uncompressed := privateKey.PublicKey().Bytes()
+	x, y := uncompressed[1:33], uncompressed[33:]
+
+	extra := keyExtras{
+		"x":               publicExtra("0x" + hex.EncodeToString(x)),
+		"y":               publicExtra("0x" + hex.EncodeToString(y)),
+		"coseKey":         publicExtra(hex.EncodeToString(passkeyCOSEKey(x, y))),
+		"credentialId":    publicExtra(base64.RawURLEncoding.EncodeToString(credentialID)),
+		"pkcs8PrivateKey": secretExtra(base64.StdEncoding.EncodeToString(pkcs8)),
+	}
+
+	return hex.EncodeToString(privateKey.Bytes()), "0x" + hex.EncodeToString(uncompressed[1:]), extra, nil
+}
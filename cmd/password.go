@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/term"
+	"golang.org/x/text/unicode/norm"
+)
+
+// readPassword reads a password from path, ignoring a trailing newline, or
+// prompts for it twice on the terminal when path is empty
+func readPassword(path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to prompt for a password, use -password-file")
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if string(password) != string(repeated) {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	if len(password) == 0 {
+		return "", fmt.Errorf("password must not be empty")
+	}
+	return string(password), nil
+}
+
+// readExistingPassword reads a password from path, ignoring a trailing
+// newline, or prompts for it once on the terminal when path is empty, to
+// open what was encrypted with it
+func readExistingPassword(path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to prompt for a password, use -password-file")
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(password), nil
+}
+
+// readMnemonicPhrase returns the phrase given as phrase, read from path, or
+// prompted for without echo when phrase is "-"
+func readMnemonicPhrase(phrase, path string) (string, error) {
+	switch {
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	case phrase == "-":
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return "", fmt.Errorf("no terminal to prompt for a mnemonic, use -mnemonic-file")
+		}
+		fmt.Fprint(os.Stderr, "Mnemonic: ")
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	return phrase, nil
+}
+
+// readMnemonic returns the BIP39 mnemonic given as phrase, read from path, or
+// prompted for without echo when phrase is "-", and its seed under passphrase
+func readMnemonic(phrase, path, passphrase string) (string, []byte, error) {
+	phrase, err := readMnemonicPhrase(phrase, path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Wallets tolerate case, spacing and Unicode normalization differences
+	// when a phrase is typed in
+	mnemonic := strings.Join(strings.Fields(strings.ToLower(norm.NFKD.String(phrase))), " ")
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return "", nil, fmt.Errorf("invalid BIP39 mnemonic")
+	}
+	return strings.ReplaceAll(mnemonic, " ", mnemonicSeparator), mnemonicSeed(mnemonic, passphrase), nil
+}
+
+// readSeed decodes the hex BIP32 seed given as value, or prompted for
+// without echo when value is "-"
+func readSeed(value string) ([]byte, error) {
+	if value == "-" {
+		fd := int(os.Stdin.Fd())
+		if !term.IsTerminal(fd) {
+			return nil, fmt.Errorf("no terminal to prompt for a seed")
+		}
+		fmt.Fprint(os.Stderr, "Seed (hex): ")
+		data, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		value = string(data)
+	}
+
+	seed, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(value), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex seed: %w", err)
+	}
+	// BIP32 seeds are 128 to 512 bits
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed must be 16 to 64 bytes, got %d", len(seed))
+	}
+	return seed, nil
+}
+
+// readPassphrase reads a BIP39 passphrase from path, ignoring a trailing
+// newline, from the environment variable env, or prompts for it twice on the
+// terminal when prompt is set
+func readPassphrase(path, env string, prompt bool) (string, error) {
+	switch {
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case env != "":
+		passphrase, ok := os.LookupEnv(env)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", env)
+		}
+		return passphrase, nil
+	case !prompt:
+		return "", nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("no terminal to prompt for a passphrase, use -passphrase-file or -passphrase-env")
+	}
+	fmt.Fprint(os.Stderr, "BIP39 passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat BIP39 passphrase: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(passphrase) != string(repeated) {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return string(passphrase), nil
+}
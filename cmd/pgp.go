@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"os"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// pgpMessageType is the armor header of OpenPGP messages
+const pgpMessageType = "PGP MESSAGE"
+
+// readPGPRecipients reads the OpenPGP public keys in the armored or binary
+// key files at paths, as exported by gpg --export [--armor]
+func readPGPRecipients(paths []string) (openpgp.EntityList, error) {
+	var recipients openpgp.EntityList
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var entities openpgp.EntityList
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN PGP")) {
+			entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+		} else {
+			entities, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading OpenPGP key %s: %w", path, err)
+		}
+		// Keys of unsupported algorithms are skipped, and encrypting to no
+		// key at all would leave the results readable by nobody
+		if len(entities) == 0 {
+			return nil, fmt.Errorf("no supported OpenPGP key in %s", path)
+		}
+		recipients = append(recipients, entities...)
+	}
+	return recipients, nil
+}
+
+// pgpEncrypt encrypts plaintext to recipients as an ASCII-armored OpenPGP
+// message, with the literal data named name
+func pgpEncrypt(plaintext []byte, recipients openpgp.EntityList, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	armored, err := armor.Encode(&buf, pgpMessageType, nil)
+	if err != nil {
+		return nil, err
+	}
+	config := &packet.Config{DefaultCipher: packet.CipherAES256, DefaultHash: crypto.SHA256}
+	w, err := openpgp.Encrypt(armored, recipients, nil, &openpgp.FileHints{FileName: name}, config)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	if err := armored.Close(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// circomlib's Poseidon over the BN254 scalar field: x^5 S-box, 8 full rounds
+// and the partial rounds below for state widths t = 2..17
+const (
+	poseidonFullRounds = 8
+	poseidonMaxInputs  = 16
+)
+
+var poseidonPartialRounds = [poseidonMaxInputs]int{56, 57, 56, 60, 60, 63, 64, 63, 60, 66, 60, 65, 70, 60, 64, 68}
+
+// poseidonParams are the round constants and MDS matrix of one state width
+type poseidonParams struct {
+	constants []fr.Element
+	mds       [][]fr.Element
+}
+
+var (
+	poseidonParamsMu    sync.Mutex
+	poseidonParamsCache = make(map[int]*poseidonParams)
+)
+
+// grainLFSR is the Grain LFSR of the Poseidon reference parameter script,
+// from which circomlib's constants were generated
+type grainLFSR struct {
+	state [80]byte
+}
+
+// newGrainLFSR seeds the LFSR for a prime field, x^5 S-box, 254-bit elements
+// and the given width and round counts, then discards 160 bits
+func newGrainLFSR(t, fullRounds, partialRounds int) *grainLFSR {
+	g := &grainLFSR{}
+	bits := g.state[:0]
+	for _, field := range []struct{ value, width int }{{1, 2}, {0, 4}, {fr.Bits, 12}, {t, 12}, {fullRounds, 10}, {partialRounds, 10}} {
+		for i := field.width - 1; i >= 0; i-- {
+			bits = append(bits, byte(field.value>>i)&1)
+		}
+	}
+	for len(bits) < len(g.state) {
+		bits = append(bits, 1)
+	}
+	for range 160 {
+		g.clock()
+	}
+	return g
+}
+
+func (g *grainLFSR) clock() byte {
+	bit := g.state[62] ^ g.state[51] ^ g.state[38] ^ g.state[23] ^ g.state[13] ^ g.state[0]
+	copy(g.state[:], g.state[1:])
+	g.state[79] = bit
+	return bit
+}
+
+// bit returns the next output bit, keeping the second bit of each pair whose
+// first bit is 1
+func (g *grainLFSR) bit() byte {
+	for g.clock() == 0 {
+		g.clock()
+	}
+	return g.clock()
+}
+
+// bits returns the next n output bits as a big-endian integer
+func (g *grainLFSR) bits(n int) *big.Int {
+	v := new(big.Int)
+	for range n {
+		v.Lsh(v, 1)
+		v.SetBit(v, 0, uint(g.bit()))
+	}
+	return v
+}
+
+// poseidonParamsFor derives the parameters of width t the way the reference
+// script does: rejection-sampled round constants, then a Cauchy MDS matrix
+func poseidonParamsFor(t int) *poseidonParams {
+	poseidonParamsMu.Lock()
+	defer poseidonParamsMu.Unlock()
+	if p, ok := poseidonParamsCache[t]; ok {
+		return p
+	}
+
+	partialRounds := poseidonPartialRounds[t-2]
+	g := newGrainLFSR(t, poseidonFullRounds, partialRounds)
+	modulus := fr.Modulus()
+
+	p := &poseidonParams{constants: make([]fr.Element, (poseidonFullRounds+partialRounds)*t)}
+	for i := range p.constants {
+		v := g.bits(fr.Bits)
+		for v.Cmp(modulus) >= 0 {
+			v = g.bits(fr.Bits)
+		}
+		p.constants[i].SetBigInt(v)
+	}
+
+	xs := make([]fr.Element, 2*t)
+	for i := range xs {
+		xs[i].SetBigInt(g.bits(fr.Bits))
+	}
+	p.mds = make([][]fr.Element, t)
+	for i := range p.mds {
+		p.mds[i] = make([]fr.Element, t)
+		for j := range p.mds[i] {
+			p.mds[i][j].Add(&xs[i], &xs[t+j])
+			p.mds[i][j].Inverse(&p.mds[i][j])
+		}
+	}
+
+	poseidonParamsCache[t] = p
+	return p
+}
+
+// poseidonHash hashes 1 to 16 field elements like circomlib's Poseidon
+func poseidonHash(inputs []*big.Int) (*big.Int, error) {
+	if len(inputs) == 0 || len(inputs) > poseidonMaxInputs {
+		return nil, fmt.Errorf("poseidon takes 1 to %d inputs, got %d", poseidonMaxInputs, len(inputs))
+	}
+
+	t := len(inputs) + 1
+	params := poseidonParamsFor(t)
+	partialRounds := poseidonPartialRounds[t-2]
+
+	state := make([]fr.Element, t)
+	for i, input := range inputs {
+		if input.Sign() < 0 || input.Cmp(fr.Modulus()) >= 0 {
+			return nil, fmt.Errorf("poseidon input %d is not a field element", i)
+		}
+		state[i+1].SetBigInt(input)
+	}
+
+	mixed := make([]fr.Element, t)
+	for r := range poseidonFullRounds + partialRounds {
+		for i := range state {
+			state[i].Add(&state[i], &params.constants[r*t+i])
+		}
+		if r < poseidonFullRounds/2 || r >= poseidonFullRounds/2+partialRounds {
+			for i := range state {
+				poseidonSbox(&state[i])
+			}
+		} else {
+			poseidonSbox(&state[0])
+		}
+
+		for i := range mixed {
+			mixed[i].SetZero()
+			for j := range state {
+				var term fr.Element
+				term.Mul(&params.mds[i][j], &state[j])
+				mixed[i].Add(&mixed[i], &term)
+			}
+		}
+		copy(state, mixed)
+	}
+
+	return state[0].BigInt(new(big.Int)), nil
+}
+
+func poseidonSbox(x *fr.Element) {
+	var sq fr.Element
+	sq.Square(x)
+	sq.Square(&sq)
+	x.Mul(x, &sq)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+// Reference outputs of circomlib's Poseidon
+func TestPoseidonHash(t *testing.T) {
+	for _, tt := range []struct {
+		inputs []int64
+		hash   string
+	}{
+		{[]int64{1}, "0x29176100eaa962bdc1fe6c654d6a3c130e96a4d1168b33848b897dc502820133"},
+		{[]int64{1, 2}, "0x115cc0f5e7d690413df64c6b9662e9cf2a3617f2743245519e19607a4417189a"},
+		{[]int64{1, 2, 3, 4}, "0x299c867db6c1fdd79dcefa40e4510b9837e60ebb1ce0663dbaa525df65250465"},
+	} {
+		inputs := make([]*big.Int, len(tt.inputs))
+		for i, input := range tt.inputs {
+			inputs[i] = big.NewInt(input)
+		}
+		hash, err := poseidonHash(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprintf("0x%064x", hash); got != tt.hash {
+			t.Errorf("poseidon(%v) = %s, want %s", tt.inputs, got, tt.hash)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// pgSchema creates the tables of the Postgres output, unless a previous run
+// already did, so it is safe to run on every connection. It mirrors the
+// SQLite output, but keys are identified by their run and index, so batches
+// need no ids back
+const pgSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id BIGSERIAL PRIMARY KEY,
+	key_type TEXT NOT NULL,
+	count INTEGER NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	insecure BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE TABLE IF NOT EXISTS keys (
+	run_id BIGINT NOT NULL REFERENCES runs (id),
+	idx INTEGER NOT NULL,
+	address TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	derivation_path TEXT,
+	PRIMARY KEY (run_id, idx)
+);
+CREATE TABLE IF NOT EXISTS metadata (
+	run_id BIGINT NOT NULL REFERENCES runs (id),
+	idx INTEGER,
+	name TEXT NOT NULL,
+	value TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS keys_address ON keys (address);
+CREATE INDEX IF NOT EXISTS metadata_run_id_idx ON metadata (run_id, idx);
+`
+
+// pgConfig parses the postgres:// or postgresql:// URL dsn, whose password may
+// also come from PGPASSWORD or the password file. Without an sslmode in the
+// URL or PGSSLMODE, the server's certificate and host name are verified,
+// sslmode=verify-full, instead of libpq's prefer, which would fall back to a
+// cleartext connection. A password is never sent over a connection without
+// TLS, other than a Unix socket
+func pgConfig(dsn string) (*pgx.ConnConfig, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, errors.New("DSN must be a postgres:// URL")
+	}
+	if u.Query().Get("sslmode") == "" && os.Getenv("PGSSLMODE") == "" {
+		query := u.Query()
+		query.Set("sslmode", "verify-full")
+		u.RawQuery = query.Encode()
+	}
+	config, err := pgx.ParseConfig(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if config.Password != "" {
+		// With sslmode=prefer or allow, the fallbacks connect without TLS
+		targets := append([]*pgconn.FallbackConfig{{Host: config.Host, TLSConfig: config.TLSConfig}}, config.Fallbacks...)
+		for _, target := range targets {
+			if target.TLSConfig == nil && !strings.HasPrefix(target.Host, "/") {
+				return nil, fmt.Errorf("refusing to send the password to %s without TLS, use sslmode=verify-full, verify-ca or require", target.Host)
+			}
+		}
+	}
+	config.RuntimeParams["application_name"] = "account-generator"
+	return config, nil
+}
+
+// writePostgres inserts result as a new run of the database at dsn, creating
+// the tables if missing, with its keys numbered from startIndex. The whole run
+// is one transaction, so a failed run leaves no partial rows behind
+func writePostgres(dsn string, result KeyGenResult, startIndex int) (string, error) {
+	runMetadata, err := resultMetadata(result)
+	if err != nil {
+		return "", err
+	}
+	createdAt, err := time.Parse(time.RFC3339, result.Timestamp)
+	if err != nil {
+		return "", err
+	}
+	config, err := pgConfig(dsn)
+	if err != nil {
+		return "", err
+	}
+	ctx := context.Background()
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, pgSchema); err != nil {
+		return "", err
+	}
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	// Does nothing once committed
+	defer tx.Rollback(ctx)
+
+	var runID int64
+	err = tx.QueryRow(ctx, "INSERT INTO runs (key_type, count, created_at, insecure) VALUES ($1, $2, $3, $4) RETURNING id",
+		result.KeyType, result.Count, createdAt, result.Insecure).Scan(&runID)
+	if err != nil {
+		return "", err
+	}
+
+	var keyRows, metadataRows [][]any
+	for name, value := range runMetadata {
+		metadataRows = append(metadataRows, []any{runID, nil, name, value})
+	}
+	paths := result.Extra["derivationPath"]
+	for i, publicKey := range result.PublicKeys {
+		idx := startIndex + i
+		var privateKey string
+		if i < len(result.PrivateKeys) {
+			privateKey = result.PrivateKeys[i]
+		}
+		var path any
+		if i < len(paths) {
+			path = paths[i]
+		}
+		keyRows = append(keyRows, []any{runID, idx, publicKey, privateKey, path})
+		for name, values := range result.Extra {
+			if name == "derivationPath" || i >= len(values) {
+				continue
+			}
+			metadataRows = append(metadataRows, []any{runID, idx, name, values[i]})
+		}
+	}
+
+	// COPY streams the rows, however many there are, in one statement
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"keys"}, []string{"run_id", "idx", "address", "private_key", "derivation_path"}, pgx.CopyFromRows(keyRows)); err != nil {
+		return "", err
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"metadata"}, []string{"run_id", "idx", "name", "value"}, pgx.CopyFromRows(metadataRows)); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return fmt.Sprint(runID), nil
+}
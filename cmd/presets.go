@@ -0,0 +1,39 @@
+package main
+
+// walletPresets maps -preset values to the path templates each wallet
+// derives the accounts it lists at, by key type
+var walletPresets = map[string]map[string]string{
+	// Ledger Live adds an account per hardened account index and shows its
+	// first address
+	"ledger-live": {
+		"evm":     "m/44'/60'/i'/0/0",
+		"solana":  "m/44'/501'/i'",
+		"sui":     "m/44'/784'/i'/0'/0'",
+		"bitcoin": "m/84'/0'/i'/0/0",
+		"cosmos":  "m/44'/118'/i'/0/0",
+		"tron":    "m/44'/195'/i'/0/0",
+		"aptos":   "m/44'/637'/i'/0'/0'",
+	},
+	"metamask": {
+		"evm": "m/44'/60'/0'/0/i",
+	},
+	// Trust Wallet derives one address per chain from the first account
+	"trust": {
+		"evm":     "m/44'/60'/0'/0/i",
+		"solana":  "m/44'/501'/i'",
+		"sui":     "m/44'/784'/i'/0'/0'",
+		"bitcoin": "m/84'/0'/0'/0/i",
+		"cosmos":  "m/44'/118'/0'/0/i",
+		"tron":    "m/44'/195'/0'/0/i",
+		"aptos":   "m/44'/637'/i'/0'/0'",
+	},
+	"phantom": {
+		"solana":  "m/44'/501'/i'/0'",
+		"evm":     "m/44'/60'/0'/0/i",
+		"sui":     "m/44'/784'/i'/0'/0'",
+		"bitcoin": "m/84'/0'/i'/0/0",
+	},
+	"suiwallet": {
+		"sui": "m/44'/784'/i'/0'/0'",
+	},
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// extraValue is a chain-specific value of a keypair, marked by the generator
+// that makes it as public when it can be shared without the private key
+type extraValue struct {
+	value  string
+	public bool
+}
+
+// keyExtras are the chain-specific values of a keypair by name
+type keyExtras map[string]extraValue
+
+// publicExtra returns value marked as public
+func publicExtra(value string) extraValue {
+	return extraValue{value: value, public: true}
+}
+
+// secretExtra returns value marked as secret
+func secretExtra(value string) extraValue {
+	return extraValue{value: value}
+}
+
+// values returns the values of the extras without their marks
+func (e keyExtras) values() map[string]string {
+	values := make(map[string]string, len(e))
+	for name, extra := range e {
+		values[name] = extra.value
+	}
+	return values
+}
+
+// publicValues returns the values of the extras marked as public
+func (e keyExtras) publicValues() map[string]string {
+	values := make(map[string]string, len(e))
+	for name, extra := range e {
+		if extra.public {
+			values[name] = extra.value
+		}
+	}
+	return values
+}
+
+// publicFormats are the formats that can hold results without private keys
+var publicFormats = []string{"json", "csv", "yaml", "env"}
+
+// publicResult returns result without its secrets: the private keys,
+// mnemonics, SLIP-39 shares, descriptors of private keys, extended private
+// keys and the extras not marked as public
+func publicResult(result KeyGenResult) KeyGenResult {
+	public := KeyGenResult{
+		KeyType:              result.KeyType,
+		Count:                result.Count,
+		Timestamp:            result.Timestamp,
+		PublicKeys:           result.PublicKeys,
+		Network:              result.Network,
+		HRP:                  result.HRP,
+		Scheme:               result.Scheme,
+		SS58Prefix:           result.SS58Prefix,
+		WalletVersion:        result.WalletVersion,
+		Workchain:            result.Workchain,
+		Multisig:             result.Multisig,
+		DistributedValidator: result.DistributedValidator,
+		WatchOnly:            result.WatchOnly,
+		MnemonicFormat:       result.MnemonicFormat,
+		Passphrase:           result.Passphrase,
+		Insecure:             result.Insecure,
+	}
+	if result.ChiaPlotKeys != nil {
+		public.ChiaPlotKeys = &ChiaPlotKeys{
+			Fingerprint:     result.ChiaPlotKeys.Fingerprint,
+			FarmerPublicKey: result.ChiaPlotKeys.FarmerPublicKey,
+			PoolPublicKey:   result.ChiaPlotKeys.PoolPublicKey,
+		}
+	}
+	for _, key := range result.ExtendedKeys {
+		public.ExtendedKeys = append(public.ExtendedKeys, ExtendedKey{Path: key.Path, Xpub: key.Xpub})
+	}
+	for name, values := range result.Extra {
+		if slices.Contains(result.publicExtras, name) {
+			if public.Extra == nil {
+				public.Extra = make(map[string][]string)
+			}
+			public.Extra[name] = values
+		}
+	}
+	return public
+}
+
+// publicFormat returns the format of the companion file of results in
+// format: the same for the formats that can hold results without private
+// keys, and json otherwise
+func publicFormat(format string) string {
+	if slices.Contains(publicFormats, format) {
+		return format
+	}
+	return "json"
+}
+
+// publicFilename returns the name of the companion file of the results file
+// output, or of the default results name if there is none, next to it in the
+// bucket of an s3:// or gs:// output: its name with .public inserted before
+// the extension, e.g. evm_keys_20240101_120000.public.json
+func publicFilename(output, keyType, extension string, insecure bool) string {
+	if output == "" || output == "-" || strings.HasPrefix(output, sqliteOutputPrefix) {
+		output = resultsFilename(keyType, extension, insecure)
+	} else if isBucketOutput(output) {
+		output = bucketOutputName(output, resultsFilename(keyType, extension, insecure))
+	}
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".public." + extension
+}
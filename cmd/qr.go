@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// qrPNGScale is the size of a module in the PNG files, in pixels
+const qrPNGScale = 8
+
+// qrEncode encodes value as a QR code at error correction level M, which
+// survives 15% damage and fits a 64-byte key in a version 5 code
+func qrEncode(value string) (*qrcode.QRCode, error) {
+	return qrcode.New(value, qrcode.Medium)
+}
+
+// writeQRCodes prints a QR code of each address, and of each private key when
+// includePrivate is set, and writes them as PNG files numbered from
+// startIndex under dir
+func writeQRCodes(dir string, privateKeys, publicKeys []string, startIndex int, includePrivate bool) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	type qrValue struct{ label, name, value string }
+	for i, publicKey := range publicKeys {
+		index := startIndex + i
+		codes := []qrValue{{fmt.Sprintf("Address %d: %s", index, publicKey), "address", publicKey}}
+		if includePrivate && i < len(privateKeys) {
+			codes = append(codes, qrValue{fmt.Sprintf("Private key %d", index), "private_key", privateKeys[i]})
+		}
+		for _, code := range codes {
+			q, err := qrEncode(code.value)
+			if err != nil {
+				return err
+			}
+			// Light modules are drawn, so the code reads correctly on the
+			// light on dark terminals most scanners are pointed at
+			fmt.Printf("%s\n%s", code.label, q.ToSmallString(false))
+			data, err := q.PNG(-qrPNGScale)
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(dir, fmt.Sprintf("%d_%s.png", index, code.name))
+			if err := writeOutputFile(path, data); err != nil {
+				return err
+			}
+			writtenFiles = append(writtenFiles, path)
+		}
+	}
+	return nil
+}
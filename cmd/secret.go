@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// secretChunkSize is the size of the locked chunks of a secretArena, a
+// multiple of the page size of every platform
+const secretChunkSize = 64 << 10
+
+// secretArena holds the private keys and secret extras of a batch outside
+// the Go heap while it is generated, in memory that is locked against
+// swapping and left out of core dumps where the platform allows, so the
+// garbage collector never copies them and they can be wiped once written
+type secretArena struct {
+	chunks [][]byte
+	free   []byte
+	// unlocked is set once locking failed, which is only warned about once
+	unlocked bool
+}
+
+// add copies s into the arena and returns the copy, which reads as zeros
+// once the arena is wiped
+func (a *secretArena) add(s string) []byte {
+	if s == "" {
+		return nil
+	}
+	if len(a.free) < len(s) {
+		size := max(secretChunkSize, (len(s)+secretChunkSize-1)/secretChunkSize*secretChunkSize)
+		chunk, err := lockedAlloc(size)
+		if err != nil && !a.unlocked {
+			a.unlocked = true
+			fmt.Fprintf(os.Stderr, "Warning: Private keys may be swapped to disk, locking their memory failed: %v; raise the limit with ulimit -l\n", err)
+		}
+		a.chunks = append(a.chunks, chunk)
+		a.free = chunk
+	}
+	n := copy(a.free, s)
+	kept := a.free[:n:n]
+	a.free = a.free[n:]
+	return kept
+}
+
+// wipe zeroes every secret of the arena
+func (a *secretArena) wipe() {
+	for _, chunk := range a.chunks {
+		clear(chunk)
+	}
+	a.free = nil
+}
+
+// secretStrings copies secrets out of an arena into the strings the
+// encoders take
+func secretStrings(secrets [][]byte) []string {
+	strs := make([]string, len(secrets))
+	for i, secret := range secrets {
+		strs[i] = string(secret)
+	}
+	return strs
+}
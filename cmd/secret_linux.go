@@ -0,0 +1,17 @@
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// excludeFromCoreDumps leaves buf out of core dumps, even those taken with
+// the process's dump limit raised again
+func excludeFromCoreDumps(buf []byte) {
+	unix.Madvise(buf, unix.MADV_DONTDUMP)
+}
+
+// setUndumpable also keeps other processes of the user from attaching to the
+// process or reading its memory through /proc
+func setUndumpable() {
+	unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0)
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+
+package main
+
+// Other Unix systems have no portable way to exclude memory from core dumps
+// beyond RLIMIT_CORE
+
+func excludeFromCoreDumps([]byte) {}
+
+func setUndumpable() {}
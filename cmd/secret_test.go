@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSecretArenaWipe(t *testing.T) {
+	var secrets secretArena
+	// The second key does not fit in the first chunk
+	long := strings.Repeat("b", secretChunkSize)
+	kept := [][]byte{secrets.add("aaaa"), secrets.add(long), secrets.add("")}
+	if got := secretStrings(kept); !slices.Equal(got, []string{"aaaa", long, ""}) {
+		t.Fatalf("arena kept %.16q", got)
+	}
+	if len(secrets.chunks) != 2 {
+		t.Errorf("arena has %d chunks, want 2", len(secrets.chunks))
+	}
+
+	secrets.wipe()
+	for i, secret := range kept {
+		if !bytes.Equal(secret, make([]byte, len(secret))) {
+			t.Errorf("secret %d reads %q after wipe, want zeros", i, secret)
+		}
+	}
+}
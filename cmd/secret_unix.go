@@ -0,0 +1,26 @@
+//go:build !windows
+
+package main
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// lockedAlloc maps size bytes outside the Go heap and locks them in RAM. If
+// locking fails, e.g. beyond RLIMIT_MEMLOCK, the memory is still returned
+// with the error
+func lockedAlloc(size int) ([]byte, error) {
+	buf, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_ANON|unix.MAP_PRIVATE)
+	if err != nil {
+		return make([]byte, size), err
+	}
+	excludeFromCoreDumps(buf)
+	return buf, unix.Mlock(buf)
+}
+
+// disableCoreDumps keeps the process from dumping its memory, and with it
+// any key, to a core file when it crashes
+func disableCoreDumps() {
+	unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{})
+	setUndumpable()
+}
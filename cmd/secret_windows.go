@@ -0,0 +1,24 @@
+package main
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockedAlloc allocates size bytes outside the Go heap and locks them in the
+// working set. If locking fails, e.g. beyond the minimum working set size,
+// the memory is still returned with the error
+func lockedAlloc(size int) ([]byte, error) {
+	addr, err := windows.VirtualAlloc(0, uintptr(size), windows.MEM_COMMIT|windows.MEM_RESERVE, windows.PAGE_READWRITE)
+	if err != nil {
+		return make([]byte, size), err
+	}
+	// addr is memory of the system, not of the Go heap, so it cannot move
+	buf := unsafe.Slice(*(**byte)(unsafe.Pointer(&addr)), size)
+	return buf, windows.VirtualLock(addr, uintptr(size))
+}
+
+// disableCoreDumps does nothing on Windows, whose crash dumps are configured
+// system-wide with Windows Error Reporting
+func disableCoreDumps() {}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const seiHRP = "sei"
+
+// generateSeiKeyPair returns a hex private key and its sei1 bech32 address,
+// with the EVM address Sei associates with the same key as an extra
+func generateSeiKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+	address, err := encodeBech32(seiHRP, btcutil.Hash160(pubKeyBytes))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error encoding account address: %w", err)
+	}
+
+	extra := keyExtras{
+		"evmAddress": publicExtra(crypto.PubkeyToAddress(*privateKey.PubKey().ToECDSA()).Hex()),
+		"publicKey":  publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	return hex.EncodeToString(privateKey.Serialize()), address, extra, nil
+}
@@ -0,0 +1,556 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	slip39IterationExponent = 1
+	slip39BaseIterations    = 10000
+	slip39Rounds            = 4
+	slip39Customization     = "shamir"
+	slip39ExtCustomization  = "shamir_extendable"
+	slip39HeaderWords       = 4
+	slip39ChecksumWords     = 3
+	slip39MinValueWords     = 13
+	slip39DigestSize        = 4
+	slip39DigestIndex       = 254
+	slip39SecretIndex       = 255
+	slip39MaxShares         = 16
+)
+
+// slip39Generator is the generator of the RS1024 checksum of share mnemonics
+var slip39Generator = [10]uint32{0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009, 0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120}
+
+// slip39Wordlist is the 1024-word SLIP-39 wordlist; every word is unique in
+// its first four letters
+var slip39Wordlist = strings.Fields(`
+academic acid acne acquire acrobat activity actress adapt adequate adjust
+admit adorn adult advance advocate afraid again agency agree aide aircraft
+airline airport ajar alarm album alcohol alien alive alpha already alto
+aluminum always amazing ambition amount amuse analysis anatomy ancestor
+ancient angel angry animal answer antenna anxiety apart aquatic arcade arena
+argue armed artist artwork aspect auction august aunt average aviation avoid
+award away axis axle beam beard beaver become bedroom behavior being believe
+belong benefit best beyond bike biology birthday bishop black blanket
+blessing blimp blind blue body bolt boring born both boundary bracelet
+branch brave breathe briefing broken brother browser bucket budget building
+bulb bulge bumpy bundle burden burning busy buyer cage calcium camera campus
+canyon capacity capital capture carbon cards careful cargo carpet carve
+category cause ceiling center ceramic champion change charity check chemical
+chest chew chubby cinema civil class clay cleanup client climate clinic
+clock clogs closet clothes club cluster coal coastal coding column company
+corner costume counter course cover cowboy cradle craft crazy credit cricket
+criminal crisis critical crowd crucial crunch crush crystal cubic cultural
+curious curly custody cylinder daisy damage dance darkness database daughter
+deadline deal debris debut decent decision declare decorate decrease deliver
+demand density deny depart depend depict deploy describe desert desire
+desktop destroy detailed detect device devote diagnose dictate diet dilemma
+diminish dining diploma disaster discuss disease dish dismiss display
+distance dive divorce document domain domestic dominant dough downtown
+dragon dramatic dream dress drift drink drove drug dryer duckling duke
+duration dwarf dynamic early earth easel easy echo eclipse ecology edge
+editor educate either elbow elder election elegant element elephant elevator
+elite else email emerald emission emperor emphasis employer empty ending
+endless endorse enemy energy enforce engage enjoy enlarge entrance envelope
+envy epidemic episode equation equip eraser erode escape estate estimate
+evaluate evening evidence evil evoke exact example exceed exchange exclude
+excuse execute exercise exhaust exotic expand expect explain express extend
+extra eyebrow facility fact failure faint fake false family famous fancy
+fangs fantasy fatal fatigue favorite fawn fiber fiction filter finance
+findings finger firefly firm fiscal fishing fitness flame flash flavor flea
+flexible flip float floral fluff focus forbid force forecast forget formal
+fortune forward founder fraction fragment frequent freshman friar fridge
+friendly frost froth frozen fumes funding furl fused galaxy game garbage
+garden garlic gasoline gather general genius genre genuine geology gesture
+glad glance glasses glen glimpse goat golden graduate grant grasp gravity
+gray greatest grief grill grin grocery gross group grownup grumpy guard
+guest guilt guitar gums hairy hamster hand hanger harvest have havoc hawk
+hazard headset health hearing heat helpful herald herd hesitate hobo holiday
+holy home hormone hospital hour huge human humidity hunting husband hush
+husky hybrid idea identify idle image impact imply improve impulse include
+income increase index indicate industry infant inform inherit injury inmate
+insect inside install intend intimate invasion involve iris island isolate
+item ivory jacket jerky jewelry join judicial juice jump junction junior
+junk jury justice kernel keyboard kidney kind kitchen knife knit laden ladle
+ladybug lair lamp language large laser laundry lawsuit leader leaf learn
+leaves lecture legal legend legs lend length level liberty library license
+lift likely lilac lily lips liquid listen literary living lizard loan lobe
+location losing loud loyalty luck lunar lunch lungs luxury lying lyrics
+machine magazine maiden mailman main makeup making mama manager mandate
+mansion manual marathon march market marvel mason material math maximum
+mayor meaning medal medical member memory mental merchant merit method
+metric midst mild military mineral minister miracle mixed mixture mobile
+modern modify moisture moment morning mortgage mother mountain mouse move
+much mule multiple muscle museum music mustang nail national necklace
+negative nervous network news nuclear numb numerous nylon oasis obesity
+object observe obtain ocean often olympic omit oral orange orbit order
+ordinary organize ounce oven overall owner paces pacific package paid
+painting pajamas pancake pants papa paper parcel parking party patent patrol
+payment payroll peaceful peanut peasant pecan penalty pencil percent perfect
+permit petition phantom pharmacy photo phrase physics pickup picture piece
+pile pink pipeline pistol pitch plains plan plastic platform playoff
+pleasure plot plunge practice prayer preach predator pregnant premium
+prepare presence prevent priest primary priority prisoner privacy prize
+problem process profile program promise prospect provide prune public pulse
+pumps punish puny pupal purchase purple python quantity quarter quick quiet
+race racism radar railroad rainbow raisin random ranked rapids raspy
+reaction realize rebound rebuild recall receiver recover regret regular
+reject relate remember remind remove render repair repeat replace require
+rescue research resident response result retailer retreat reunion revenue
+review reward rhyme rhythm rich rival river robin rocky romantic romp roster
+round royal ruin ruler rumor sack safari salary salon salt satisfy satoshi
+saver says scandal scared scatter scene scholar science scout scramble screw
+script scroll seafood season secret security segment senior shadow shaft
+shame shaped sharp shelter sheriff short should shrimp sidewalk silent
+silver similar simple single sister skin skunk slap slavery sled slice slim
+slow slush smart smear smell smirk smith smoking smug snake snapshot sniff
+society software soldier solution soul source space spark speak species
+spelling spend spew spider spill spine spirit spit spray sprinkle square
+squeeze stadium staff standard starting station stay steady step stick stilt
+story strategy strike style subject submit sugar suitable sunlight superior
+surface surprise survive sweater swimming swing switch symbolic sympathy
+syndrome system tackle tactics tadpole talent task taste taught taxi teacher
+teammate teaspoon temple tenant tendency tension terminal testify texture
+thank that theater theory therapy thorn threaten thumb thunder ticket tidy
+timber timely ting tofu together tolerate total toxic tracks traffic
+training transfer trash traveler treat trend trial tricycle trip triumph
+trouble true trust twice twin type typical ugly ultimate umbrella uncover
+undergo unfair unfold unhappy union universe unkind unknown unusual unwrap
+upgrade upstairs username usher usual valid valuable vampire vanish various
+vegan velvet venture verdict verify very veteran vexed victim video view
+vintage violence viral visitor visual vitamins vocal voice volume voter
+voting walnut warmth warn watch wavy wealthy weapon webcam welcome welfare
+western width wildlife window wine wireless wisdom withdraw wits wolf woman
+work worthy wrap wrist writing wrote year yelp yield yoga zero
+`)
+
+var slip39WordIndex = func() map[string]int {
+	index := make(map[string]int, len(slip39Wordlist))
+	for i, word := range slip39Wordlist {
+		index[word] = i
+	}
+	return index
+}()
+
+// gf256Exp and gf256Log are the exponent and logarithm tables of GF(256)
+// under the Rijndael polynomial x^8 + x^4 + x^3 + x + 1, generated by 3
+var gf256Exp, gf256Log = func() ([255]byte, [256]byte) {
+	var exp [255]byte
+	var log [256]byte
+	x := 1
+	for i := range exp {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		// Multiply by 3 = x + 1
+		x ^= x << 1
+		if x&0x100 != 0 {
+			x ^= 0x11b
+		}
+	}
+	return exp, log
+}()
+
+// slip39Share is a decoded share mnemonic
+type slip39Share struct {
+	id              uint16
+	extendable      bool
+	exponent        byte
+	groupIndex      byte
+	groupThreshold  byte
+	groupCount      byte
+	memberIndex     byte
+	memberThreshold byte
+	value           []byte
+}
+
+// slip39Point is a share of a secret split with slip39SplitSecret
+type slip39Point struct {
+	x     byte
+	value []byte
+}
+
+// slip39Interpolate evaluates at x the polynomials through the points, byte
+// by byte in GF(256)
+func slip39Interpolate(points []slip39Point, x byte) []byte {
+	for _, p := range points {
+		if p.x == x {
+			return append([]byte{}, p.value...)
+		}
+	}
+
+	// log of the product of (x - x_j) over every point, subtraction being xor
+	logProd := 0
+	for _, p := range points {
+		logProd += int(gf256Log[p.x^x])
+	}
+
+	result := make([]byte, len(points[0].value))
+	for i, p := range points {
+		// log of the basis polynomial of p at x:
+		// prod_{j != i} (x - x_j) / (x_i - x_j)
+		logBasis := logProd - int(gf256Log[p.x^x])
+		for j, q := range points {
+			if j != i {
+				logBasis -= int(gf256Log[p.x^q.x])
+			}
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+		for k, b := range p.value {
+			if b != 0 {
+				result[k] ^= gf256Exp[(int(gf256Log[b])+logBasis)%255]
+			}
+		}
+	}
+	return result
+}
+
+// slip39Digest is the first four bytes of HMAC-SHA256(random, secret)
+func slip39Digest(random, secret []byte) []byte {
+	mac := hmac.New(sha256.New, random)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestSize]
+}
+
+// slip39SplitSecret splits secret into count points, any threshold of which
+// recover it; above a threshold of 1 the polynomial also encodes a digest of
+// the secret at x = 254 to detect wrong recoveries
+func slip39SplitSecret(random io.Reader, threshold, count int, secret []byte) ([]slip39Point, error) {
+	if threshold < 1 || threshold > count || count > slip39MaxShares {
+		return nil, fmt.Errorf("invalid %d-of-%d sharing", threshold, count)
+	}
+	if threshold == 1 {
+		points := make([]slip39Point, count)
+		for i := range points {
+			points[i] = slip39Point{x: byte(i), value: append([]byte{}, secret...)}
+		}
+		return points, nil
+	}
+
+	randomPart := make([]byte, len(secret)-slip39DigestSize)
+	if _, err := io.ReadFull(random, randomPart); err != nil {
+		return nil, err
+	}
+	base := make([]slip39Point, 0, threshold)
+	for i := 0; i < threshold-2; i++ {
+		value := make([]byte, len(secret))
+		if _, err := io.ReadFull(random, value); err != nil {
+			return nil, err
+		}
+		base = append(base, slip39Point{x: byte(i), value: value})
+	}
+	digest := append(slip39Digest(randomPart, secret), randomPart...)
+	base = append(base, slip39Point{x: slip39DigestIndex, value: digest}, slip39Point{x: slip39SecretIndex, value: secret})
+
+	points := append([]slip39Point{}, base[:threshold-2]...)
+	for i := threshold - 2; i < count; i++ {
+		points = append(points, slip39Point{x: byte(i), value: slip39Interpolate(base, byte(i))})
+	}
+	return points, nil
+}
+
+// slip39RecoverSecret recovers the secret from threshold points and checks
+// its digest
+func slip39RecoverSecret(threshold int, points []slip39Point) ([]byte, error) {
+	if threshold == 1 {
+		return points[0].value, nil
+	}
+	secret := slip39Interpolate(points, slip39SecretIndex)
+	digest := slip39Interpolate(points, slip39DigestIndex)
+	if !hmac.Equal(digest[:slip39DigestSize], slip39Digest(digest[slip39DigestSize:], secret)) {
+		return nil, fmt.Errorf("invalid digest of the shared secret")
+	}
+	return secret, nil
+}
+
+// slip39Feistel encrypts or decrypts a master secret with the four-round
+// Feistel network keyed by PBKDF2-HMAC-SHA256 of the passphrase
+func slip39Feistel(data []byte, passphrase string, id uint16, extendable bool, exponent byte, decrypt bool) []byte {
+	half := len(data) / 2
+	l := append([]byte{}, data[:half]...)
+	r := append([]byte{}, data[half:]...)
+
+	var salt []byte
+	if !extendable {
+		salt = append([]byte(slip39Customization), byte(id>>8), byte(id))
+	}
+	iterations := (slip39BaseIterations << exponent) / slip39Rounds
+
+	for round := 0; round < slip39Rounds; round++ {
+		i := round
+		if decrypt {
+			i = slip39Rounds - 1 - round
+		}
+		f := pbkdf2.Key(append([]byte{byte(i)}, passphrase...), append(append([]byte{}, salt...), r...), iterations, half, sha256.New)
+		for k := range f {
+			f[k] ^= l[k]
+		}
+		l, r = r, f
+	}
+	return append(r, l...)
+}
+
+// slip39Polymod computes the RS1024 checksum state of values
+func slip39Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xfffff)<<10 ^ uint32(v)
+		for i, g := range slip39Generator {
+			if (b>>i)&1 != 0 {
+				chk ^= g
+			}
+		}
+	}
+	return chk
+}
+
+// slip39CustomizationValues returns the checksum customization string of a
+// share as values
+func slip39CustomizationValues(extendable bool) []int {
+	customization := slip39Customization
+	if extendable {
+		customization = slip39ExtCustomization
+	}
+	values := make([]int, len(customization))
+	for i, c := range []byte(customization) {
+		values[i] = int(c)
+	}
+	return values
+}
+
+// encode renders the share as its mnemonic
+func (s slip39Share) encode() string {
+	header := uint64(s.id)<<25 | uint64(s.exponent)<<20 |
+		uint64(s.groupIndex)<<16 | uint64(s.groupThreshold-1)<<12 | uint64(s.groupCount-1)<<8 |
+		uint64(s.memberIndex)<<4 | uint64(s.memberThreshold-1)
+	if s.extendable {
+		header |= 1 << 24
+	}
+	indices := make([]int, 0, slip39HeaderWords)
+	for i := slip39HeaderWords - 1; i >= 0; i-- {
+		indices = append(indices, int(header>>(10*i))&0x3ff)
+	}
+
+	// The value is left padded to a whole number of words
+	valueWords := (len(s.value)*8 + 9) / 10
+	value := new(big.Int).SetBytes(s.value)
+	for i := valueWords - 1; i >= 0; i-- {
+		indices = append(indices, int(new(big.Int).Rsh(value, uint(10*i)).Int64())&0x3ff)
+	}
+
+	values := append(slip39CustomizationValues(s.extendable), indices...)
+	chk := slip39Polymod(append(values, 0, 0, 0)) ^ 1
+	for i := slip39ChecksumWords - 1; i >= 0; i-- {
+		indices = append(indices, int(chk>>(10*i))&0x3ff)
+	}
+
+	words := make([]string, len(indices))
+	for i, index := range indices {
+		words[i] = slip39Wordlist[index]
+	}
+	return strings.Join(words, " ")
+}
+
+// decodeSlip39Share parses a share mnemonic and checks its checksum
+func decodeSlip39Share(mnemonic string) (slip39Share, error) {
+	var share slip39Share
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < slip39HeaderWords+slip39MinValueWords+slip39ChecksumWords {
+		return share, fmt.Errorf("share mnemonics must have at least %d words", slip39HeaderWords+slip39MinValueWords+slip39ChecksumWords)
+	}
+	indices := make([]int, len(words))
+	for i, word := range words {
+		index, ok := slip39WordIndex[word]
+		if !ok {
+			return share, fmt.Errorf("invalid share word: %s", word)
+		}
+		indices[i] = index
+	}
+
+	var header uint64
+	for _, index := range indices[:slip39HeaderWords] {
+		header = header<<10 | uint64(index)
+	}
+	share = slip39Share{
+		id:              uint16(header >> 25),
+		extendable:      header>>24&1 == 1,
+		exponent:        byte(header >> 20 & 0xf),
+		groupIndex:      byte(header >> 16 & 0xf),
+		groupThreshold:  byte(header>>12&0xf) + 1,
+		groupCount:      byte(header>>8&0xf) + 1,
+		memberIndex:     byte(header >> 4 & 0xf),
+		memberThreshold: byte(header&0xf) + 1,
+	}
+	if slip39Polymod(append(slip39CustomizationValues(share.extendable), indices...)) != 1 {
+		return share, fmt.Errorf("invalid share checksum")
+	}
+	if share.groupThreshold > share.groupCount {
+		return share, fmt.Errorf("invalid share: group threshold exceeds the group count")
+	}
+
+	valueIndices := indices[slip39HeaderWords : len(indices)-slip39ChecksumWords]
+	padding := len(valueIndices) * 10 % 16
+	if padding > 8 {
+		return share, fmt.Errorf("invalid share length")
+	}
+	value := new(big.Int)
+	for _, index := range valueIndices {
+		value.Lsh(value, 10).Or(value, big.NewInt(int64(index)))
+	}
+	size := (len(valueIndices)*10 - padding) / 8
+	if value.BitLen() > size*8 {
+		return share, fmt.Errorf("invalid share padding")
+	}
+	share.value = value.FillBytes(make([]byte, size))
+	return share, nil
+}
+
+// slip39SplitMasterSecret encrypts masterSecret under passphrase and splits
+// it into count single-group share mnemonics, any threshold of which
+// recover it
+func slip39SplitMasterSecret(random io.Reader, masterSecret []byte, passphrase string, threshold, count int) ([]string, error) {
+	if threshold == 1 && count > 1 {
+		return nil, fmt.Errorf("multiple shares with a threshold of 1 are not allowed, use 1-of-1")
+	}
+	idBytes := make([]byte, 2)
+	if _, err := io.ReadFull(random, idBytes); err != nil {
+		return nil, err
+	}
+	id := (uint16(idBytes[0])<<8 | uint16(idBytes[1])) & 0x7fff
+
+	encrypted := slip39Feistel(masterSecret, passphrase, id, false, slip39IterationExponent, false)
+	groups, err := slip39SplitSecret(random, 1, 1, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	members, err := slip39SplitSecret(random, threshold, count, groups[0].value)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonics := make([]string, len(members))
+	for i, member := range members {
+		mnemonics[i] = slip39Share{
+			id:              id,
+			exponent:        slip39IterationExponent,
+			groupThreshold:  1,
+			groupCount:      1,
+			memberIndex:     member.x,
+			memberThreshold: byte(threshold),
+			value:           member.value,
+		}.encode()
+	}
+	return mnemonics, nil
+}
+
+// newSlip39MasterSecret returns a new master secret with the entropy of a
+// BIP39 mnemonic of words words, and its threshold-of-count share mnemonics
+func newSlip39MasterSecret(random io.Reader, words int, passphrase string, threshold, count int) ([]byte, []string, error) {
+	masterSecret := make([]byte, words*32/3/8)
+	if _, err := io.ReadFull(random, masterSecret); err != nil {
+		return nil, nil, err
+	}
+	shares, err := slip39SplitMasterSecret(random, masterSecret, passphrase, threshold, count)
+	if err != nil {
+		return nil, nil, err
+	}
+	return masterSecret, shares, nil
+}
+
+// slip39CombineMnemonics recovers the master secret from share mnemonics of
+// one or more groups, decrypting it under passphrase
+func slip39CombineMnemonics(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, fmt.Errorf("no shares")
+	}
+	shares := make([]slip39Share, len(mnemonics))
+	for i, mnemonic := range mnemonics {
+		share, err := decodeSlip39Share(mnemonic)
+		if err != nil {
+			return nil, fmt.Errorf("share %d: %w", i+1, err)
+		}
+		shares[i] = share
+	}
+
+	first := shares[0]
+	groups := make(map[byte][]slip39Share)
+	for _, share := range shares {
+		if share.id != first.id || share.extendable != first.extendable || share.exponent != first.exponent ||
+			share.groupThreshold != first.groupThreshold || share.groupCount != first.groupCount || len(share.value) != len(first.value) {
+			return nil, fmt.Errorf("shares do not belong to the same secret")
+		}
+		groups[share.groupIndex] = append(groups[share.groupIndex], share)
+	}
+	if len(groups) < int(first.groupThreshold) {
+		return nil, fmt.Errorf("need shares of %d groups, got %d", first.groupThreshold, len(groups))
+	}
+
+	groupPoints := make([]slip39Point, 0, len(groups))
+	for index, members := range groups {
+		threshold := int(members[0].memberThreshold)
+		points := make([]slip39Point, 0, len(members))
+		seen := make(map[byte]bool)
+		for _, member := range members {
+			if int(member.memberThreshold) != threshold {
+				return nil, fmt.Errorf("shares of group %d disagree on the member threshold", index+1)
+			}
+			if !seen[member.memberIndex] {
+				seen[member.memberIndex] = true
+				points = append(points, slip39Point{x: member.memberIndex, value: member.value})
+			}
+		}
+		if len(points) < threshold {
+			continue
+		}
+		secret, err := slip39RecoverSecret(threshold, points[:threshold])
+		if err != nil {
+			return nil, fmt.Errorf("group %d: %w", index+1, err)
+		}
+		groupPoints = append(groupPoints, slip39Point{x: index, value: secret})
+	}
+	if len(groupPoints) < int(first.groupThreshold) {
+		return nil, fmt.Errorf("not enough shares: %d of the %d groups needed are complete", len(groupPoints), first.groupThreshold)
+	}
+
+	encrypted, err := slip39RecoverSecret(int(first.groupThreshold), groupPoints[:first.groupThreshold])
+	if err != nil {
+		return nil, err
+	}
+	return slip39Feistel(encrypted, passphrase, first.id, first.extendable, first.exponent, true), nil
+}
+
+// readSlip39Shares reads share mnemonics from path, one per line
+func readSlip39Shares(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mnemonics []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			mnemonics = append(mnemonics, line)
+		}
+	}
+	return mnemonics, nil
+}
+
+// parseSlip39Scheme parses a T-of-N sharing scheme
+func parseSlip39Scheme(scheme string) (int, int, error) {
+	var threshold, count int
+	if _, err := fmt.Sscanf(scheme, "%d-of-%d", &threshold, &count); err != nil || threshold < 1 || threshold > count || count > slip39MaxShares {
+		return 0, 0, fmt.Errorf("sharing scheme must be T-of-N with 1 <= T <= N <= %d: %q", slip39MaxShares, scheme)
+	}
+	if threshold == 1 && count > 1 {
+		return 0, 0, fmt.Errorf("multiple shares with a threshold of 1 are not allowed, use 1-of-1")
+	}
+	return threshold, count, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// Vectors of the SLIP-39 reference implementation's vectors.json, all under
+// the passphrase TREZOR; shares that must be rejected have an error instead
+// of a secret
+func TestSlip39Vectors(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		mnemonics   []string
+		secret, err string
+	}{
+		{
+			"valid mnemonic without sharing (128 bits)",
+			[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"},
+			"bb54aac4b89dc868ba37d9cc21b2cece",
+			"",
+		},
+		{
+			"mnemonic with invalid checksum (128 bits)",
+			[]string{"duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision kidney"},
+			"",
+			"invalid share checksum",
+		},
+		{
+			"mnemonic with invalid padding (128 bits)",
+			[]string{"duckling enlarge academic academic email result length solution fridge kidney coal piece deal husband erode duke ajar music cargo fitness"},
+			"",
+			"invalid share padding",
+		},
+		{
+			"basic sharing 2-of-3 (128 bits)",
+			[]string{
+				"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed",
+				"shadow pistol academic acid actress prayer class unknown daughter sweater depict flip twice unkind craft early superior advocate guest smoking",
+			},
+			"b43ceb7e57a0ea8766221624d01b0864",
+			"",
+		},
+		{
+			"basic sharing 2-of-3 with one share (128 bits)",
+			[]string{"shadow pistol academic always adequate wildlife fancy gross oasis cylinder mustang wrist rescue view short owner flip making coding armed"},
+			"",
+			"not enough shares",
+		},
+		{
+			"mnemonics with different identifiers (128 bits)",
+			[]string{
+				"adequate smoking academic acid debut wine petition glen cluster slow rhyme slow simple epidemic rumor junk tracks treat olympic tolerate",
+				"adequate stay academic agency agency formal party ting frequent learn upstairs remember smear leaf damage anatomy ladle market hush corner",
+			},
+			"",
+			"do not belong to the same secret",
+		},
+		{
+			"mnemonics with different iteration exponents (128 bits)",
+			[]string{
+				"peasant leaves academic acid desert exact olympic math alive axle trial tackle drug deny decent smear dominant desert bucket remind",
+				"peasant leader academic agency cultural blessing percent network envelope medal junk primary human pumps jacket fragment payroll ticket evoke voice",
+			},
+			"",
+			"do not belong to the same secret",
+		},
+		{
+			"threshold number of groups and members in each group (128 bits)",
+			[]string{
+				"eraser senior beard romp adorn nuclear spill corner cradle style ancient family general leader ambition exchange unusual garlic promise voice",
+				"eraser senior ceramic snake clay various huge numb argue hesitate auction category timber browser greatest hanger petition script leaf pickup",
+				"eraser senior ceramic shaft dynamic become junior wrist silver peasant force math alto coal amazing segment yelp velvet image paces",
+				"eraser senior ceramic round column hawk trust auction smug shame alive greatest sheriff living perfect corner chest sled fumes adequate",
+				"eraser senior decision smug corner ruin rescue cubic angel tackle skin skunk program roster trash rumor slush angel flea amazing",
+			},
+			"7c3397a292a5941682d7a4ae2d898d11",
+			"",
+		},
+		{
+			"valid mnemonic without sharing (256 bits)",
+			[]string{"theory painting academic academic armed sweater year military elder discuss acne wildlife boring employer fused large satoshi bundle carbon diagnose anatomy hamster leaves tracks paces beyond phantom capital marvel lips brave detect luck"},
+			"989baf9dcaad5b10ca33dfd8cc75e42477025dce88ae83e75a230086a0e00e92",
+			"",
+		},
+	} {
+		secret, err := slip39CombineMnemonics(tt.mnemonics, "TREZOR")
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: recovered %x, %v, want error %q", tt.description, secret, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || hex.EncodeToString(secret) != tt.secret {
+			t.Errorf("%s: %x, %v, want %s", tt.description, secret, err, tt.secret)
+		}
+	}
+}
+
+func TestSlip39SplitMasterSecret(t *testing.T) {
+	masterSecret, shares, err := newSlip39MasterSecret(rand.Reader, 12, "TREZOR", 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][]string{{shares[0], shares[1]}, {shares[2], shares[0]}} {
+		secret, err := slip39CombineMnemonics(pair, "TREZOR")
+		if err != nil || !bytes.Equal(secret, masterSecret) {
+			t.Errorf("combined %x, %v, want %x", secret, err, masterSecret)
+		}
+	}
+	if secret, err := slip39CombineMnemonics(shares[:1], "TREZOR"); err == nil {
+		t.Errorf("one of 2 shares recovered %x", secret)
+	}
+}
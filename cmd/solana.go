@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/mr-tron/base58"
+)
+
+// solanaKeypairJSON renders a base58 64-byte secret key as the JSON array of
+// bytes solana-keygen writes to id.json and the solana CLI reads as --keypair
+func solanaKeypairJSON(privateKey string) ([]byte, error) {
+	secret, err := base58.Decode(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	// A []byte would marshal as base64, so widen it to numbers
+	values := make([]int, len(secret))
+	for i, b := range secret {
+		values[i] = int(b)
+	}
+	return json.Marshal(values)
+}
+
+// solanaKeyFiles writes one account as the solana-keygen keypair file
+// <address>.json
+func solanaKeyFiles(_ int, privateKey, publicKey string, _ map[string]string, _ string) (map[string][]byte, error) {
+	data, err := solanaKeypairJSON(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{publicKey + ".json": data}, nil
+}
+
+// encodeSolanaJSON renders one solana-keygen keypair array per line, so the
+// file of a single key is itself a keypair file
+func encodeSolanaJSON(result KeyGenResult) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, privateKey := range result.PrivateKeys {
+		data, err := solanaKeypairJSON(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"gopkg.in/yaml.v3"
+)
+
+// SOPS files (https://github.com/getsops/sops) keep the structure of the
+// document in the clear and encrypt each value with AES-256-GCM under a data
+// key, which is itself encrypted to each age recipient and KMS key
+const (
+	sopsVersion           = "3.9.0"
+	sopsConfigFile        = ".sops.yaml"
+	sopsUnencryptedSuffix = "_unencrypted"
+	sopsDataKeySize       = 32
+	// sopsNonceSize is the GCM nonce size of SOPS, larger than the standard 12
+	sopsNonceSize = 32
+)
+
+// sopsFormats are the formats -sops can encrypt
+var sopsFormats = []string{"json", "yaml"}
+
+// sopsRecipients are the keys the data key of a SOPS file is encrypted to
+type sopsRecipients struct {
+	// age are age1... X25519 recipients
+	age []string
+	// kms are AWS KMS key IDs, ARNs or aliases
+	kms []string
+}
+
+// sopsKeyList is a list of keys of a creation rule, either comma-separated
+// or a YAML list
+type sopsKeyList []string
+
+func (l *sopsKeyList) UnmarshalYAML(node *yaml.Node) error {
+	var keys []string
+	if node.Kind == yaml.SequenceNode {
+		if err := node.Decode(&keys); err != nil {
+			return err
+		}
+	} else {
+		var s string
+		if err := node.Decode(&s); err != nil {
+			return err
+		}
+		keys = strings.Split(s, ",")
+	}
+	for _, key := range keys {
+		if key = strings.TrimSpace(key); key != "" {
+			*l = append(*l, key)
+		}
+	}
+	return nil
+}
+
+// sopsConfig is the part of a .sops.yaml the generator applies: the age and
+// KMS keys of its creation rules
+type sopsConfig struct {
+	CreationRules []struct {
+		PathRegex string      `yaml:"path_regex"`
+		Age       sopsKeyList `yaml:"age"`
+		KMS       sopsKeyList `yaml:"kms"`
+	} `yaml:"creation_rules"`
+}
+
+// findSOPSConfig returns the path of the .sops.yaml in the working directory
+// or the closest of its parents, as the sops CLI looks it up
+func findSOPSConfig() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, sopsConfigFile)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no %s found in the working directory or its parents", sopsConfigFile)
+		}
+		dir = parent
+	}
+}
+
+// sopsRulesRecipients returns the keys of the first creation rule of the
+// .sops.yaml found by findSOPSConfig whose path_regex matches filename,
+// relative to the directory of the .sops.yaml. Rules with key_groups, PGP or
+// other key types alone are not supported
+func sopsRulesRecipients(filename string) (sopsRecipients, error) {
+	path, err := findSOPSConfig()
+	if err != nil {
+		return sopsRecipients{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sopsRecipients{}, err
+	}
+	var config sopsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return sopsRecipients{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if abs, err := filepath.Abs(filename); err == nil {
+		filename = strings.TrimPrefix(abs, filepath.Dir(path)+string(filepath.Separator))
+	}
+	for _, rule := range config.CreationRules {
+		if rule.PathRegex != "" {
+			re, err := regexp.Compile(rule.PathRegex)
+			if err != nil {
+				return sopsRecipients{}, fmt.Errorf("%s: path_regex %q: %w", path, rule.PathRegex, err)
+			}
+			if !re.MatchString(filename) {
+				continue
+			}
+		}
+		if len(rule.Age) == 0 && len(rule.KMS) == 0 {
+			return sopsRecipients{}, fmt.Errorf("%s: the creation rule of %s has no age or kms keys", path, filename)
+		}
+		return sopsRecipients{age: rule.Age, kms: rule.KMS}, nil
+	}
+	return sopsRecipients{}, fmt.Errorf("%s: no creation rule matches %s", path, filename)
+}
+
+// sopsSeal encrypts value, of the SOPS type valueType, as an ENC[...] value
+// bound to additionalData
+func sopsSeal(key []byte, value, valueType, additionalData string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, sopsNonceSize)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, sopsNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, nonce, []byte(value), []byte(additionalData))
+	tag := len(sealed) - gcm.Overhead()
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(sealed[:tag]),
+		base64.StdEncoding.EncodeToString(nonce),
+		base64.StdEncoding.EncodeToString(sealed[tag:]),
+		valueType), nil
+}
+
+// sopsScalar returns the type of node as SOPS names it, and its value as
+// SOPS encrypts and authenticates it
+func sopsScalar(node *yaml.Node) (string, string, error) {
+	switch node.ShortTag() {
+	case "!!int":
+		var value int
+		if err := node.Decode(&value); err != nil {
+			return "", "", err
+		}
+		return "int", strconv.Itoa(value), nil
+	case "!!float":
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return "", "", err
+		}
+		return "float", strconv.FormatFloat(value, 'f', -1, 64), nil
+	case "!!bool":
+		var value bool
+		if err := node.Decode(&value); err != nil {
+			return "", "", err
+		}
+		// SOPS writes booleans as Python does
+		if value {
+			return "bool", "True", nil
+		}
+		return "bool", "False", nil
+	default:
+		return "str", node.Value, nil
+	}
+}
+
+// sopsEncryptNode encrypts the values under node in place, in document order,
+// each bound to the keys of its path, and adds them to the MAC
+func sopsEncryptNode(node *yaml.Node, path []string, key []byte, mac *bytes.Buffer) error {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		// Items of a list share the path of the list
+		for _, child := range node.Content {
+			if err := sopsEncryptNode(child, path, key, mac); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(node.Content); i += 2 {
+			if err := sopsEncryptNode(node.Content[i+1], append(path, node.Content[i].Value), key, mac); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if node.ShortTag() == "!!null" {
+			return nil
+		}
+		valueType, value, err := sopsScalar(node)
+		if err != nil {
+			return err
+		}
+		mac.WriteString(value)
+		for _, name := range path {
+			if strings.HasSuffix(name, sopsUnencryptedSuffix) {
+				return nil
+			}
+		}
+		// Empty strings are left empty
+		if value == "" {
+			return nil
+		}
+		if node.Value, err = sopsSeal(key, value, valueType, strings.Join(path, ":")+":"); err != nil {
+			return err
+		}
+		node.Tag = "!!str"
+		node.Style = 0
+	default:
+		return fmt.Errorf("unsupported YAML node kind %v", node.Kind)
+	}
+	return nil
+}
+
+// ageArmor encrypts plaintext to the X25519 recipient as an ASCII-armored age
+// file, the way SOPS stores data keys
+func ageArmor(plaintext []byte, recipient *age.X25519Recipient) (string, error) {
+	var buf strings.Builder
+	armored := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armored, recipient)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	if err := armored.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// kmsEncryptDataKey has KMS encrypt a SOPS data key under keyID, returning
+// the ARN of the key, which SOPS decrypts with, and the ciphertext
+func kmsEncryptDataKey(ctx context.Context, client *kms.Client, keyID string, dataKey []byte) (string, []byte, error) {
+	out, err := client.Encrypt(ctx, &kms.EncryptInput{KeyId: aws.String(keyID), Plaintext: dataKey})
+	if err != nil {
+		return "", nil, err
+	}
+	return aws.ToString(out.KeyId), out.CiphertextBlob, nil
+}
+
+// sopsEncrypt encrypts data, a document in format json or yaml, as a SOPS
+// file of the same format whose data key is encrypted to recipients
+func sopsEncrypt(data []byte, format string, recipients sopsRecipients) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("SOPS files must be a mapping")
+	}
+	root := doc.Content[0]
+
+	dataKey := make([]byte, sopsDataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	var mac bytes.Buffer
+	if err := sopsEncryptNode(root, nil, dataKey, &mac); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	metadata := &yaml.Node{Kind: yaml.MappingNode}
+	addField := func(node *yaml.Node, key string, value *yaml.Node) {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+	str := func(value string) *yaml.Node {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+
+	if len(recipients.kms) > 0 {
+		ctx := context.Background()
+		client, err := newKMSClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, keyID := range recipients.kms {
+			arn, blob, err := kmsEncryptDataKey(ctx, client, keyID, dataKey)
+			if err != nil {
+				return nil, fmt.Errorf("encrypting data key with %s: %w", keyID, err)
+			}
+			entry := &yaml.Node{Kind: yaml.MappingNode}
+			addField(entry, "arn", str(arn))
+			addField(entry, "created_at", str(now.Format(time.RFC3339)))
+			addField(entry, "enc", str(base64.StdEncoding.EncodeToString(blob)))
+			addField(entry, "aws_profile", str(""))
+			keys.Content = append(keys.Content, entry)
+		}
+		addField(metadata, "kms", keys)
+	}
+	if len(recipients.age) > 0 {
+		keys := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, recipient := range recipients.age {
+			key, err := parseAgeRecipient(recipient)
+			if err != nil {
+				return nil, err
+			}
+			armored, err := ageArmor(dataKey, key)
+			if err != nil {
+				return nil, err
+			}
+			entry := &yaml.Node{Kind: yaml.MappingNode}
+			addField(entry, "recipient", str(strings.TrimSpace(recipient)))
+			addField(entry, "enc", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: armored, Style: yaml.LiteralStyle})
+			keys.Content = append(keys.Content, entry)
+		}
+		addField(metadata, "age", keys)
+	}
+
+	// The MAC is the uppercase hex SHA-512 of the plaintext values, bound to
+	// the modification time
+	lastModified := now.Format(time.RFC3339)
+	sum := sha512.Sum512(mac.Bytes())
+	encryptedMAC, err := sopsSeal(dataKey, fmt.Sprintf("%X", sum), "str", lastModified)
+	if err != nil {
+		return nil, err
+	}
+	addField(metadata, "lastmodified", str(lastModified))
+	addField(metadata, "mac", str(encryptedMAC))
+	addField(metadata, "unencrypted_suffix", str(sopsUnencryptedSuffix))
+	addField(metadata, "version", str(sopsVersion))
+	addField(root, "sops", metadata)
+
+	if format == "json" {
+		var buf bytes.Buffer
+		if err := writeJSONNode(&buf, root); err != nil {
+			return nil, err
+		}
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		indented.WriteByte('\n')
+		return indented.Bytes(), nil
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSONNode writes node as compact JSON, keeping the order of mappings
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		start, end, step := byte('{'), byte('}'), 2
+		if node.Kind == yaml.SequenceNode {
+			start, end, step = '[', ']', 1
+		}
+		buf.WriteByte(start)
+		for i := 0; i < len(node.Content); i += step {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if step == 2 {
+				key, err := json.Marshal(node.Content[i].Value)
+				if err != nil {
+					return err
+				}
+				buf.Write(key)
+				buf.WriteByte(':')
+			}
+			if err := writeJSONNode(buf, node.Content[i+step-1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(end)
+	case yaml.ScalarNode:
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	default:
+		return fmt.Errorf("unsupported YAML node kind %v", node.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteOutputPrefix marks -output values naming a SQLite database
+const sqliteOutputPrefix = "sqlite:"
+
+// sqliteSchema creates the tables of the SQLite output, unless a previous run
+// already did. Each run of the generator adds a row to runs, a row per keypair
+// to keys, and its other values to metadata: per-key ones with their key_id,
+// and the run's own with a NULL key_id
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	key_type TEXT NOT NULL,
+	count INTEGER NOT NULL,
+	created_at TEXT NOT NULL,
+	insecure INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS keys (
+	id INTEGER PRIMARY KEY,
+	run_id INTEGER NOT NULL REFERENCES runs (id),
+	idx INTEGER NOT NULL,
+	address TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	derivation_path TEXT
+);
+CREATE TABLE IF NOT EXISTS metadata (
+	run_id INTEGER NOT NULL REFERENCES runs (id),
+	key_id INTEGER REFERENCES keys (id),
+	name TEXT NOT NULL,
+	value TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS keys_address ON keys (address);
+CREATE INDEX IF NOT EXISTS keys_run_id ON keys (run_id);
+CREATE INDEX IF NOT EXISTS metadata_key_id ON metadata (key_id);
+CREATE INDEX IF NOT EXISTS metadata_run_id_name ON metadata (run_id, name);
+`
+
+// resultMetadata returns the fields of result that describe the whole run,
+// as strings or JSON, by their json names
+func resultMetadata(result KeyGenResult) (map[string]string, error) {
+	result.PrivateKeys, result.PublicKeys, result.Extra = nil, nil, nil
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]string)
+	for name, raw := range fields {
+		// These have columns of their own
+		if slices.Contains([]string{"keyType", "count", "timestamp", "insecure", "privateKeys", "publicKeys"}, name) {
+			continue
+		}
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			metadata[name] = s
+		} else {
+			metadata[name] = string(raw)
+		}
+	}
+	return metadata, nil
+}
+
+// createSQLiteFile creates a missing database at path as an empty file with
+// filePerm, as SQLite would create it with 0644 under the usual umask. SQLite
+// opens an empty file as a new database and gives its journals the same mode.
+// An existing database is left as it is, since runs are added to it
+func createSQLiteFile(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePerm)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Chmod(filePerm); err != nil {
+		f.Close()
+		return err
+	}
+	return closeOutputFile(f)
+}
+
+// writeSQLite inserts result as a new run of the SQLite database at path,
+// created if missing, with its keys numbered from startIndex. The whole run is
+// one transaction, so a failed run leaves no partial rows behind
+func writeSQLite(path string, result KeyGenResult, startIndex int) (int64, error) {
+	if err := createSQLiteFile(path); err != nil {
+		return 0, err
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return 0, err
+	}
+	runMetadata, err := resultMetadata(result)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("INSERT INTO runs (key_type, count, created_at, insecure) VALUES (?, ?, ?, ?)",
+		result.KeyType, result.Count, result.Timestamp, result.Insecure)
+	if err != nil {
+		return 0, err
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	insertKey, err := tx.Prepare("INSERT INTO keys (run_id, idx, address, private_key, derivation_path) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer insertKey.Close()
+	insertMetadata, err := tx.Prepare("INSERT INTO metadata (run_id, key_id, name, value) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return 0, err
+	}
+	defer insertMetadata.Close()
+
+	for name, value := range runMetadata {
+		if _, err := insertMetadata.Exec(runID, nil, name, value); err != nil {
+			return 0, err
+		}
+	}
+
+	paths := result.Extra["derivationPath"]
+	for i, publicKey := range result.PublicKeys {
+		var privateKey string
+		if i < len(result.PrivateKeys) {
+			privateKey = result.PrivateKeys[i]
+		}
+		var path any
+		if i < len(paths) {
+			path = paths[i]
+		}
+		res, err := insertKey.Exec(runID, startIndex+i, publicKey, privateKey, path)
+		if err != nil {
+			return 0, err
+		}
+		keyID, err := res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		for name, values := range result.Extra {
+			if name == "derivationPath" || i >= len(values) {
+				continue
+			}
+			if _, err := insertMetadata.Exec(runID, keyID, name, values[i]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return runID, tx.Commit()
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+
+	starkcurve "github.com/consensys/gnark-crypto/ecc/stark-curve"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fr"
+)
+
+// starkN is the order of the STARK curve's generator, which private keys are
+// drawn below
+var starkN = fr.Modulus()
+
+// starknetAddressHook, when set, computes the counterfactual account
+// contract address for a public key and is reported alongside each key
+var starknetAddressHook func(publicKey *big.Int) (*big.Int, error)
+
+func mustHexInt(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex constant: " + s)
+	}
+	return n
+}
+
+// formatFelt renders a field element as a 0x-prefixed, 64-digit hex string
+func formatFelt(n *big.Int) string {
+	return fmt.Sprintf("0x%064x", n)
+}
+
+// starknetPublicKey returns the coordinates of privateKey*G
+func starknetPublicKey(privateKey *big.Int) (x, y *big.Int) {
+	var pub starkcurve.G1Affine
+	pub.ScalarMultiplicationBase(privateKey)
+	return pub.X.BigInt(new(big.Int)), pub.Y.BigInt(new(big.Int))
+}
+
+// generateStarknetKeyPair returns a private key on the STARK curve and its
+// public key, the x coordinate of k*G; the y coordinate is kept as an extra
+func generateStarknetKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	var privateKey *big.Int
+	for {
+		k, err := rand.Int(random, starkN)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if k.Sign() != 0 {
+			privateKey = k
+			break
+		}
+	}
+
+	x, y := starknetPublicKey(privateKey)
+
+	extra := keyExtras{
+		"publicKeyY": publicExtra(formatFelt(y)),
+	}
+
+	if starknetAddressHook != nil {
+		address, err := starknetAddressHook(x)
+		if err != nil {
+			return "", "", nil, fmt.Errorf("error computing account address: %w", err)
+		}
+		extra["accountAddress"] = publicExtra(formatFelt(address))
+	}
+
+	return formatFelt(privateKey), formatFelt(x), extra, nil
+}
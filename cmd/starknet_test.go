@@ -0,0 +1,11 @@
+package main
+
+import "testing"
+
+// The getStarkKey example of starknet.js
+func TestStarknetPublicKey(t *testing.T) {
+	x, _ := starknetPublicKey(mustHexInt("019800ea6a9a73f94aee6a3d2edf018fc770443e90c7ba121e8303ec6b349279"))
+	if publicKey := formatFelt(x); publicKey != "0x033f45f07e1bd1a51b45fc24ec8c8c9908db9e42191be9e169bfcac0c0d99745" {
+		t.Errorf("starknetPublicKey = %s", publicKey)
+	}
+}
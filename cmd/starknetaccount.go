@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	pedersenhash "github.com/consensys/gnark-crypto/ecc/stark-curve/pedersen-hash"
+)
+
+// starknetContractAddressPrefix is the felt encoding of the ASCII string
+// "STARKNET_CONTRACT_ADDRESS"
+var starknetContractAddressPrefix = new(big.Int).SetBytes([]byte("STARKNET_CONTRACT_ADDRESS"))
+
+// starknetAddressBound is 2^251 - 256, the bound addresses are reduced by
+var starknetAddressBound = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 251), big.NewInt(256))
+
+// starknetAccountPreset is the class and constructor of a wallet's account
+// contract; calldata entries named publicKey take the account's public key
+type starknetAccountPreset struct {
+	classHash string
+	calldata  []string
+}
+
+// starknetAccountPresets maps -account-preset values to the account classes
+// the wallets deploy
+var starknetAccountPresets = map[string]starknetAccountPreset{
+	// OpenZeppelin account v0.8.1, constructor(public_key)
+	"openzeppelin": {classHash: "0x061dac032f228abef9c6626f995015233097ae253a7f72d68552db02f2971b8f", calldata: []string{"publicKey"}},
+	// Argent X account v0.4.0, constructor(owner: Signer::Starknet(public_key), guardian: Option::None)
+	"argent": {classHash: "0x036078334509b514626504edc9fb252328d1a240e4e948bef8d0c08dff45927f", calldata: []string{"0", "publicKey", "1"}},
+	// Braavos base account, constructor(public_key), upgraded to the full
+	// account class by the deployment transaction
+	"braavos": {classHash: "0x03d16c7a9a60b0593bd202f660a28c5d76e0403601d9ccc7e4fa253b6a70c201", calldata: []string{"publicKey"}},
+}
+
+// starknetPedersenArray hashes a list of felts with StarkWare's Pedersen
+// hash as h(h(...h(0, e1)...), n)
+func starknetPedersenArray(elements []*big.Int) *big.Int {
+	felts := make([]*fp.Element, len(elements))
+	for i, e := range elements {
+		felts[i] = new(fp.Element).SetBigInt(e)
+	}
+	h := pedersenhash.PedersenArray(felts...)
+	return h.BigInt(new(big.Int))
+}
+
+// starknetContractAddress computes the address of a contract deployed by a
+// DEPLOY_ACCOUNT transaction, whose deployer is zero
+func starknetContractAddress(salt, classHash *big.Int, calldata []*big.Int) *big.Int {
+	address := starknetPedersenArray([]*big.Int{
+		starknetContractAddressPrefix,
+		new(big.Int),
+		salt,
+		classHash,
+		starknetPedersenArray(calldata),
+	})
+	return address.Mod(address, starknetAddressBound)
+}
+
+// parseFelt parses a 0x hex or decimal felt
+func parseFelt(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(strings.TrimSpace(s), 0)
+	if !ok || n.Sign() < 0 || n.Cmp(fp.Modulus()) >= 0 {
+		return nil, fmt.Errorf("invalid felt: %q", s)
+	}
+	return n, nil
+}
+
+// starknetAccountAddressFunc returns a starknetAddressHook computing the
+// counterfactual address of an account of classHash, salted with the public
+// key, whose constructor takes calldata
+func starknetAccountAddressFunc(classHash string, calldata []string) (func(publicKey *big.Int) (*big.Int, error), error) {
+	class, err := parseFelt(classHash)
+	if err != nil {
+		return nil, fmt.Errorf("class hash: %w", err)
+	}
+	for _, entry := range calldata {
+		if entry == "publicKey" {
+			continue
+		}
+		if _, err := parseFelt(entry); err != nil {
+			return nil, fmt.Errorf("constructor calldata: %w", err)
+		}
+	}
+
+	return func(publicKey *big.Int) (*big.Int, error) {
+		felts := make([]*big.Int, 0, len(calldata))
+		for _, entry := range calldata {
+			if entry == "publicKey" {
+				felts = append(felts, publicKey)
+				continue
+			}
+			felt, err := parseFelt(entry)
+			if err != nil {
+				return nil, err
+			}
+			felts = append(felts, felt)
+		}
+		return starknetContractAddress(publicKey, class, felts), nil
+	}, nil
+}
@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+// The contract address example of the Starknet documentation, a contract
+// deployed with no constructor calldata
+func TestStarknetContractAddress(t *testing.T) {
+	salt := mustHexInt("5bebda1b28ba6daa824126577b9fbc984033e8b18360f5e1ef694cb172c7aa5")
+	classHash := mustHexInt("0439218681f9108b470d2379cf589ef47e60dc5888ee49ec70071671d74ca9c6")
+	if address := formatFelt(starknetContractAddress(salt, classHash, nil)); address != "0x043c6817e70b3fd99a4f120790b2e82c6843df62b573fdadf9e2d677b60ac5eb" {
+		t.Errorf("starknetContractAddress = %s", address)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/ChainSafe/go-schnorrkel"
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	ss58ChecksumPrefix = "SS58PRE"
+	maxSS58Prefix      = 16383
+)
+
+// substrateSchemes lists the -scheme values accepted for the substrate type
+var substrateSchemes = []string{"sr25519", "ed25519"}
+
+// ss58Encode encodes a 32-byte public key as an SS58 address for the given
+// network prefix, using the two-byte prefix form above 63
+func ss58Encode(pubKey []byte, prefix uint16) (string, error) {
+	if prefix > maxSS58Prefix {
+		return "", fmt.Errorf("ss58 prefix must be at most %d", maxSS58Prefix)
+	}
+
+	var payload []byte
+	if prefix < 64 {
+		payload = []byte{byte(prefix)}
+	} else {
+		payload = []byte{
+			byte((prefix&0xfc)>>2) | 0x40,
+			byte(prefix>>8) | byte((prefix&0x03)<<6),
+		}
+	}
+	payload = append(payload, pubKey...)
+
+	hash := blake2b.Sum512(append([]byte(ss58ChecksumPrefix), payload...))
+
+	return base58.Encode(append(payload, hash[:2]...)), nil
+}
+
+// generateSubstrateKeyPair returns the hex secret seed, as printed by subkey,
+// and the SS58 address for the chosen signature scheme
+func generateSubstrateKeyPair(random io.Reader, scheme string, prefix uint16) (string, string, keyExtras, error) {
+	seed := make([]byte, 32)
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return "", "", nil, err
+	}
+
+	var pubKey []byte
+	switch scheme {
+	case "sr25519":
+		miniSecret, err := schnorrkel.NewMiniSecretKeyFromRaw([32]byte(seed))
+		if err != nil {
+			return "", "", nil, err
+		}
+		encoded := miniSecret.Public().Encode()
+		pubKey = encoded[:]
+	case "ed25519":
+		pubKey = ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	default:
+		return "", "", nil, fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	address, err := ss58Encode(pubKey, prefix)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	extra := keyExtras{
+		"publicKey": publicExtra("0x" + hex.EncodeToString(pubKey)),
+	}
+
+	return "0x" + hex.EncodeToString(seed), address, extra, nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+// suiAlias is an entry of sui.aliases, which names the keys of the
+// sui.keystore next to it
+type suiAlias struct {
+	Alias           string `json:"alias"`
+	PublicKeyBase64 string `json:"public_key_base64"`
+}
+
+// decodeSuiPrivateKey returns the scheme flag and secret of a suiprivkey key
+func decodeSuiPrivateKey(privateKey string) (byte, []byte, error) {
+	if err := validateSuiPrivateKey(privateKey); err != nil {
+		return 0, nil, err
+	}
+	_, data, _ := bech32.Decode(privateKey)
+	converted, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	return converted[0], converted[1:], nil
+}
+
+// readSuiJSON decodes the JSON file at path into v, reporting whether it exists
+func readSuiJSON(path string, v any) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// writeSuiJSON writes v as indented JSON, as the sui CLI does, keeping the
+// mode of an existing file
+func writeSuiJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	mode := fs.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, data, mode)
+}
+
+// appendSuiKeystore adds the keys to the sui.keystore at path, a JSON array of
+// base64 flag || secret entries, creating it if missing. Each key is also
+// named in the sui.aliases file next to it, which the sui CLI looks keys up
+// in, unless the keystore predates aliases. Keys already in the keystore are
+// skipped; the number of keys added is returned
+func appendSuiKeystore(path string, privateKeys, addresses []string) (int, error) {
+	var keys []string
+	keystoreExists, err := readSuiJSON(path, &keys)
+	if err != nil {
+		return 0, err
+	}
+	aliasesPath := strings.TrimSuffix(path, ".keystore") + ".aliases"
+	var aliases []suiAlias
+	aliasesExist, err := readSuiJSON(aliasesPath, &aliases)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for i, privateKey := range privateKeys {
+		schemeFlag, secret, err := decodeSuiPrivateKey(privateKey)
+		if err != nil {
+			return 0, err
+		}
+		entry := base64.StdEncoding.EncodeToString(append([]byte{schemeFlag}, secret...))
+		if slices.Contains(keys, entry) {
+			continue
+		}
+		keys = append(keys, entry)
+		added++
+
+		pubKey, err := suiPublicKey(schemeFlag, secret)
+		if err != nil {
+			return 0, err
+		}
+		// Aliases must start with a letter; the address keeps them unique
+		aliases = append(aliases, suiAlias{
+			Alias:           "generated-" + strings.TrimPrefix(addresses[i], "0x")[:8],
+			PublicKeyBase64: base64.StdEncoding.EncodeToString(append([]byte{schemeFlag}, pubKey...)),
+		})
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	if aliases == nil {
+		aliases = []suiAlias{}
+	}
+
+	if err := writeSuiJSON(path, keys); err != nil {
+		return 0, err
+	}
+	if aliasesExist || !keystoreExists {
+		if err := writeSuiJSON(aliasesPath, aliases); err != nil {
+			return 0, err
+		}
+	}
+	return added, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateData is what -template files are executed with: the fields of
+// the json format, and the keypairs one by one as .Keys
+type templateData struct {
+	KeyGenResult
+	Keys []keyRecord
+}
+
+// templateFuncs quote values for the formats templates usually emit
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// shell quotes a word for POSIX shells
+	"shell": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	},
+	// sql quotes a standard SQL string literal
+	"sql": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"join":  strings.Join,
+}
+
+// parseTemplate reads the -template file at path. Referring to a missing
+// extra is an error rather than an empty string
+func parseTemplate(path string) (*template.Template, error) {
+	return template.New(filepath.Base(path)).Funcs(templateFuncs).Option("missingkey=error").ParseFiles(path)
+}
+
+// templateExtension returns the extension of the files rendered by the
+// template at path: its own, less a .tmpl suffix, e.g. sh for exports.sh.tmpl
+func templateExtension(path string) string {
+	extension := strings.TrimPrefix(filepath.Ext(strings.TrimSuffix(filepath.Base(path), ".tmpl")), ".")
+	if extension == "" {
+		return "txt"
+	}
+	return extension
+}
+
+// keyRecords splits result into its keypairs, numbered from startIndex
+func keyRecords(result KeyGenResult, startIndex int) []keyRecord {
+	records := make([]keyRecord, len(result.PublicKeys))
+	for i, publicKey := range result.PublicKeys {
+		records[i] = keyRecord{Index: startIndex + i, Type: result.KeyType, Address: publicKey, Mnemonic: result.Mnemonic, Insecure: result.Insecure}
+		if i < len(result.PrivateKeys) {
+			records[i].PrivateKey = result.PrivateKeys[i]
+		}
+		for name, values := range result.Extra {
+			if i < len(values) {
+				if records[i].Extra == nil {
+					records[i].Extra = make(map[string]string)
+				}
+				records[i].Extra[name] = values[i]
+			}
+		}
+	}
+	return records
+}
+
+// renderTemplate executes tmpl with result
+func renderTemplate(tmpl *template.Template, result KeyGenResult, startIndex int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{KeyGenResult: result, Keys: keyRecords(result, startIndex)}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
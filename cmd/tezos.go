@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/mr-tron/base58"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Tezos base58check prefixes, chosen so the encoded strings start with the
+// familiar edsk/edpk/tz1 and spsk/sppk/tz2 markers
+var (
+	tezosEd25519SeedPrefix     = []byte{13, 15, 58, 7}
+	tezosEd25519PubKeyPrefix   = []byte{13, 15, 37, 217}
+	tezosEd25519HashPrefix     = []byte{6, 161, 159}
+	tezosSecp256k1SecretPrefix = []byte{17, 162, 224, 201}
+	tezosSecp256k1PubKeyPrefix = []byte{3, 254, 226, 86}
+	tezosSecp256k1HashPrefix   = []byte{6, 161, 161}
+)
+
+// tezosSchemes lists the -scheme values accepted for the tezos type
+var tezosSchemes = []string{"ed25519", "secp256k1"}
+
+// base58CheckEncode encodes prefix||payload followed by the first four bytes
+// of its double SHA-256
+func base58CheckEncode(prefix, payload []byte) string {
+	data := append(append([]byte{}, prefix...), payload...)
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	return base58.Encode(append(data, second[:4]...))
+}
+
+// generateTezosKeyPair returns an edsk or spsk secret key and the matching tz1
+// or tz2 public key hash, with the edpk or sppk public key as an extra
+func generateTezosKeyPair(random io.Reader, scheme string) (string, string, keyExtras, error) {
+	var secret, pubKey []byte
+	var secretPrefix, pubKeyPrefix, hashPrefix []byte
+
+	switch scheme {
+	case "ed25519":
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, seed); err != nil {
+			return "", "", nil, err
+		}
+		secret = seed
+		pubKey = ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+		secretPrefix, pubKeyPrefix, hashPrefix = tezosEd25519SeedPrefix, tezosEd25519PubKeyPrefix, tezosEd25519HashPrefix
+	case "secp256k1":
+		privateKey, err := newSecp256k1Key(random)
+		if err != nil {
+			return "", "", nil, err
+		}
+		secret = privateKey.Serialize()
+		pubKey = privateKey.PubKey().SerializeCompressed()
+		secretPrefix, pubKeyPrefix, hashPrefix = tezosSecp256k1SecretPrefix, tezosSecp256k1PubKeyPrefix, tezosSecp256k1HashPrefix
+	default:
+		return "", "", nil, fmt.Errorf("unsupported scheme: %s", scheme)
+	}
+
+	h, err := blake2b.New(20, nil)
+	if err != nil {
+		return "", "", nil, err
+	}
+	h.Write(pubKey)
+
+	extra := keyExtras{
+		"publicKey": publicExtra(base58CheckEncode(pubKeyPrefix, pubKey)),
+	}
+
+	return base58CheckEncode(secretPrefix, secret), base58CheckEncode(hashPrefix, h.Sum(nil)), extra, nil
+}
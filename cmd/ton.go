@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/xssnick/tonutils-go/ton/wallet"
+)
+
+const (
+	tonMainnetGlobalID = -239
+	tonTestnetGlobalID = -3
+)
+
+// tonWalletVersions lists the values accepted by the -wallet-version flag
+var tonWalletVersions = []string{"v3r2", "v4r2", "v5"}
+
+// tonWalletConfig resolves a wallet version name to its contract config and
+// the subwallet ID wallets use by default for that version
+func tonWalletConfig(version string, workchain int8, testnet bool) (wallet.VersionConfig, uint32, error) {
+	switch version {
+	case "v3r2":
+		return wallet.V3R2, uint32(wallet.DefaultSubwallet + int32(workchain)), nil
+	case "v4r2":
+		return wallet.V4R2, uint32(wallet.DefaultSubwallet + int32(workchain)), nil
+	case "v5":
+		networkID := int32(tonMainnetGlobalID)
+		if testnet {
+			networkID = tonTestnetGlobalID
+		}
+		return wallet.ConfigV5R1Final{NetworkGlobalID: networkID, Workchain: workchain}, 0, nil
+	default:
+		return nil, 0, fmt.Errorf("unsupported wallet version: %s", version)
+	}
+}
+
+// generateTONKeyPair returns a hex ed25519 seed and the non-bounceable
+// user-friendly address of the wallet contract, with the raw and bounceable forms as extras
+func generateTONKeyPair(random io.Reader, version string, workchain int8, testnet bool) (string, string, keyExtras, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return "", "", nil, err
+	}
+
+	pubKey := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+
+	config, subwallet, err := tonWalletConfig(version, workchain, testnet)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	addr, err := wallet.AddressFromPubKey(pubKey, config, subwallet, workchain)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error computing wallet address: %w", err)
+	}
+	addr = addr.Testnet(testnet)
+
+	extra := keyExtras{
+		"rawAddress":        publicExtra(addr.StringRaw()),
+		"bounceableAddress": publicExtra(addr.Bounce(true).String()),
+		"publicKey":         publicExtra(hex.EncodeToString(pubKey)),
+	}
+
+	return hex.EncodeToString(seed), addr.Bounce(false).String(), extra, nil
+}
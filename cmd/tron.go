@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/mr-tron/base58"
+)
+
+const tronAddressPrefix = 0x41
+
+// tronAddress returns the base58check T-address and the 0x41-prefixed hex
+// form for a secp256k1 public key, reusing the EVM keccak address bytes
+func tronAddress(publicKey ecdsa.PublicKey) (string, string) {
+	evmAddress := crypto.PubkeyToAddress(publicKey)
+
+	payload := append([]byte{tronAddressPrefix}, evmAddress.Bytes()...)
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+
+	return base58.Encode(append(payload, second[:4]...)), hex.EncodeToString(payload)
+}
+
+func generateTronKeyPair(random io.Reader) (string, string, keyExtras, error) {
+	privateKey, err := newEthereumKey(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return tronKeyPair(privateKey)
+}
+
+// tronKeyPair returns the hex private key and T-address of a key, with the
+// hex address as an extra
+func tronKeyPair(privateKey *ecdsa.PrivateKey) (string, string, keyExtras, error) {
+	address, hexAddress := tronAddress(privateKey.PublicKey)
+	extra := keyExtras{
+		"hexAddress": publicExtra(hexAddress),
+	}
+
+	return hex.EncodeToString(crypto.FromECDSA(privateKey)), address, extra, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+// -output can name an object of an S3 or GCS bucket instead of a file, so
+// the results are uploaded without ever touching the disk
+const (
+	s3OutputPrefix  = "s3://"
+	gcsOutputPrefix = "gs://"
+)
+
+// Server-side encryption of uploaded results
+var (
+	// uploadSSE is the S3 server-side encryption, one of s3SSEAlgorithms, or
+	// the bucket's default if empty
+	uploadSSE string
+	// uploadKMSKey is the AWS KMS key of aws:kms encryption on S3, or the
+	// Cloud KMS key of the object on GCS
+	uploadKMSKey string
+)
+
+// s3SSEAlgorithms are the values of -sse
+var s3SSEAlgorithms = []string{"AES256", "aws:kms", "aws:kms:dsse"}
+
+// isBucketOutput reports whether output names an S3 or GCS object or prefix
+func isBucketOutput(output string) bool {
+	return strings.HasPrefix(output, s3OutputPrefix) || strings.HasPrefix(output, gcsOutputPrefix)
+}
+
+// bucketOutputName returns the object output names: output itself, or name
+// under it if output is a bucket or a prefix ending in /
+func bucketOutputName(output, name string) string {
+	_, rest, _ := strings.Cut(output, "://")
+	if !strings.Contains(rest, "/") {
+		return output + "/" + name
+	}
+	if strings.HasSuffix(output, "/") {
+		return output + name
+	}
+	return output
+}
+
+// splitBucketOutput returns the bucket and object key of an s3:// or gs://
+// output
+func splitBucketOutput(output string) (string, string, error) {
+	_, rest, _ := strings.Cut(output, "://")
+	bucket, key, _ := strings.Cut(rest, "/")
+	if bucket == "" || key == "" || strings.HasSuffix(key, "/") {
+		return "", "", fmt.Errorf("%s does not name a bucket and an object", output)
+	}
+	return bucket, key, nil
+}
+
+// uploadResults uploads data to the object of an s3:// or gs:// output. An
+// object that already exists is never overwritten
+func uploadResults(output string, data []byte) error {
+	bucket, key, err := splitBucketOutput(output)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(output, s3OutputPrefix) {
+		return uploadS3(bucket, key, data)
+	}
+	return uploadGCS(bucket, key, data)
+}
+
+// uploadS3 puts data as the object key of bucket, with the default AWS
+// configuration and uploadSSE and uploadKMSKey. AWS_ENDPOINT_URL_S3 or
+// AWS_ENDPOINT_URL overrides the endpoint, e.g. for MinIO, with path-style
+// addressing
+func uploadS3(bucket, key string, data []byte) error {
+	ctx := context.Background()
+	cfg, err := loadAWSConfig(ctx)
+	if err != nil {
+		return err
+	}
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = os.Getenv("AWS_ENDPOINT_URL_S3") != "" || os.Getenv("AWS_ENDPOINT_URL") != ""
+	})
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/octet-stream"),
+		IfNoneMatch: aws.String("*"),
+	}
+	sse := uploadSSE
+	if sse == "" && uploadKMSKey != "" {
+		sse = "aws:kms"
+	}
+	if sse != "" {
+		input.ServerSideEncryption = s3types.ServerSideEncryption(sse)
+	}
+	if uploadKMSKey != "" {
+		input.SSEKMSKeyId = aws.String(uploadKMSKey)
+	}
+	_, err = client.PutObject(ctx, input)
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusPreconditionFailed {
+		return fmt.Errorf("s3://%s/%s already exists", bucket, key)
+	}
+	return err
+}
+
+// uploadGCS creates the object of bucket from data, with Application
+// Default Credentials and, if set, the Cloud KMS key uploadKMSKey.
+// STORAGE_EMULATOR_HOST overrides the endpoint, as for the GCS client
+// libraries
+func uploadGCS(bucket, object string, data []byte) error {
+	ctx := context.Background()
+	var opts []option.ClientOption
+	if host := os.Getenv("STORAGE_EMULATOR_HOST"); host != "" {
+		endpoint := strings.TrimRight(host, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+		opts = []option.ClientOption{option.WithEndpoint(endpoint + "/storage/v1/"), option.WithoutAuthentication()}
+	} else {
+		var err error
+		if opts, err = gcpClientOptions(ctx); err != nil {
+			return err
+		}
+	}
+	service, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	call := service.Objects.Insert(bucket, &storage.Object{Name: object}).
+		IfGenerationMatch(0).
+		Media(bytes.NewReader(data), googleapi.ContentType("application/octet-stream")).
+		Context(ctx)
+	if uploadKMSKey != "" {
+		call = call.KmsKeyName(uploadKMSKey)
+	}
+	_, err = call.Do()
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("gs://%s/%s already exists", bucket, object)
+	}
+	return err
+}
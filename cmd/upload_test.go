@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUploadS3(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	withAWSEndpoint(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	})
+	uploadSSE, uploadKMSKey = "", "alias/keys"
+	t.Cleanup(func() { uploadSSE, uploadKMSKey = "", "" })
+
+	if err := uploadS3("keys", "runs/evm keys.json", []byte(`{"count":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPut || got.URL.Path != "/keys/runs/evm keys.json" {
+		t.Fatalf("%s %s, want a path-style PUT of the object", got.Method, got.URL.Path)
+	}
+	for header, want := range map[string]string{
+		"If-None-Match":                               "*",
+		"X-Amz-Server-Side-Encryption":                "aws:kms",
+		"X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id": "alias/keys",
+	} {
+		if value := got.Header.Get(header); value != want {
+			t.Errorf("%s = %q, want %q", header, value, want)
+		}
+	}
+	if !strings.HasPrefix(got.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+		t.Errorf("Authorization %q is not signed with the credentials", got.Header.Get("Authorization"))
+	}
+	if !strings.Contains(string(body), `{"count":1}`) {
+		t.Errorf("body %q does not hold the object", body)
+	}
+}
+
+func TestUploadS3RefusesExistingObjects(t *testing.T) {
+	withAWSEndpoint(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		io.WriteString(w, `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+	})
+	err := uploadS3("keys", "evm.json", []byte("{}"))
+	if err == nil || err.Error() != "s3://keys/evm.json already exists" {
+		t.Fatalf("uploadS3 = %v, want an already exists error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// utxoNetworks holds the address and WIF version bytes of Bitcoin-derivative
+// chains, keyed by key type and then by -network value; chains without
+// segwit leave Bech32HRPSegwit empty
+var utxoNetworks = map[string]map[string]*chaincfg.Params{
+	"litecoin": {
+		"mainnet": {Name: "litecoin", PubKeyHashAddrID: 0x30, ScriptHashAddrID: 0x32, PrivateKeyID: 0xb0, Bech32HRPSegwit: "ltc"},
+		"testnet": {Name: "litecoin-testnet", PubKeyHashAddrID: 0x6f, ScriptHashAddrID: 0x3a, PrivateKeyID: 0xef, Bech32HRPSegwit: "tltc"},
+	},
+	"dogecoin": {
+		"mainnet": {Name: "dogecoin", PubKeyHashAddrID: 0x1e, ScriptHashAddrID: 0x16, PrivateKeyID: 0x9e},
+		"testnet": {Name: "dogecoin-testnet", PubKeyHashAddrID: 0x71, ScriptHashAddrID: 0xc4, PrivateKeyID: 0xf1},
+	},
+}
+
+// generateUTXOKeyPair returns a WIF key and the native segwit address for
+// chains that support it, or the legacy P2PKH address otherwise
+func generateUTXOKeyPair(random io.Reader, params *chaincfg.Params) (string, string, keyExtras, error) {
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	wif, err := btcutil.NewWIF(privateKey, params, true)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+	pubKeyHash := btcutil.Hash160(pubKeyBytes)
+
+	p2pkh, err := btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating p2pkh address: %w", err)
+	}
+
+	extra := keyExtras{
+		"publicKey": publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	if params.Bech32HRPSegwit == "" {
+		return wif.String(), p2pkh.EncodeAddress(), extra, nil
+	}
+
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error creating p2wpkh address: %w", err)
+	}
+	extra["legacyAddress"] = publicExtra(p2pkh.EncodeAddress())
+
+	return wif.String(), p2wpkh.EncodeAddress(), extra, nil
+}
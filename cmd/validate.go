@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxTypoDistance is the edit distance up to which a wordlist word is
+// suggested for a misspelled one
+const maxTypoDistance = 2
+
+// wordDistance is the optimal string alignment distance of a and b: the
+// insertions, deletions, substitutions and transpositions of adjacent letters
+// turning one into the other
+func wordDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+// typoCorrection is a replacement of the word at a position of a mnemonic
+type typoCorrection struct {
+	position int
+	word     string
+	distance int
+}
+
+// closeWords returns the wordlist words within maxTypoDistance of word,
+// closest first
+func closeWords(word string) []typoCorrection {
+	var corrections []typoCorrection
+	for _, candidate := range bip39.GetWordList() {
+		if distance := wordDistance(word, candidate); distance <= maxTypoDistance {
+			corrections = append(corrections, typoCorrection{word: candidate, distance: distance})
+		}
+	}
+	slices.SortStableFunc(corrections, func(a, b typoCorrection) int { return a.distance - b.distance })
+	return corrections
+}
+
+// checksumCorrections returns the replacements of one word of words, among
+// those close to it, that give the mnemonic a valid checksum; only the word
+// at position is tried when it is not negative
+func checksumCorrections(words []string, position int) []typoCorrection {
+	var corrections []typoCorrection
+	candidate := slices.Clone(words)
+	for i, word := range words {
+		if position >= 0 && i != position {
+			continue
+		}
+		for _, correction := range closeWords(word) {
+			if correction.word == word {
+				continue
+			}
+			candidate[i] = correction.word
+			if bip39.IsMnemonicValid(strings.Join(candidate, " ")) {
+				correction.position = i
+				corrections = append(corrections, correction)
+			}
+		}
+		candidate[i] = word
+	}
+	slices.SortStableFunc(corrections, func(a, b typoCorrection) int { return a.distance - b.distance })
+	return corrections
+}
+
+// masterFingerprint returns the BIP32 fingerprint of the master key of seed,
+// as wallets and PSBTs identify a seed by
+func masterFingerprint(seed []byte) (string, error) {
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return "", err
+	}
+	publicKey, err := master.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(btcutil.Hash160(publicKey.SerializeCompressed())[:4]), nil
+}
+
+func runMnemonic(args []string) {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Println("Usage: mnemonic validate [flags]")
+		os.Exit(1)
+	}
+	runMnemonicValidate(args[1:])
+}
+
+func runMnemonicValidate(args []string) {
+	fs := flag.NewFlagSet("mnemonic validate", flag.ExitOnError)
+	phrase := fs.String("phrase", "-", "BIP39 mnemonic to validate, or '-' to prompt for it")
+	mnemonicFile := fs.String("mnemonic-file", "", "Validate the BIP39 mnemonic in this file instead")
+	wordlist := fs.String("wordlist", "english", "Language of the mnemonic: english, japanese, korean, spanish, chinese-simplified, chinese-traditional, french, italian or czech")
+	usePassphrase := fs.Bool("passphrase", false, "Prompt for a BIP39 passphrase to compute the fingerprint under")
+	passphraseFile := fs.String("passphrase-file", "", "Read the BIP39 passphrase from this file")
+	passphraseEnv := fs.String("passphrase-env", "", "Read the BIP39 passphrase from this environment variable")
+	fs.Parse(args)
+
+	if err := setMnemonicWordlist(*wordlist); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	text, err := readMnemonicPhrase(*phrase, *mnemonicFile)
+	if err != nil {
+		fmt.Printf("Error reading mnemonic: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Normalize as readMnemonic does when importing
+	words := strings.Fields(strings.ToLower(norm.NFKD.String(text)))
+	valid := true
+	if !slices.Contains(mnemonicWordCounts, len(words)) {
+		fmt.Printf("Word count: %d, expected 12, 15, 18, 21 or 24\n", len(words))
+		valid = false
+	}
+
+	var unknown []int
+	for i, word := range words {
+		if _, ok := bip39.GetWordIndex(word); ok {
+			continue
+		}
+		unknown = append(unknown, i)
+		fmt.Printf("Word %d %q is not in the %s wordlist", i+1, word, *wordlist)
+		if suggestions := closeWords(word); len(suggestions) > 0 {
+			names := make([]string, 0, len(suggestions))
+			for _, suggestion := range suggestions {
+				names = append(names, suggestion.word)
+			}
+			fmt.Printf(", did you mean %s?", strings.Join(names, ", "))
+		}
+		fmt.Println()
+		valid = false
+	}
+
+	// A single typo can only be repaired through the checksum when the rest
+	// of the phrase is intact
+	if valid && !bip39.IsMnemonicValid(strings.Join(words, " ")) {
+		fmt.Println("Checksum: invalid")
+		valid = false
+	}
+	if !valid && len(unknown) <= 1 && slices.Contains(mnemonicWordCounts, len(words)) {
+		position := -1
+		if len(unknown) == 1 {
+			position = unknown[0]
+		}
+		corrections := checksumCorrections(words, position)
+		if len(corrections) == 0 {
+			fmt.Println("No single-word correction gives a valid checksum")
+		}
+		for _, correction := range corrections {
+			fmt.Printf("Possible correction: word %d %q -> %q\n", correction.position+1, words[correction.position], correction.word)
+		}
+	}
+	if !valid {
+		os.Exit(1)
+	}
+
+	passphrase, err := readPassphrase(*passphraseFile, *passphraseEnv, *usePassphrase)
+	if err != nil {
+		fmt.Printf("Error reading passphrase: %v\n", err)
+		os.Exit(1)
+	}
+	fingerprint, err := masterFingerprint(mnemonicSeed(strings.Join(words, " "), passphrase))
+	if err != nil {
+		fmt.Printf("Error deriving fingerprint: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Valid %d-word %s mnemonic\n", len(words), *wordlist)
+	fmt.Printf("Fingerprint: %s\n", fingerprint)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vaultClient calls the HTTP API of a HashiCorp Vault server, configured
+// from the environment variables the vault CLI reads
+type vaultClient struct {
+	addr      string
+	token     string
+	namespace string
+	http      *http.Client
+}
+
+// newVaultClient returns a client of the server at addr, or VAULT_ADDR. It
+// authenticates with AppRole when VAULT_ROLE_ID and VAULT_SECRET_ID are set,
+// and otherwise with the token of VAULT_TOKEN or ~/.vault-token
+func newVaultClient(addr string) (*vaultClient, error) {
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return nil, errors.New("no Vault address, set -vault-addr or VAULT_ADDR")
+	}
+	c := &vaultClient{
+		addr:      strings.TrimRight(addr, "/"),
+		namespace: os.Getenv("VAULT_NAMESPACE"),
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}
+
+	if roleID := os.Getenv("VAULT_ROLE_ID"); roleID != "" {
+		var login struct {
+			Auth struct {
+				ClientToken string `json:"client_token"`
+			} `json:"auth"`
+		}
+		body := map[string]string{"role_id": roleID, "secret_id": os.Getenv("VAULT_SECRET_ID")}
+		if err := c.request(http.MethodPost, "auth/approle/login", body, &login); err != nil {
+			return nil, fmt.Errorf("AppRole login: %w", err)
+		}
+		c.token = login.Auth.ClientToken
+		return c, nil
+	}
+
+	c.token = os.Getenv("VAULT_TOKEN")
+	if c.token == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(home, ".vault-token"))
+		if err != nil {
+			return nil, errors.New("no Vault token, set VAULT_TOKEN, log in with the vault CLI, or set VAULT_ROLE_ID and VAULT_SECRET_ID")
+		}
+		c.token = strings.TrimSpace(string(data))
+	}
+	return c, nil
+}
+
+// request calls the API at /v1/path with body as JSON, decoding the response
+// into out unless it is nil
+func (c *vaultClient) request(method, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, c.addr+"/v1/"+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("X-Vault-Token", c.token)
+	}
+	if c.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.namespace)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respData, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("%s %s: %s", method, path, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	if out == nil || len(respData) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respData, out)
+}
+
+// splitVaultPath splits a -vault-path into the mount of the KV v2 engine, its
+// first segment, and the path of the secrets in it
+func splitVaultPath(path string) (string, string, error) {
+	mount, rest, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if mount == "" {
+		return "", "", errors.New("-vault-path must start with the KV v2 mount, e.g. secret/wallets")
+	}
+	return mount, rest, nil
+}
+
+// writeVault writes each keypair of result, numbered from startIndex, to the
+// KV v2 engine as the secret <path>/<address>. The secret holds the private
+// key, address and chain-specific values, some of which are secret; the key
+// type, index, network and derivation path are set as its custom metadata,
+// which can be listed without reading the key. Existing secrets are never
+// overwritten. The number of secrets written is returned
+func writeVault(addr, path string, result KeyGenResult, startIndex int) (int, error) {
+	mount, prefix, err := splitVaultPath(path)
+	if err != nil {
+		return 0, err
+	}
+	c, err := newVaultClient(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	paths := result.Extra["derivationPath"]
+	for i, publicKey := range result.PublicKeys {
+		name := strings.TrimLeft(prefix+"/"+publicKey, "/")
+		data := map[string]string{"address": publicKey}
+		if i < len(result.PrivateKeys) {
+			data["privateKey"] = result.PrivateKeys[i]
+		}
+		for k, values := range result.Extra {
+			if k != "derivationPath" && i < len(values) {
+				data[k] = values[i]
+			}
+		}
+		// check-and-set 0 only creates the secret if it does not exist
+		body := map[string]any{"data": data, "options": map[string]int{"cas": 0}}
+		if err := c.request(http.MethodPost, mount+"/data/"+name, body, nil); err != nil {
+			return i, err
+		}
+
+		metadata := map[string]string{
+			"keyType":   result.KeyType,
+			"index":     strconv.Itoa(startIndex + i),
+			"generated": result.Timestamp,
+		}
+		if result.Network != "" {
+			metadata["network"] = result.Network
+		}
+		if i < len(paths) {
+			metadata["derivationPath"] = paths[i]
+		}
+		if err := c.request(http.MethodPost, mount+"/metadata/"+name, map[string]any{"custom_metadata": metadata}, nil); err != nil {
+			return i, err
+		}
+	}
+	return len(result.PublicKeys), nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WalletAccount is a keypair of one chain in a wallet bundle
+type WalletAccount struct {
+	DerivationPath string            `json:"derivationPath"`
+	PrivateKey     string            `json:"privateKey"`
+	Address        string            `json:"address"`
+	Extra          map[string]string `json:"extra,omitempty"`
+}
+
+// WalletBundle is the accounts of every chain derived from one mnemonic
+type WalletBundle struct {
+	Mnemonic string                     `json:"mnemonic"`
+	Accounts map[string][]WalletAccount `json:"accounts"`
+}
+
+// WalletResult is the output of the wallet command
+type WalletResult struct {
+	Count     int            `json:"count"`
+	Timestamp string         `json:"timestamp"`
+	Chains    []string       `json:"chains"`
+	Network   string         `json:"network,omitempty"`
+	HRP       string         `json:"hrp,omitempty"`
+	Wallets   []WalletBundle `json:"wallets"`
+}
+
+// deriveWalletBundle derives the first accounts of every chain from seed at
+// the chains' standard paths
+func deriveWalletBundle(mnemonic string, seed []byte, chains []string, accounts int, opts derivationOptions) (WalletBundle, error) {
+	bundle := WalletBundle{Mnemonic: mnemonic, Accounts: make(map[string][]WalletAccount)}
+	for _, chain := range chains {
+		deriver := mnemonicDerivers[chain]
+		for index := 0; index < accounts; index++ {
+			path, err := deriver.path(index, opts)
+			if err != nil {
+				return bundle, fmt.Errorf("%s: %w", chain, err)
+			}
+			privateKey, address, extra, err := deriver.derive(seed, path, opts)
+			if err != nil {
+				return bundle, fmt.Errorf("%s: %w", chain, err)
+			}
+			account := WalletAccount{
+				DerivationPath: formatDerivationPath(path),
+				PrivateKey:     privateKey,
+				Address:        address,
+			}
+			// The path has its own field
+			delete(extra, "derivationPath")
+			if len(extra) > 0 {
+				account.Extra = extra.values()
+			}
+			bundle.Accounts[chain] = append(bundle.Accounts[chain], account)
+		}
+	}
+	return bundle, nil
+}
+
+func runWallet(args []string) {
+	fs := flag.NewFlagSet("wallet", flag.ExitOnError)
+	chainsFlag := fs.String("chains", "evm,solana,sui", "Comma-separated chains of each wallet: "+quoteList(slices.Sorted(maps.Keys(mnemonicDerivers))))
+	count := fs.Int("count", 1, "Number of wallets, each with its own new mnemonic")
+	accounts := fs.Int("accounts", 1, "Number of accounts to derive per chain")
+	words := fs.Int("words", defaultMnemonicWords, "Number of words of the new mnemonics: 12, 15, 18, 21 or 24")
+	fromMnemonic := fs.String("from-mnemonic", "", "Derive one wallet from this existing BIP39 mnemonic instead, or '-' to prompt for it")
+	mnemonicFile := fs.String("mnemonic-file", "", "Derive one wallet from the existing BIP39 mnemonic in this file instead")
+	network := fs.String("network", "mainnet", "Network of the bitcoin accounts: 'mainnet', 'testnet', 'signet', or 'regtest'")
+	hrp := fs.String("hrp", "cosmos", "Bech32 account prefix of the cosmos accounts")
+	scheme := fs.String("scheme", "ed25519", "Signature scheme of the sui accounts: 'ed25519', 'secp256k1', or 'secp256r1'")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	chains := strings.Split(*chainsFlag, ",")
+	for i, chain := range chains {
+		chains[i] = strings.TrimSpace(chain)
+		if _, ok := mnemonicDerivers[chains[i]]; !ok {
+			fmt.Printf("Error: Chains must be among %s\n", quoteList(slices.Sorted(maps.Keys(mnemonicDerivers))))
+			os.Exit(1)
+		}
+	}
+	if *count <= 0 || *accounts <= 0 {
+		fmt.Println("Error: Count and accounts must be greater than 0")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if !slices.Contains(mnemonicWordCounts, *words) {
+		fmt.Println("Error: Words must be 12, 15, 18, 21 or 24")
+		os.Exit(1)
+	}
+	importMnemonic := *fromMnemonic != "" || *mnemonicFile != ""
+	if importMnemonic && *count != 1 {
+		fmt.Println("Error: An existing mnemonic derives a single wallet")
+		os.Exit(1)
+	}
+	if _, ok := bitcoinNetworks[*network]; !ok && slices.Contains(chains, "bitcoin") {
+		fmt.Println("Error: Network must be 'mainnet', 'testnet', 'signet', or 'regtest'")
+		os.Exit(1)
+	}
+
+	opts := derivationOptions{network: *network, hrp: *hrp, scheme: *scheme, words: *words}
+	wallets := make([]WalletBundle, 0, *count)
+	for i := 0; i < *count; i++ {
+		var mnemonic string
+		var seed []byte
+		var err error
+		if importMnemonic {
+			mnemonic, seed, err = readMnemonic(*fromMnemonic, *mnemonicFile, "")
+		} else {
+			mnemonic, seed, err = newMnemonic(rand.Reader, *words, "")
+		}
+		if err != nil {
+			fmt.Printf("Error creating mnemonic: %v\n", err)
+			os.Exit(1)
+		}
+
+		bundle, err := deriveWalletBundle(mnemonic, seed, chains, *accounts, opts)
+		if err != nil {
+			fmt.Printf("Error deriving wallet %d: %v\n", i+1, err)
+			os.Exit(1)
+		}
+		wallets = append(wallets, bundle)
+	}
+
+	result := WalletResult{
+		Count:     *count,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Chains:    chains,
+		Wallets:   wallets,
+	}
+	if slices.Contains(chains, "bitcoin") {
+		result.Network = *network
+	}
+	if slices.Contains(chains, "cosmos") {
+		result.HRP = *hrp
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error creating JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := fmt.Sprintf("wallets_%s.json", time.Now().Format("20060102_150405"))
+	if err := writeOutputFile(filename, jsonData); err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully generated %d wallets of %s and saved to %s\n", *count, strings.Join(chains, ", "), filename)
+}
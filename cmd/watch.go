@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// watchKeyTypes are the key types the watch command derives addresses of
+var watchKeyTypes = []string{"bitcoin", "evm", "tron", "cosmos"}
+
+// watchAddressTypes are the bitcoin address types of the watch command
+var watchAddressTypes = []string{"p2pkh", "p2sh-p2wpkh", "p2wpkh", "p2tr"}
+
+// WatchOnly is the extended public key the addresses of the watch command
+// are derived from
+type WatchOnly struct {
+	Xpub        string `json:"xpub"`
+	AddressType string `json:"addressType,omitempty"`
+}
+
+// slip132AddressTypes maps the purposes of SLIP-0132 prefixes to the address
+// type their accounts receive on
+var slip132AddressTypes = map[uint32]string{
+	44: "p2pkh",
+	49: "p2sh-p2wpkh",
+	84: "p2wpkh",
+}
+
+// parseAccountXpub parses an extended public key with any SLIP-0132 prefix,
+// returning the purpose and whether it is for a test network
+func parseAccountXpub(xpub string) (*hdkeychain.ExtendedKey, uint32, bool, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if key.IsPrivate() {
+		return nil, 0, false, fmt.Errorf("expected an extended public key, got a private one")
+	}
+	version := key.Version()
+	for purpose, versions := range slip132Versions {
+		if bytes.Equal(version, versions.mainnet[0][:]) {
+			return key, purpose, false, nil
+		}
+		if bytes.Equal(version, versions.testnet[0][:]) {
+			return key, purpose, true, nil
+		}
+	}
+	return nil, 0, false, fmt.Errorf("unknown extended public key version %x", version)
+}
+
+// watchBitcoinAddress encodes the address of a public key as addressType
+func watchBitcoinAddress(publicKey *btcec.PublicKey, addressType string, params *chaincfg.Params) (string, error) {
+	pubKeyHash := btcutil.Hash160(publicKey.SerializeCompressed())
+	switch addressType {
+	case "p2pkh":
+		address, err := btcutil.NewAddressPubKeyHash(pubKeyHash, params)
+		if err != nil {
+			return "", err
+		}
+		return address.EncodeAddress(), nil
+	case "p2sh-p2wpkh":
+		redeemScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+		if err != nil {
+			return "", err
+		}
+		address, err := btcutil.NewAddressScriptHash(redeemScript, params)
+		if err != nil {
+			return "", err
+		}
+		return address.EncodeAddress(), nil
+	case "p2wpkh":
+		address, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, params)
+		if err != nil {
+			return "", err
+		}
+		return address.EncodeAddress(), nil
+	case "p2tr":
+		outputKey := txscript.ComputeTaprootKeyNoScript(publicKey)
+		address, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+		if err != nil {
+			return "", err
+		}
+		return address.EncodeAddress(), nil
+	default:
+		return "", fmt.Errorf("unsupported address type: %s", addressType)
+	}
+}
+
+// watchAddress renders the address of a derived public key for keyType
+func watchAddress(keyType string, publicKey *btcec.PublicKey, addressType, hrp string, params *chaincfg.Params) (string, error) {
+	switch keyType {
+	case "bitcoin":
+		return watchBitcoinAddress(publicKey, addressType, params)
+	case "evm":
+		return crypto.PubkeyToAddress(*publicKey.ToECDSA()).Hex(), nil
+	case "tron":
+		address, _ := tronAddress(*publicKey.ToECDSA())
+		return address, nil
+	case "cosmos":
+		return encodeBech32(hrp, btcutil.Hash160(publicKey.SerializeCompressed()))
+	default:
+		return "", fmt.Errorf("unsupported key type: %s", keyType)
+	}
+}
+
+func runWatch(args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	xpub := fs.String("xpub", "", "Account-level extended public key (xpub, ypub, zpub, or tpub, upub, vpub) to derive addresses from")
+	keyType := fs.String("type", "bitcoin", "Key type the addresses are for: "+quoteList(watchKeyTypes))
+	addressType := fs.String("address-type", "", "bitcoin only: "+quoteList(watchAddressTypes)+"; defaults to the type of the key's prefix")
+	hrp := fs.String("hrp", "cosmos", "cosmos only: bech32 account prefix")
+	change := fs.Bool("change", false, "Derive change addresses (chain 1) instead of receive addresses (chain 0)")
+	startIndex := fs.Int("start-index", 0, "Index of the first address to derive")
+	count := fs.Int("count", 20, "Number of addresses to derive")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *xpub == "" {
+		fmt.Println("Error: Extended public key is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *count <= 0 || *startIndex < 0 || int64(*startIndex)+int64(*count) > hardenedOffset {
+		fmt.Println("Error: Count must be greater than 0 and indices between 0 and 2^31-1")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	account, purpose, testnet, err := parseAccountXpub(*xpub)
+	if err != nil {
+		fmt.Printf("Error parsing extended public key: %v\n", err)
+		os.Exit(1)
+	}
+
+	params := &chaincfg.MainNetParams
+	network := "mainnet"
+	if testnet {
+		params = &chaincfg.TestNet3Params
+		network = "testnet"
+	}
+
+	switch *keyType {
+	case "bitcoin":
+		if *addressType == "" {
+			*addressType = slip132AddressTypes[purpose]
+		}
+		if !slices.Contains(watchAddressTypes, *addressType) {
+			fmt.Printf("Error: Address type must be %s\n", quoteList(watchAddressTypes))
+			os.Exit(1)
+		}
+	case "evm", "tron", "cosmos":
+		if *addressType != "" || purpose != 44 {
+			fmt.Printf("Error: %s addresses are derived from plain xpub keys without an address type\n", *keyType)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Error: Key type must be %s\n", quoteList(watchKeyTypes))
+		os.Exit(1)
+	}
+
+	chain := uint32(0)
+	if *change {
+		chain = 1
+	}
+	chainKey, err := account.Derive(chain)
+	if err != nil {
+		fmt.Printf("Error deriving chain %d: %v\n", chain, err)
+		os.Exit(1)
+	}
+
+	publicKeys := make([]string, 0, *count)
+	extras := make(map[string][]string)
+	for i := *startIndex; i < *startIndex+*count; i++ {
+		child, err := chainKey.Derive(uint32(i))
+		if err != nil {
+			fmt.Printf("Error deriving address %d: %v\n", i, err)
+			os.Exit(1)
+		}
+		publicKey, err := child.ECPubKey()
+		if err != nil {
+			fmt.Printf("Error deriving address %d: %v\n", i, err)
+			os.Exit(1)
+		}
+		address, err := watchAddress(*keyType, publicKey, *addressType, *hrp, params)
+		if err != nil {
+			fmt.Printf("Error encoding address %d: %v\n", i, err)
+			os.Exit(1)
+		}
+		publicKeys = append(publicKeys, address)
+		extras["derivationPath"] = append(extras["derivationPath"], fmt.Sprintf("%d/%d", chain, i))
+		extras["publicKey"] = append(extras["publicKey"], hex.EncodeToString(publicKey.SerializeCompressed()))
+	}
+
+	result := KeyGenResult{
+		KeyType:     *keyType,
+		Count:       *count,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		PrivateKeys: []string{},
+		PublicKeys:  publicKeys,
+		WatchOnly:   &WatchOnly{Xpub: *xpub, AddressType: *addressType},
+		Extra:       extras,
+	}
+	if *keyType == "bitcoin" {
+		result.Network = network
+	}
+	if *keyType == "cosmos" {
+		result.HRP = *hrp
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error creating JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := fmt.Sprintf("%s-watch_addresses_%s.json", *keyType, time.Now().Format("20060102_150405"))
+	if err := writeOutputFile(filename, jsonData); err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Successfully derived %d watch-only %s addresses and saved to %s\n", *count, *keyType, filename)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// bip44AccountDepth is the depth of the account level m/purpose'/coin'/account'
+const bip44AccountDepth = 3
+
+// ExtendedKey is the BIP32 extended key pair of an account, from which
+// watch-only wallets derive its addresses
+type ExtendedKey struct {
+	Path string `json:"path"`
+	Xpub string `json:"xpub"`
+	Xprv string `json:"xprv,omitempty"`
+}
+
+// slip132Versions maps BIP32 purposes to the SLIP-0132 public and private
+// version bytes of bitcoin extended keys on mainnet and test networks
+var slip132Versions = map[uint32]struct{ mainnet, testnet [2][4]byte }{
+	// xpub/xprv and tpub/tprv
+	44: {mainnet: [2][4]byte{{0x04, 0x88, 0xb2, 0x1e}, {0x04, 0x88, 0xad, 0xe4}}, testnet: [2][4]byte{{0x04, 0x35, 0x87, 0xcf}, {0x04, 0x35, 0x83, 0x94}}},
+	// ypub/yprv and upub/uprv
+	49: {mainnet: [2][4]byte{{0x04, 0x9d, 0x7c, 0xb2}, {0x04, 0x9d, 0x78, 0x78}}, testnet: [2][4]byte{{0x04, 0x4a, 0x52, 0x62}, {0x04, 0x4a, 0x4e, 0x28}}},
+	// zpub/zprv and vpub/vprv
+	84: {mainnet: [2][4]byte{{0x04, 0xb2, 0x47, 0x46}, {0x04, 0xb2, 0x43, 0x0c}}, testnet: [2][4]byte{{0x04, 0x5f, 0x1c, 0xf6}, {0x04, 0x5f, 0x18, 0xbc}}},
+}
+
+// accountExtendedKeys returns the extended keys of the accounts the count
+// keypairs from start are derived under, in order. Bitcoin keys use the
+// SLIP-0132 prefixes of the path's purpose, such as zpub for BIP84, and
+// other chains the plain xpub ones
+func accountExtendedKeys(deriver mnemonicDeriver, seed []byte, start, count int, opts derivationOptions, bitcoin bool) ([]ExtendedKey, error) {
+	var keys []ExtendedKey
+	var seen []string
+	for index := start; index < start+count; index++ {
+		path, err := deriver.path(index, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) <= bip44AccountDepth {
+			return nil, fmt.Errorf("%s has no account level", formatDerivationPath(path))
+		}
+		account := path[:bip44AccountDepth]
+		accountPath := formatDerivationPath(account)
+		if slices.Contains(seen, accountPath) {
+			continue
+		}
+		seen = append(seen, accountPath)
+
+		xprv, err := bip32ExtendedKey(seed, account)
+		if err != nil {
+			return nil, err
+		}
+		xpub, err := xprv.Neuter()
+		if err != nil {
+			return nil, err
+		}
+
+		if versions, ok := slip132Versions[account[0]-hardenedOffset]; bitcoin && ok {
+			version := versions.mainnet
+			if opts.network != "mainnet" {
+				version = versions.testnet
+			}
+			if xpub, err = xpub.CloneWithVersion(version[0][:]); err != nil {
+				return nil, err
+			}
+			if xprv, err = xprv.CloneWithVersion(version[1][:]); err != nil {
+				return nil, err
+			}
+		}
+		keys = append(keys, ExtendedKey{Path: accountPath, Xpub: xpub.String(), Xprv: xprv.String()})
+	}
+	return keys, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/hex"
+	"io"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// zcashNetworks holds the WIF version byte and two-byte transparent P2PKH
+// prefix for each -network value
+var zcashNetworks = map[string]struct {
+	params *chaincfg.Params
+	p2pkh  []byte
+}{
+	"mainnet": {&chaincfg.Params{Name: "zcash", PrivateKeyID: 0x80}, []byte{0x1c, 0xb8}},
+	"testnet": {&chaincfg.Params{Name: "zcash-testnet", PrivateKeyID: 0xef}, []byte{0x1d, 0x25}},
+}
+
+// generateZcashKeyPair returns a WIF key and its transparent t1 (or tm on
+// testnet) address, with the compressed public key as an extra
+func generateZcashKeyPair(random io.Reader, network string) (string, string, keyExtras, error) {
+	net := zcashNetworks[network]
+
+	privateKey, err := newSecp256k1Key(random)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	wif, err := btcutil.NewWIF(privateKey, net.params, true)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	pubKeyBytes := privateKey.PubKey().SerializeCompressed()
+	address := base58CheckEncode(net.p2pkh, btcutil.Hash160(pubKeyBytes))
+
+	extra := keyExtras{
+		"publicKey": publicExtra(hex.EncodeToString(pubKeyBytes)),
+	}
+
+	return wif.String(), address, extra, nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+)
+
+// Maximum lengths of the JWT values the zkLogin circuit hashes, and the bits
+// of each packed field element
+const (
+	zkLoginMaxKeyClaimNameLength  = 32
+	zkLoginMaxKeyClaimValueLength = 115
+	zkLoginMaxAudLength           = 145
+	zkLoginPackWidth              = 248
+	zkLoginNonceBytes             = 20
+	zkLoginSaltBits               = 128
+	zkLoginRandomnessBytes        = 16
+)
+
+// SuiZkLogin describes the zkLogin account of one OAuth identity and salt
+type SuiZkLogin struct {
+	Address       string `json:"address"`
+	AddressSeed   string `json:"addressSeed"`
+	Issuer        string `json:"iss"`
+	Audience      string `json:"aud"`
+	KeyClaimName  string `json:"keyClaimName"`
+	KeyClaimValue string `json:"keyClaimValue"`
+	Salt          string `json:"salt"`
+	MaxEpoch      uint64 `json:"maxEpoch"`
+}
+
+// zkLoginHashString packs an ASCII string zero-padded to maxLength into
+// 31-byte big-endian field elements, aligned from the end, and hashes them
+func zkLoginHashString(s string, maxLength int) (*big.Int, error) {
+	if len(s) > maxLength {
+		return nil, fmt.Errorf("%q is longer than %d characters", s, maxLength)
+	}
+	padded := make([]byte, maxLength)
+	copy(padded, s)
+
+	chunkSize := zkLoginPackWidth / 8
+	var chunks []*big.Int
+	for end := len(padded); end > 0; end -= chunkSize {
+		start := max(end-chunkSize, 0)
+		chunks = append([]*big.Int{new(big.Int).SetBytes(padded[start:end])}, chunks...)
+	}
+	return poseidonHash(chunks)
+}
+
+// zkLoginAddressSeed computes the address seed of a JWT key claim, audience
+// and user salt as the Sui SDKs' genAddressSeed does
+func zkLoginAddressSeed(salt *big.Int, claimName, claimValue, aud string) (*big.Int, error) {
+	name, err := zkLoginHashString(claimName, zkLoginMaxKeyClaimNameLength)
+	if err != nil {
+		return nil, fmt.Errorf("key claim name: %w", err)
+	}
+	value, err := zkLoginHashString(claimValue, zkLoginMaxKeyClaimValueLength)
+	if err != nil {
+		return nil, fmt.Errorf("key claim value: %w", err)
+	}
+	audience, err := zkLoginHashString(aud, zkLoginMaxAudLength)
+	if err != nil {
+		return nil, fmt.Errorf("aud: %w", err)
+	}
+	saltHash, err := poseidonHash([]*big.Int{salt})
+	if err != nil {
+		return nil, err
+	}
+	return poseidonHash([]*big.Int{name, value, audience, saltHash})
+}
+
+// zkLoginAddress returns the Sui address of an address seed under issuer,
+// blake2b-256(0x05 || len(iss) || iss || seed)
+func zkLoginAddress(iss string, addressSeed *big.Int) (string, error) {
+	// Google tokens may omit the scheme, but addresses use the full issuer
+	if iss == "accounts.google.com" {
+		iss = "https://accounts.google.com"
+	}
+	if len(iss) > 255 {
+		return "", fmt.Errorf("issuer is longer than 255 characters")
+	}
+
+	data := append([]byte{byte(len(iss))}, iss...)
+	data = append(data, addressSeed.FillBytes(make([]byte, 32))...)
+	return suiAddress(zkLoginFlag, data), nil
+}
+
+// zkLoginNonce returns the OAuth nonce binding an ephemeral ed25519 public
+// key to maxEpoch and randomness, as the Sui SDKs' generateNonce does
+func zkLoginNonce(publicKey ed25519.PublicKey, maxEpoch uint64, randomness *big.Int) (string, error) {
+	suiPublicKey := new(big.Int).SetBytes(append([]byte{ed25519Flag}, publicKey...))
+	high := new(big.Int).Rsh(suiPublicKey, 128)
+	low := new(big.Int).Sub(suiPublicKey, new(big.Int).Lsh(high, 128))
+
+	hash, err := poseidonHash([]*big.Int{high, low, new(big.Int).SetUint64(maxEpoch), randomness})
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the low 20 bytes of the hash
+	nonce := make([]byte, zkLoginNonceBytes)
+	hash.Mod(hash, new(big.Int).Lsh(big.NewInt(1), zkLoginNonceBytes*8)).FillBytes(nonce)
+	return base64.RawURLEncoding.EncodeToString(nonce), nil
+}
+
+// generateZkLoginEphemeralKeyPair returns a suiprivkey ephemeral ed25519 key
+// and its base64 flag || public key, with a fresh randomness and the nonce to
+// request the JWT with as extras
+func generateZkLoginEphemeralKeyPair(maxEpoch uint64) (string, string, map[string]string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	randomnessBytes := make([]byte, zkLoginRandomnessBytes)
+	if _, err := rand.Read(randomnessBytes); err != nil {
+		return "", "", nil, err
+	}
+	randomness := new(big.Int).SetBytes(randomnessBytes)
+
+	nonce, err := zkLoginNonce(publicKey, maxEpoch, randomness)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("error computing nonce: %w", err)
+	}
+	privateKeyStr, err := encodeSuiPrivateKey(ed25519Flag, privateKey.Seed())
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	extra := map[string]string{
+		"nonce":      nonce,
+		"randomness": randomness.String(),
+	}
+
+	return privateKeyStr, base64.StdEncoding.EncodeToString(append([]byte{ed25519Flag}, publicKey...)), extra, nil
+}
+
+// runZkLogin implements the zklogin subcommand, which computes the Sui
+// zkLogin address of an OAuth identity and generates ephemeral keypairs with
+// the nonces to start the OAuth flow with
+func runZkLogin(args []string) {
+	fs := flag.NewFlagSet("zklogin", flag.ExitOnError)
+	iss := fs.String("iss", "", "OAuth provider issuer (iss claim), e.g. 'https://accounts.google.com'")
+	aud := fs.String("aud", "", "OAuth client ID (aud claim)")
+	claimName := fs.String("claim-name", "sub", "JWT claim identifying the user")
+	claimValue := fs.String("sub", "", "Value of the identifying claim, the user's sub by default")
+	saltFlag := fs.String("salt", "", "User salt as a decimal or 0x hex integer below 2^128; a random salt is generated when empty")
+	maxEpoch := fs.Uint64("max-epoch", 0, "Last Sui epoch the ephemeral keys are valid for")
+	count := fs.Int("count", 1, "Number of ephemeral keypairs to generate")
+	files := addFileFlags(fs)
+	fs.Parse(args)
+	if err := files.apply(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *iss == "" || *aud == "" || *claimValue == "" {
+		fmt.Println("Error: Issuer, audience and sub are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *maxEpoch == 0 {
+		fmt.Println("Error: Max epoch is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *count <= 0 {
+		fmt.Println("Error: Count must be greater than 0")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	saltLimit := new(big.Int).Lsh(big.NewInt(1), zkLoginSaltBits)
+	var salt *big.Int
+	if *saltFlag == "" {
+		var err error
+		salt, err = rand.Int(rand.Reader, saltLimit)
+		if err != nil {
+			fmt.Printf("Error generating salt: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		var ok bool
+		salt, ok = new(big.Int).SetString(*saltFlag, 0)
+		if !ok || salt.Sign() < 0 || salt.Cmp(saltLimit) >= 0 {
+			fmt.Println("Error: Salt must be an integer between 0 and 2^128")
+			fs.Usage()
+			os.Exit(1)
+		}
+	}
+
+	addressSeed, err := zkLoginAddressSeed(salt, *claimName, *claimValue, *aud)
+	if err != nil {
+		fmt.Printf("Error computing address seed: %v\n", err)
+		os.Exit(1)
+	}
+	address, err := zkLoginAddress(*iss, addressSeed)
+	if err != nil {
+		fmt.Printf("Error computing address: %v\n", err)
+		os.Exit(1)
+	}
+
+	privateKeys := make([]string, 0, *count)
+	publicKeys := make([]string, 0, *count)
+	extras := make(map[string][]string)
+	for i := 0; i < *count; i++ {
+		privateKey, publicKey, extra, err := generateZkLoginEphemeralKeyPair(*maxEpoch)
+		if err != nil {
+			fmt.Printf("Error generating ephemeral keypair %d: %v\n", i+1, err)
+			os.Exit(1)
+		}
+		privateKeys = append(privateKeys, privateKey)
+		publicKeys = append(publicKeys, publicKey)
+		for k, v := range extra {
+			extras[k] = append(extras[k], v)
+		}
+	}
+
+	result := KeyGenResult{
+		KeyType:     "sui-zklogin",
+		Count:       *count,
+		Timestamp:   time.Now().Format(time.RFC3339),
+		PrivateKeys: privateKeys,
+		PublicKeys:  publicKeys,
+		Scheme:      "ed25519",
+		ZkLogin: &SuiZkLogin{
+			Address:       address,
+			AddressSeed:   addressSeed.String(),
+			Issuer:        *iss,
+			Audience:      *aud,
+			KeyClaimName:  *claimName,
+			KeyClaimValue: *claimValue,
+			Salt:          salt.String(),
+			MaxEpoch:      *maxEpoch,
+		},
+		Extra: extras,
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Printf("Error creating JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	filename := fmt.Sprintf("sui-zklogin_keys_%s.json", time.Now().Format("20060102_150405"))
+	if err := writeOutputFile(filename, jsonData); err != nil {
+		fmt.Printf("Error writing to file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("zkLogin address %s with %d ephemeral keypairs saved to %s\n", address, *count, filename)
+}